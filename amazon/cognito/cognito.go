@@ -0,0 +1,194 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cognito implements the Amazon Cognito user pool sign-in and
+// refresh flows, for applications that authenticate users directly
+// instead of through the hosted UI returned by endpoints.AWSCognito.
+package cognito // import "golang.org/x/oauth2/amazon/cognito"
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/internal"
+)
+
+// Config describes a Cognito user pool app client.
+type Config struct {
+	// Region is the AWS region of the user pool, such as "us-east-1".
+	Region string
+
+	// ClientID is the app client ID.
+	ClientID string
+
+	// ClientSecret is the app client secret, if the app client has
+	// one. It is used to compute the SECRET_HASH of every request.
+	ClientSecret string
+
+	// Endpoint optionally overrides the Cognito Identity Provider
+	// endpoint. The default is "https://cognito-idp.REGION.amazonaws.com/".
+	Endpoint string
+}
+
+// PasswordCredentialsToken signs in username with the USER_PASSWORD_AUTH
+// flow, which must be enabled for the app client. The returned Token's
+// "id_token" extra holds the user's ID token.
+//
+// If Cognito responds with a challenge, such as NEW_PASSWORD_REQUIRED or
+// SOFTWARE_TOKEN_MFA, an error naming the challenge is returned.
+func (c *Config) PasswordCredentialsToken(ctx context.Context, username, password string) (*oauth2.Token, error) {
+	params := map[string]string{
+		"USERNAME": username,
+		"PASSWORD": password,
+	}
+	return c.initiateAuth(ctx, "USER_PASSWORD_AUTH", username, params)
+}
+
+// TokenSource returns a TokenSource that returns t until it expires,
+// then refreshes it with the REFRESH_TOKEN_AUTH flow. Username is used
+// only to compute the SECRET_HASH and may be empty if the app client
+// has no secret.
+func (c *Config) TokenSource(ctx context.Context, username string, t *oauth2.Token) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(t, &refreshSource{
+		ctx:          ctx,
+		conf:         c,
+		username:     username,
+		refreshToken: t.RefreshToken,
+	})
+}
+
+type refreshSource struct {
+	ctx          context.Context
+	conf         *Config
+	username     string
+	refreshToken string
+}
+
+func (s *refreshSource) Token() (*oauth2.Token, error) {
+	if s.refreshToken == "" {
+		return nil, errors.New("cognito: token expired and refresh token is not set")
+	}
+	params := map[string]string{"REFRESH_TOKEN": s.refreshToken}
+	tok, err := s.conf.initiateAuth(s.ctx, "REFRESH_TOKEN_AUTH", s.username, params)
+	if err != nil {
+		return nil, err
+	}
+	// Cognito does not rotate refresh tokens by default.
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = s.refreshToken
+	}
+	s.refreshToken = tok.RefreshToken
+	return tok, nil
+}
+
+// secretHash returns the SECRET_HASH for username, or "" if c has no
+// client secret.
+func (c *Config) secretHash(username string) string {
+	if c.ClientSecret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(c.ClientSecret))
+	mac.Write([]byte(username + c.ClientID))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Config) endpoint() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	return "https://cognito-idp." + c.Region + ".amazonaws.com/"
+}
+
+type authenticationResult struct {
+	AccessToken  string `json:"AccessToken"`
+	ExpiresIn    int64  `json:"ExpiresIn"`
+	IdToken      string `json:"IdToken"`
+	RefreshToken string `json:"RefreshToken"`
+	TokenType    string `json:"TokenType"`
+}
+
+// initiateAuth calls the InitiateAuth API with the given flow.
+func (c *Config) initiateAuth(ctx context.Context, flow, username string, params map[string]string) (*oauth2.Token, error) {
+	if c.ClientID == "" || (c.Region == "" && c.Endpoint == "") {
+		return nil, errors.New("cognito: ClientID and Region must be set")
+	}
+	if h := c.secretHash(username); h != "" {
+		params["SECRET_HASH"] = h
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"AuthFlow":       flow,
+		"ClientId":       c.ClientID,
+		"AuthParameters": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSCognitoIdentityProviderService.InitiateAuth")
+	res, err := internal.ContextClient(ctx).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cognito: cannot fetch token: %v", err)
+	}
+	defer res.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("cognito: cannot fetch token: %v", err)
+	}
+	if c := res.StatusCode; c < 200 || c > 299 {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		// Error types may be prefixed with a namespace ending in '#'.
+		code := apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+		return nil, &oauth2.RetrieveError{
+			Response:         res,
+			Body:             respBody,
+			ErrorCode:        code,
+			ErrorDescription: apiErr.Message,
+		}
+	}
+	var resp struct {
+		AuthenticationResult *authenticationResult `json:"AuthenticationResult"`
+		ChallengeName        string                `json:"ChallengeName"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("cognito: cannot parse response: %v", err)
+	}
+	if resp.ChallengeName != "" {
+		return nil, fmt.Errorf("cognito: sign-in requires unsupported challenge %s", resp.ChallengeName)
+	}
+	r := resp.AuthenticationResult
+	if r == nil || r.AccessToken == "" {
+		return nil, errors.New("cognito: server response missing access token")
+	}
+	tok := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: r.RefreshToken,
+	}
+	if r.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	if r.IdToken != "" {
+		tok = tok.WithExtra(map[string]interface{}{"id_token": r.IdToken})
+	}
+	return tok, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cognito
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type initiateAuthRequest struct {
+	AuthFlow       string
+	ClientId       string
+	AuthParameters map[string]string
+}
+
+func newTestServer(t *testing.T, handle func(req initiateAuthRequest) (int, string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("X-Amz-Target"), "AWSCognitoIdentityProviderService.InitiateAuth"; got != want {
+			t.Errorf("X-Amz-Target = %q; want %q", got, want)
+		}
+		var req initiateAuthRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		code, body := handle(req)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestPasswordCredentialsTokenAndRefresh(t *testing.T) {
+	conf := &Config{ClientID: "client", ClientSecret: "secret"}
+	ts := newTestServer(t, func(req initiateAuthRequest) (int, string) {
+		if req.ClientId != "client" {
+			t.Errorf("ClientId = %q; want %q", req.ClientId, "client")
+		}
+		if got, want := req.AuthParameters["SECRET_HASH"], conf.secretHash("alice"); got != want {
+			t.Errorf("SECRET_HASH = %q; want %q", got, want)
+		}
+		switch req.AuthFlow {
+		case "USER_PASSWORD_AUTH":
+			want := map[string]string{"USERNAME": "alice", "PASSWORD": "pw", "SECRET_HASH": conf.secretHash("alice")}
+			if !reflect.DeepEqual(req.AuthParameters, want) {
+				t.Errorf("AuthParameters = %v; want %v", req.AuthParameters, want)
+			}
+			return 200, `{"AuthenticationResult":{"AccessToken":"a1","ExpiresIn":1,"IdToken":"id1","RefreshToken":"r1","TokenType":"Bearer"}}`
+		case "REFRESH_TOKEN_AUTH":
+			if got := req.AuthParameters["REFRESH_TOKEN"]; got != "r1" {
+				t.Errorf("REFRESH_TOKEN = %q; want %q", got, "r1")
+			}
+			return 200, `{"AuthenticationResult":{"AccessToken":"a2","ExpiresIn":3600,"IdToken":"id2","TokenType":"Bearer"}}`
+		}
+		t.Errorf("unexpected AuthFlow %q", req.AuthFlow)
+		return 400, `{}`
+	})
+	defer ts.Close()
+	conf.Endpoint = ts.URL
+
+	tok, err := conf.PasswordCredentialsToken(context.Background(), "alice", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "a1" || tok.RefreshToken != "r1" || tok.Extra("id_token") != "id1" {
+		t.Errorf("token = %+v, id_token %v", tok, tok.Extra("id_token"))
+	}
+	// The token expires within the expiry delta, so it is refreshed.
+	tok, err = conf.TokenSource(context.Background(), "alice", tok).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "a2" || tok.RefreshToken != "r1" || tok.Extra("id_token") != "id2" {
+		t.Errorf("refreshed token = %+v, id_token %v", tok, tok.Extra("id_token"))
+	}
+	if !tok.Expiry.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expiry = %v; want about an hour from now", tok.Expiry)
+	}
+}
+
+func TestPasswordCredentialsTokenError(t *testing.T) {
+	ts := newTestServer(t, func(req initiateAuthRequest) (int, string) {
+		return 400, `{"__type":"NotAuthorizedException","message":"Incorrect username or password."}`
+	})
+	defer ts.Close()
+	conf := &Config{ClientID: "client", Endpoint: ts.URL}
+	_, err := conf.PasswordCredentialsToken(context.Background(), "alice", "wrong")
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.ErrorCode != "NotAuthorizedException" || re.ErrorDescription != "Incorrect username or password." {
+		t.Errorf("err = %v; want RetrieveError with NotAuthorizedException", err)
+	}
+}
+
+func TestPasswordCredentialsTokenChallenge(t *testing.T) {
+	ts := newTestServer(t, func(req initiateAuthRequest) (int, string) {
+		if _, ok := req.AuthParameters["SECRET_HASH"]; ok {
+			t.Error("SECRET_HASH sent without a client secret")
+		}
+		return 200, `{"ChallengeName":"NEW_PASSWORD_REQUIRED","Session":"s"}`
+	})
+	defer ts.Close()
+	conf := &Config{ClientID: "client", Endpoint: ts.URL}
+	if _, err := conf.PasswordCredentialsToken(context.Background(), "alice", "pw"); err == nil {
+		t.Error("PasswordCredentialsToken succeeded despite challenge")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ResponseMode is the value of the "response_mode" parameter, which
+// selects how the authorization response is delivered to the redirect
+// URI. See ParseAuthResponse and ParseAuthResponseURL for reading each
+// mode.
+type ResponseMode string
+
+const (
+	ResponseModeQuery    ResponseMode = "query"
+	ResponseModeFragment ResponseMode = "fragment"
+	ResponseModeFormPost ResponseMode = "form_post"
+)
+
+// ResponseModeOption returns an AuthCodeOption that sets the
+// "response_mode" parameter. It only applies to Config.AuthCodeURL.
+func ResponseModeOption(mode ResponseMode) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("response_mode", string(mode)))
+}
+
+// Prompt is a value of the OpenID Connect "prompt" parameter.
+type Prompt string
+
+const (
+	PromptNone          Prompt = "none"
+	PromptLogin         Prompt = "login"
+	PromptConsent       Prompt = "consent"
+	PromptSelectAccount Prompt = "select_account"
+)
+
+// PromptOption returns an AuthCodeOption that sets the "prompt"
+// parameter to the given values. It reports an error if prompts is
+// empty, repeats a value, contains an empty value or a value with
+// spaces, or combines PromptNone with other values. It only applies to
+// Config.AuthCodeURL.
+func PromptOption(prompts ...Prompt) (AuthCodeOption, error) {
+	if len(prompts) == 0 {
+		return nil, errors.New("oauth2: no prompt values")
+	}
+	seen := make(map[Prompt]bool)
+	vals := make([]string, len(prompts))
+	for i, p := range prompts {
+		if p == "" || strings.ContainsAny(string(p), " \t\n") {
+			return nil, fmt.Errorf("oauth2: invalid prompt value %q", p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("oauth2: repeated prompt value %q", p)
+		}
+		seen[p] = true
+		vals[i] = string(p)
+	}
+	if seen[PromptNone] && len(prompts) > 1 {
+		return nil, errors.New("oauth2: prompt value \"none\" cannot be combined with others")
+	}
+	return AuthURLOnly(SetAuthURLParam("prompt", strings.Join(vals, " "))), nil
+}
+
+// LoginHintOption returns an AuthCodeOption that sets the "login_hint"
+// parameter, typically the user's email address, to prefill the login
+// form. It only applies to Config.AuthCodeURL.
+func LoginHintOption(hint string) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("login_hint", hint))
+}
+
+// DomainHintOption returns an AuthCodeOption that sets the "domain_hint"
+// parameter, which Azure AD uses to skip home realm discovery for users
+// of the given domain. It only applies to Config.AuthCodeURL.
+func DomainHintOption(domain string) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("domain_hint", domain))
+}
+
+// GrantTypeOption returns an AuthCodeOption that replaces the
+// "grant_type" parameter of token requests, for providers that expect a
+// variant of the standard grant type in Exchange. It is ignored by
+// AuthCodeURL.
+func GrantTypeOption(grantType string) AuthCodeOption {
+	return TokenRequestOnly(setParam{"grant_type", grantType})
+}
+
+// ResourceOption returns an AuthCodeOption that sets the RFC 8707
+// "resource" parameter to the given target resources. It applies to
+// AuthCodeURL and to token requests; use AuthURLOnly or TokenRequestOnly
+// to restrict it.
+func ResourceOption(resources ...string) AuthCodeOption {
+	return SetParams(url.Values{"resource": resources})
+}
+
+// AudienceOption returns an AuthCodeOption that sets the "audience"
+// parameter used by providers such as Auth0 to select the API that
+// access tokens are for. It applies to AuthCodeURL and to token
+// requests; use AuthURLOnly or TokenRequestOnly to restrict it.
+func AudienceOption(audience string) AuthCodeOption {
+	return SetAuthURLParam("audience", audience)
+}
+
+// ErrNonceMismatch is returned by VerifyIDTokenNonce when the nonce
+// claim of an ID token does not match the expected nonce.
+var ErrNonceMismatch = errors.New("oauth2: ID token nonce mismatch")
+
+// GenerateNonce generates a nonce with 32 octets of randomness.
+//
+// A fresh nonce should be generated for each authorization and stored
+// with the state. NonceOption(nonce) should then be passed to
+// Config.AuthCodeURL, and VerifyIDTokenNonce used to check the ID token
+// returned for the authorization.
+func GenerateNonce() string {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// NonceOption returns an AuthCodeOption that sets the OpenID Connect
+// "nonce" parameter. It only applies to Config.AuthCodeURL.
+func NonceOption(nonce string) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("nonce", nonce))
+}
+
+// VerifyIDTokenNonce reports whether the "nonce" claim of the ID token
+// idToken is nonce, returning ErrNonceMismatch if not. It does not
+// verify the signature or any other claim of the ID token, which must be
+// done separately.
+func VerifyIDTokenNonce(idToken, nonce string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return errors.New("oauth2: malformed ID token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("oauth2: malformed ID token: %v", err)
+	}
+	var claims struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return fmt.Errorf("oauth2: malformed ID token: %v", err)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return ErrNonceMismatch
+	}
+	return nil
+}
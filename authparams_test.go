@@ -0,0 +1,139 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAuthParamOptions(t *testing.T) {
+	prompt, err := PromptOption(PromptLogin, PromptConsent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := newConf("https://example.com")
+	u, err := url.Parse(conf.AuthCodeURL("state",
+		ResponseModeOption(ResponseModeFormPost),
+		prompt,
+		LoginHintOption("gopher@example.com"),
+		DomainHintOption("example.com"),
+		NonceOption("n-0S6_WzA2Mj"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	for k, want := range map[string]string{
+		"response_mode": "form_post",
+		"prompt":        "login consent",
+		"login_hint":    "gopher@example.com",
+		"domain_hint":   "example.com",
+		"nonce":         "n-0S6_WzA2Mj",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q; want %q", k, got, want)
+		}
+	}
+}
+
+func TestAuthParamOptionsNotInTokenRequest(t *testing.T) {
+	prompt, err := PromptOption(PromptLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		for _, k := range []string{"response_mode", "prompt", "login_hint", "domain_hint", "nonce"} {
+			if v, ok := r.PostForm[k]; ok {
+				t.Errorf("token request has %s = %q", k, v)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	defer ts.Close()
+	conf := newConf(ts.URL)
+	_, err = conf.Exchange(context.Background(), "exchange-code",
+		ResponseModeOption(ResponseModeFormPost),
+		prompt,
+		LoginHintOption("gopher@example.com"),
+		DomainHintOption("example.com"),
+		NonceOption("n-0S6_WzA2Mj"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPromptOptionErrors(t *testing.T) {
+	for _, prompts := range [][]Prompt{
+		nil,
+		{""},
+		{"select account"},
+		{PromptLogin, PromptLogin},
+		{PromptNone, PromptConsent},
+	} {
+		if _, err := PromptOption(prompts...); err == nil {
+			t.Errorf("PromptOption(%q) succeeded", prompts)
+		}
+	}
+}
+
+func TestVerifyIDTokenNonce(t *testing.T) {
+	nonce := GenerateNonce()
+	if other := GenerateNonce(); other == nonce {
+		t.Fatalf("GenerateNonce returned %q twice", nonce)
+	}
+	idToken := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"nonce":"`+nonce+`"}`)) + ".sig"
+	if err := VerifyIDTokenNonce(idToken, nonce); err != nil {
+		t.Errorf("VerifyIDTokenNonce = %v", err)
+	}
+	if err := VerifyIDTokenNonce(idToken, "other"); err != ErrNonceMismatch {
+		t.Errorf("VerifyIDTokenNonce with another nonce = %v; want ErrNonceMismatch", err)
+	}
+	if err := VerifyIDTokenNonce("not-a-jwt", nonce); err == nil || err == ErrNonceMismatch {
+		t.Errorf("VerifyIDTokenNonce of a malformed token = %v; want a malformed token error", err)
+	}
+}
+
+func TestExchangeOptions(t *testing.T) {
+	ts := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		for k, want := range map[string][]string{
+			"grant_type":    {"urn:twitch:extension_code"},
+			"code":          {"exchange-code"},
+			"code_verifier": {"verifier"},
+			"resource":      {"https://api1", "https://api2"},
+			"audience":      {"aud"},
+		} {
+			if got := r.PostForm[k]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s = %q; want %q", k, got, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	defer ts.Close()
+	conf := newConf(ts.URL)
+	_, err := conf.Exchange(context.Background(), "exchange-code",
+		GrantTypeOption("urn:twitch:extension_code"),
+		VerifierOption("verifier"),
+		ResourceOption("https://api1", "https://api2"),
+		AudienceOption("aud"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse(conf.AuthCodeURL("state", GrantTypeOption("ignored"), ResourceOption("https://api1")))
+	if q := u.Query(); q.Get("grant_type") != "" || q.Get("resource") != "https://api1" {
+		t.Errorf("AuthCodeURL = %s; want resource and no grant_type", u)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrStateMismatch is returned when the state parameter of an
+// authorization response does not match the expected state.
+var ErrStateMismatch = errors.New("oauth2: authorization response state mismatch")
+
+// AuthResponse is a successful authorization response, as delivered to
+// the redirect URI.
+type AuthResponse struct {
+	// Code is the authorization code, for the authorization code
+	// and hybrid flows.
+	Code string
+
+	// State is the state parameter.
+	State string
+
+	// AccessToken, TokenType, ExpiresIn and Scope are returned by
+	// the implicit flow and by hybrid flows that include "token" in
+	// the response type.
+	AccessToken string
+	TokenType   string
+	ExpiresIn   int64
+	Scope       string
+
+	// IDToken is the OpenID Connect ID token, for response types
+	// that include "id_token".
+	IDToken string
+
+	// Issuer is the "iss" parameter of RFC 9207, if present.
+	Issuer string
+
+	// Params holds all the parameters of the response.
+	Params url.Values
+}
+
+// AuthError is an error authorization response.
+// See https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.2.1.
+type AuthError struct {
+	// ErrorCode is the "error" parameter, such as "access_denied".
+	ErrorCode string
+	// ErrorDescription is the "error_description" parameter.
+	ErrorDescription string
+	// ErrorURI is the "error_uri" parameter.
+	ErrorURI string
+	// State is the "state" parameter.
+	State string
+}
+
+func (e *AuthError) Error() string {
+	s := fmt.Sprintf("oauth2: authorization failed: %q", e.ErrorCode)
+	if e.ErrorDescription != "" {
+		s += fmt.Sprintf(" %q", e.ErrorDescription)
+	}
+	if e.ErrorURI != "" {
+		s += fmt.Sprintf(" %q", e.ErrorURI)
+	}
+	return s
+}
+
+// ParseAuthResponse parses the authorization response delivered to the
+// redirect URI handler in r. It accepts responses in the query string
+// (response_mode=query) and in the body of a POST request
+// (response_mode=form_post). Responses in the URL fragment are not sent
+// to servers; see ParseAuthResponseURL for those.
+//
+// If state is not empty, the response's state parameter must equal it,
+// compared in constant time, or ErrStateMismatch is returned. The state
+// is checked before anything else, so that error responses from
+// forged requests are not reported. An error response from the
+// authorization server is returned as an *AuthError.
+func ParseAuthResponse(r *http.Request, state string) (*AuthResponse, error) {
+	v := r.URL.Query()
+	if r.Method == "POST" {
+		ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if ct == "application/x-www-form-urlencoded" {
+			if err := r.ParseForm(); err != nil {
+				return nil, fmt.Errorf("oauth2: cannot parse authorization response: %v", err)
+			}
+			v = r.PostForm
+		}
+	}
+	return parseAuthResponse(v, state)
+}
+
+// ParseAuthResponseURL parses an authorization response from the URL
+// the user agent was redirected to, such as one captured by a native
+// application. Parameters in the fragment, as used by the implicit and
+// hybrid flows, take precedence over those in the query string. State
+// is checked as by ParseAuthResponse.
+func ParseAuthResponseURL(rawURL, state string) (*AuthResponse, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot parse authorization response: %v", err)
+	}
+	v := u.Query()
+	if u.Fragment != "" {
+		f, err := url.ParseQuery(u.Fragment)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: cannot parse authorization response: %v", err)
+		}
+		for k, vv := range f {
+			v[k] = vv
+		}
+	}
+	return parseAuthResponse(v, state)
+}
+
+func parseAuthResponse(v url.Values, state string) (*AuthResponse, error) {
+	if state != "" && subtle.ConstantTimeCompare([]byte(v.Get("state")), []byte(state)) != 1 {
+		return nil, ErrStateMismatch
+	}
+	if code := v.Get("error"); code != "" {
+		return nil, &AuthError{
+			ErrorCode:        code,
+			ErrorDescription: v.Get("error_description"),
+			ErrorURI:         v.Get("error_uri"),
+			State:            v.Get("state"),
+		}
+	}
+	res := &AuthResponse{
+		Code:        v.Get("code"),
+		State:       v.Get("state"),
+		AccessToken: v.Get("access_token"),
+		TokenType:   v.Get("token_type"),
+		Scope:       v.Get("scope"),
+		IDToken:     v.Get("id_token"),
+		Issuer:      v.Get("iss"),
+		Params:      v,
+	}
+	if e := v.Get("expires_in"); e != "" {
+		n, err := strconv.ParseInt(strings.TrimSpace(e), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: invalid expires_in %q in authorization response", e)
+		}
+		res.ExpiresIn = n
+	}
+	if res.Code == "" && res.AccessToken == "" && res.IDToken == "" {
+		return nil, errors.New("oauth2: authorization response has no code or token")
+	}
+	return res, nil
+}
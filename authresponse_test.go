@@ -0,0 +1,48 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAuthResponse(t *testing.T) {
+	r := httptest.NewRequest("GET", "/callback?code=abc&state=s1&iss=https%3A%2F%2Fas", nil)
+	res, err := ParseAuthResponse(r, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "abc" || res.Issuer != "https://as" {
+		t.Errorf("response = %+v", res)
+	}
+
+	if _, err := ParseAuthResponse(r, "other"); err != ErrStateMismatch {
+		t.Errorf("wrong state: %v; want ErrStateMismatch", err)
+	}
+
+	r = httptest.NewRequest("POST", "/callback", strings.NewReader("error=access_denied&error_description=no&state=s1"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = ParseAuthResponse(r, "s1")
+	var ae *AuthError
+	if !errors.As(err, &ae) || ae.ErrorCode != "access_denied" || ae.ErrorDescription != "no" {
+		t.Errorf("form_post error response: %v; want access_denied AuthError", err)
+	}
+}
+
+func TestParseAuthResponseURL(t *testing.T) {
+	res, err := ParseAuthResponseURL("https://app/cb?state=q#access_token=at&token_type=Bearer&expires_in=3600&id_token=idt&code=c&state=s1", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AccessToken != "at" || res.TokenType != "Bearer" || res.ExpiresIn != 3600 || res.IDToken != "idt" || res.Code != "c" || res.State != "s1" {
+		t.Errorf("response = %+v", res)
+	}
+	if _, err := ParseAuthResponseURL("https://app/cb?state=s1", "s1"); err == nil {
+		t.Error("response without code or token accepted")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2/internal"
+)
+
+func init() {
+	internal.PublicAuthStyleCache = func(c interface{}) *internal.AuthStyleCache {
+		if c, ok := c.(*AuthStyleCache); ok && c != nil {
+			return &c.c
+		}
+		return nil
+	}
+}
+
+// AuthStyleCache records which AuthStyle each token endpoint accepted
+// when Endpoint.AuthStyle is AuthStyleAutoDetect, so that the probing
+// request is made at most once per endpoint and client.
+//
+// Styles are recorded per client ID, since clients registered with the
+// same server may be configured with different authentication methods.
+// An entry recorded without a client ID applies to every client of the
+// endpoint that has no entry of its own.
+//
+// An AuthStyleCache may be shared by several Configs by setting their
+// AuthStyleCache fields, and is safe for concurrent use.
+type AuthStyleCache struct {
+	c internal.AuthStyleCache
+}
+
+// AuthStyleCacheKey identifies an entry of an AuthStyleCache.
+type AuthStyleCacheKey struct {
+	TokenURL string
+
+	// ClientID is the client the entry was recorded for, or empty
+	// if it applies to every client of the endpoint.
+	ClientID string
+}
+
+// NewAuthStyleCache returns an empty AuthStyleCache holding at most
+// maxSize entries, each used for at most ttl after it was recorded.
+// A maxSize or ttl of zero means no limit.
+func NewAuthStyleCache(maxSize int, ttl time.Duration) *AuthStyleCache {
+	return &AuthStyleCache{c: internal.AuthStyleCache{MaxSize: maxSize, TTL: ttl}}
+}
+
+// Set records that the token endpoint at tokenURL accepts style for
+// every client, which is typically used to pre-seed the cache with
+// known providers. See SetClient for the accepted styles.
+func (c *AuthStyleCache) Set(tokenURL string, style AuthStyle) {
+	c.SetClient(tokenURL, "", style)
+}
+
+// SetClient records that the token endpoint at tokenURL accepts style
+// for the client clientID. The style must be AuthStyleInParams or
+// AuthStyleInHeader; AuthStyleAutoDetect removes the entry instead, so
+// that the style is detected again by the next token request.
+// SetClient panics for other values.
+func (c *AuthStyleCache) SetClient(tokenURL, clientID string, style AuthStyle) {
+	switch style {
+	case AuthStyleAutoDetect:
+		c.c.DeleteAuthStyle(tokenURL, clientID)
+	case AuthStyleInParams, AuthStyleInHeader:
+		c.c.SetAuthStyle(tokenURL, clientID, internal.AuthStyle(style))
+	default:
+		panic(fmt.Sprintf("oauth2: invalid AuthStyle %d", style))
+	}
+}
+
+// Lookup reports which style is recorded for every client of tokenURL,
+// if any.
+func (c *AuthStyleCache) Lookup(tokenURL string) (style AuthStyle, ok bool) {
+	return c.LookupClient(tokenURL, "")
+}
+
+// LookupClient reports which style is used for the client clientID of
+// tokenURL, if any: the one recorded for clientID, or else the one
+// recorded for every client.
+func (c *AuthStyleCache) LookupClient(tokenURL, clientID string) (style AuthStyle, ok bool) {
+	s, ok := c.c.LookupAuthStyle(tokenURL, clientID)
+	return AuthStyle(s), ok
+}
+
+// Entries returns a snapshot of the cache contents. It is intended for
+// debugging.
+func (c *AuthStyleCache) Entries() map[AuthStyleCacheKey]AuthStyle {
+	m := make(map[AuthStyleCacheKey]AuthStyle)
+	for k, v := range c.c.Entries() {
+		m[AuthStyleCacheKey(k)] = AuthStyle(v)
+	}
+	return m
+}
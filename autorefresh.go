@@ -0,0 +1,132 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"sync"
+	"time"
+)
+
+// AutoRefreshOptions configures an AutoRefreshTokenSource.
+type AutoRefreshOptions struct {
+	// RefreshFraction is the fraction of a token's lifetime after
+	// which it is refreshed, between 0 and 1. Zero means 0.75.
+	RefreshFraction float64
+
+	// RetryInterval is how long to wait before retrying a failed
+	// background refresh. Zero means 10 seconds.
+	RetryInterval time.Duration
+
+	// OnError optionally is called with the error of every failed
+	// background refresh. It is called on the timer's goroutine,
+	// without holding the AutoRefreshTokenSource's lock, after the
+	// retry is scheduled, so it may call Token or Stop.
+	OnError func(error)
+}
+
+// AutoRefreshTokenSource is a TokenSource that refreshes its token on
+// a timer ahead of expiry, so callers of Token never wait for a
+// refresh once the first token has been fetched. It is intended for
+// long-lived programs; call Stop when it is no longer needed.
+type AutoRefreshTokenSource struct {
+	src  TokenSource
+	opts AutoRefreshOptions
+
+	mu      sync.Mutex // guards the fields below
+	t       *Token
+	timer   *time.Timer
+	stopped bool
+}
+
+// NewAutoRefreshTokenSource returns an AutoRefreshTokenSource that
+// fetches tokens from src. The first token is fetched by the first
+// call to Token; src should not cache tokens itself, or the background
+// refreshes will return the same token until it expires.
+func NewAutoRefreshTokenSource(src TokenSource, opts AutoRefreshOptions) *AutoRefreshTokenSource {
+	if opts.RefreshFraction <= 0 || opts.RefreshFraction > 1 {
+		opts.RefreshFraction = 0.75
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 10 * time.Second
+	}
+	return &AutoRefreshTokenSource{src: src, opts: opts}
+}
+
+// Token returns the current token, fetching one from the underlying
+// TokenSource if there is no valid token, such as before the first
+// background refresh or after background refreshes failed for longer
+// than the token's remaining lifetime.
+func (s *AutoRefreshTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.t.Valid() {
+		return s.t, nil
+	}
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.setLocked(t, timeNow())
+	return t, nil
+}
+
+// Stop stops background refreshes. Token keeps working afterwards, but
+// fetches tokens only when the current one has expired.
+func (s *AutoRefreshTokenSource) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// setLocked caches t, obtained at now, and schedules its refresh. The
+// refresh is never scheduled sooner than RetryInterval, so that a token
+// that is already expired when it is received does not make the
+// refreshes loop against the token endpoint.
+func (s *AutoRefreshTokenSource) setLocked(t *Token, now time.Time) {
+	s.t = t
+	if t.Expiry.IsZero() {
+		return
+	}
+	lifetime := t.Expiry.Sub(now)
+	d := time.Duration(float64(lifetime) * s.opts.RefreshFraction)
+	if d < s.opts.RetryInterval {
+		d = s.opts.RetryInterval
+	}
+	s.scheduleLocked(d)
+}
+
+func (s *AutoRefreshTokenSource) scheduleLocked(d time.Duration) {
+	if s.stopped {
+		return
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(d, s.refresh)
+}
+
+// refresh runs on the timer's goroutine.
+func (s *AutoRefreshTokenSource) refresh() {
+	t, err := s.src.Token()
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	if err != nil {
+		s.scheduleLocked(s.opts.RetryInterval)
+		s.mu.Unlock()
+		if s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+		return
+	}
+	s.setLocked(t, timeNow())
+	s.mu.Unlock()
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAutoRefreshTokenSource(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	fail := errors.New("temporary failure")
+	src := tokenSourceFunc(func() (*Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 2 {
+			return nil, fail
+		}
+		// Tokens live for 11s, so the default 0.75 fraction would
+		// wait minutes; the test uses a small fraction instead.
+		return &Token{AccessToken: fmt.Sprint("t", calls), Expiry: time.Now().Add(11 * time.Second)}, nil
+	})
+	errc := make(chan error, 1)
+	var ts *AutoRefreshTokenSource
+	ts = NewAutoRefreshTokenSource(src, AutoRefreshOptions{
+		RefreshFraction: 0.001,
+		RetryInterval:   time.Millisecond,
+		OnError: func(err error) {
+			// OnError may use the source without deadlocking.
+			ts.Token()
+			errc <- err
+		},
+	})
+	defer ts.Stop()
+
+	tok, err := ts.Token()
+	if err != nil || tok.AccessToken != "t1" {
+		t.Fatalf("Token() = %v, %v; want t1", tok, err)
+	}
+	if err := <-errc; err != fail {
+		t.Errorf("OnError got %v; want %v", err, fail)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tok, _ := ts.Token()
+		if tok.AccessToken != "t1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("token was not refreshed in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ts.Stop()
+	mu.Lock()
+	n := calls
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if calls > n+1 {
+		t.Errorf("%d refreshes after Stop", calls-n)
+	}
+}
+
+func TestAutoRefreshTokenSourceExpiredToken(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	src := tokenSourceFunc(func() (*Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return &Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)}, nil
+	})
+	ts := NewAutoRefreshTokenSource(src, AutoRefreshOptions{RetryInterval: time.Hour})
+	defer ts.Stop()
+	ts.Token()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Errorf("%d calls to the source for an expired token; want 1", calls)
+	}
+}
+
+type tokenSourceFunc func() (*Token, error)
+
+func (f tokenSourceFunc) Token() (*Token, error) { return f() }
@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cache persists OAuth 2.0 tokens between runs of a program,
+// typically a command-line tool, so that users authorize it once.
+package cache // import "golang.org/x/oauth2/cache"
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrNotFound is returned by TokenStore.Get when there is no token for
+// a key.
+var ErrNotFound = errors.New("oauth2/cache: token not found")
+
+// TokenStore stores tokens by key. Implementations must be safe for
+// concurrent use.
+type TokenStore interface {
+	// Get returns the token stored under key, or ErrNotFound.
+	Get(key string) (*oauth2.Token, error)
+
+	// Put stores t under key, replacing any previous token.
+	Put(key string, t *oauth2.Token) error
+
+	// Delete removes the token stored under key, if any.
+	Delete(key string) error
+}
+
+// Key returns a store key for the tokens of subject, such as a user
+// name or e-mail address, obtained with c. Tokens of different client
+// IDs, token endpoints or scopes have different keys.
+func Key(c *oauth2.Config, subject string) string {
+	h := sha256.New()
+	for _, s := range []string{c.ClientID, c.Endpoint.TokenURL, strings.Join(c.Scopes, " "), subject} {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TokenSource returns a TokenSource that returns tokens from src and
+// saves every new token in s under key.
+func TokenSource(s TokenStore, key string, src oauth2.TokenSource) oauth2.TokenSource {
+	return &storingSource{store: s, key: key, src: src}
+}
+
+// ConfigTokenSource returns a TokenSource that starts with the token
+// stored in s under key and refreshes it with c, saving refreshed tokens
+// in s. It returns ErrNotFound if s has no token for key, in which case
+// the caller should authorize the user and Put the token.
+func ConfigTokenSource(ctx context.Context, c *oauth2.Config, s TokenStore, key string) (oauth2.TokenSource, error) {
+	t, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return &storingSource{store: s, key: key, src: c.TokenSource(ctx, t), last: t}, nil
+}
+
+type storingSource struct {
+	store TokenStore
+	key   string
+	src   oauth2.TokenSource
+
+	mu   sync.Mutex
+	last *oauth2.Token // last token saved or loaded
+}
+
+func (s *storingSource) Token() (*oauth2.Token, error) {
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last != nil && s.last.AccessToken == t.AccessToken && s.last.RefreshToken == t.RefreshToken {
+		return t, nil
+	}
+	if err := s.store.Put(s.key, t); err != nil {
+		return nil, fmt.Errorf("oauth2/cache: cannot save token: %v", err)
+	}
+	s.last = t
+	return t, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestKey(t *testing.T) {
+	c := &oauth2.Config{ClientID: "id", Scopes: []string{"a", "b"}}
+	if Key(c, "alice") == Key(c, "bob") {
+		t.Error("Key is the same for different subjects")
+	}
+	c2 := *c
+	c2.Scopes = []string{"a"}
+	if Key(c, "alice") == Key(&c2, "alice") {
+		t.Error("Key is the same for different scopes")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	key := bytes.Repeat([]byte{1}, 32)
+	s := &FileStore{Path: path, Key: key}
+	if _, err := s.Get("k"); err != ErrNotFound {
+		t.Fatalf("Get on missing file: %v; want ErrNotFound", err)
+	}
+	if err := s.Put("k", &oauth2.Token{AccessToken: "secret-access", RefreshToken: "r"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret-access")) {
+		t.Error("file contains the plaintext token")
+	}
+	if fi, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v; want 0600", fi.Mode().Perm())
+	}
+
+	tok, err := (&FileStore{Path: path, Key: key}).Get("k")
+	if err != nil || tok.AccessToken != "secret-access" || tok.RefreshToken != "r" {
+		t.Fatalf("Get = %+v, %v", tok, err)
+	}
+	if _, err := (&FileStore{Path: path, Key: bytes.Repeat([]byte{2}, 32)}).Get("k"); err == nil {
+		t.Error("Get with wrong key succeeded")
+	}
+	if err := s.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("k"); err != ErrNotFound {
+		t.Errorf("Get after Delete: %v; want ErrNotFound", err)
+	}
+}
+
+func TestConfigTokenSourceSavesRefreshedToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"new","token_type":"Bearer","refresh_token":"r2","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	s := &FileStore{Path: filepath.Join(t.TempDir(), "tokens"), Key: bytes.Repeat([]byte{1}, 32)}
+	c := &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{TokenURL: ts.URL}}
+	key := Key(c, "alice")
+	if _, err := ConfigTokenSource(context.Background(), c, s, key); err != ErrNotFound {
+		t.Fatalf("ConfigTokenSource with empty store: %v; want ErrNotFound", err)
+	}
+	// An expired token with a refresh token.
+	s.Put(key, &oauth2.Token{AccessToken: "old", RefreshToken: "r1", Expiry: time.Now().Add(-time.Hour)})
+	src, err := ConfigTokenSource(context.Background(), c, s, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok, err := src.Token(); err != nil || tok.AccessToken != "new" {
+		t.Fatalf("Token() = %v, %v", tok, err)
+	}
+	saved, err := s.Get(key)
+	if err != nil || saved.AccessToken != "new" || saved.RefreshToken != "r2" {
+		t.Errorf("saved token = %+v, %v; want refreshed token", saved, err)
+	}
+}
+
+func TestKeyringStore(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test fakes secret-tool")
+	}
+	secrets := make(map[string]string)
+	old := runKeyring
+	defer func() { runKeyring = old }()
+	runKeyring = func(stdin, name string, args ...string) ([]byte, error) {
+		if name != "secret-tool" {
+			t.Fatalf("ran %s; want secret-tool", name)
+		}
+		id := strings.Join(args[len(args)-4:], " ")
+		switch args[0] {
+		case "store":
+			secrets[id] = stdin
+		case "lookup":
+			if s, ok := secrets[id]; ok {
+				return []byte(s), nil
+			}
+			return nil, &keyringExitError{err: errors.New("exit status 1")}
+		case "clear":
+			delete(secrets, id)
+		}
+		return nil, nil
+	}
+
+	k := &KeyringStore{Service: "example.com/tool"}
+	if _, err := k.Get("k"); err != ErrNotFound {
+		t.Fatalf("Get: %v; want ErrNotFound", err)
+	}
+	if err := k.Put("k", &oauth2.Token{AccessToken: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if tok, err := k.Get("k"); err != nil || tok.AccessToken != "a" {
+		t.Fatalf("Get = %v, %v", tok, err)
+	}
+	if err := k.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get("k"); err != ErrNotFound {
+		t.Errorf("Get after Delete: %v; want ErrNotFound", err)
+	}
+}
+
+func TestSecurityAddCommand(t *testing.T) {
+	cmd, err := securityAddCommand(`example.com/"tool"`, "k", []byte(`{"access_token":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `add-generic-password -U -s "example.com/\"tool\"" -a "k" -X 7b226163636573735f746f6b656e223a2261227d` + "\n"
+	if cmd != want {
+		t.Errorf("securityAddCommand = %q; want %q", cmd, want)
+	}
+	if strings.Contains(cmd, "access_token") {
+		t.Errorf("securityAddCommand = %q; want the secret hex-encoded", cmd)
+	}
+	if _, err := securityAddCommand("svc", "k\n-w x", nil); err == nil {
+		t.Error("securityAddCommand with a newline in the key succeeded")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// FileStore is a TokenStore that keeps all tokens in a single file,
+// encrypted with AES-256-GCM. The file is created with mode 0600.
+type FileStore struct {
+	// Path is the location of the file.
+	Path string
+
+	// Key is the 32-byte encryption key. It should come from a
+	// source other than the file system holding Path, such as the
+	// OS keyring or a hardware security module.
+	Key []byte
+
+	mu sync.Mutex
+}
+
+// Get implements TokenStore.
+func (f *FileStore) Get(key string) (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := m[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+// Put implements TokenStore.
+func (f *FileStore) Put(key string, t *oauth2.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, err := f.read()
+	if err != nil {
+		return err
+	}
+	m[key] = t
+	return f.write(m)
+}
+
+// Delete implements TokenStore.
+func (f *FileStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := m[key]; !ok {
+		return nil
+	}
+	delete(m, key)
+	return f.write(m)
+}
+
+func (f *FileStore) aead() (cipher.AEAD, error) {
+	if len(f.Key) != 32 {
+		return nil, errors.New("oauth2/cache: FileStore.Key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(f.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// read returns the tokens in the file, or an empty map if the file
+// does not exist.
+func (f *FileStore) read() (map[string]*oauth2.Token, error) {
+	aead, err := f.aead()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*oauth2.Token), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/cache: %v", err)
+	}
+	n := aead.NonceSize()
+	if len(b) < n {
+		return nil, fmt.Errorf("oauth2/cache: %s is corrupt", f.Path)
+	}
+	plain, err := aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/cache: cannot decrypt %s: %v", f.Path, err)
+	}
+	m := make(map[string]*oauth2.Token)
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, fmt.Errorf("oauth2/cache: cannot parse %s: %v", f.Path, err)
+	}
+	return m, nil
+}
+
+// write replaces the file atomically with the encrypted tokens in m.
+func (f *FileStore) write(m map[string]*oauth2.Token) error {
+	aead, err := f.aead()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), ".tokens-*")
+	if err != nil {
+		return fmt.Errorf("oauth2/cache: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(aead.Seal(nonce, nonce, plain, nil))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("oauth2/cache: %v", err)
+	}
+	return nil
+}
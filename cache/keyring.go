@@ -0,0 +1,154 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// KeyringStore is a TokenStore backed by the operating system's
+// keyring: the login keychain on macOS, through the security command,
+// and the Secret Service on Linux and BSD, through the secret-tool
+// command from libsecret. Other systems are not supported.
+type KeyringStore struct {
+	// Service names the application that owns the tokens, such as
+	// "example.com/mytool".
+	Service string
+}
+
+// runKeyring runs a keyring command. It is a variable for tests.
+var runKeyring = func(stdin string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, &keyringExitError{err: err, stderr: strings.TrimSpace(stderr.String())}
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// keyringExitError reports that a keyring command exited unsuccessfully,
+// which for lookups usually means the item does not exist.
+type keyringExitError struct {
+	err    error
+	stderr string
+}
+
+func (e *keyringExitError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%v: %s", e.err, e.stderr)
+	}
+	return e.err.Error()
+}
+
+// Get implements TokenStore.
+func (k *KeyringStore) Get(key string) (*oauth2.Token, error) {
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = runKeyring("", "security", "find-generic-password", "-s", k.Service, "-a", key, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		out, err = runKeyring("", "secret-tool", "lookup", "service", k.Service, "key", key)
+		if err == nil && len(out) == 0 {
+			return nil, ErrNotFound
+		}
+	default:
+		return nil, k.unsupported()
+	}
+	var ee *keyringExitError
+	if errors.As(err, &ee) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/cache: keyring: %v", err)
+	}
+	t := new(oauth2.Token)
+	if err := json.Unmarshal(bytes.TrimSpace(out), t); err != nil {
+		return nil, fmt.Errorf("oauth2/cache: keyring: cannot parse token: %v", err)
+	}
+	return t, nil
+}
+
+// Put implements TokenStore.
+func (k *KeyringStore) Put(key string, t *oauth2.Token) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		// A secret passed as an argument is visible to other users in
+		// the process list, so the command is sent on stdin to the
+		// interactive mode of security instead.
+		var cmd string
+		cmd, err = securityAddCommand(k.Service, key, b)
+		if err == nil {
+			_, err = runKeyring(cmd, "security", "-i")
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err = runKeyring(string(b), "secret-tool", "store", "--label", k.Service+" OAuth 2.0 token", "service", k.Service, "key", key)
+	default:
+		return k.unsupported()
+	}
+	if err != nil {
+		return fmt.Errorf("oauth2/cache: keyring: %v", err)
+	}
+	return nil
+}
+
+// Delete implements TokenStore.
+func (k *KeyringStore) Delete(key string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = runKeyring("", "security", "delete-generic-password", "-s", k.Service, "-a", key)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err = runKeyring("", "secret-tool", "clear", "service", k.Service, "key", key)
+	default:
+		return k.unsupported()
+	}
+	var ee *keyringExitError
+	if err != nil && !errors.As(err, &ee) {
+		return fmt.Errorf("oauth2/cache: keyring: %v", err)
+	}
+	return nil
+}
+
+// securityAddCommand returns the command of the interactive mode of the
+// macOS security command that stores secret, hex-encoded, as the generic
+// password of service and account.
+func securityAddCommand(service, account string, secret []byte) (string, error) {
+	if strings.ContainsAny(service+account, "\r\n") {
+		return "", errors.New("service and key must not contain newlines")
+	}
+	return fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		securityQuote(service), securityQuote(account), hex.EncodeToString(secret)), nil
+}
+
+// securityQuote quotes s as an argument of the interactive mode of the
+// security command.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (k *KeyringStore) unsupported() error {
+	return fmt.Errorf("oauth2/cache: keyring is not supported on %s", runtime.GOOS)
+}
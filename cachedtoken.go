@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CachedTokenSource returns a TokenSource that caches tokens from src
+// like ReuseTokenSource, but with stale-while-revalidate semantics:
+// once the cached token is within refreshWindow of its expiry, Token
+// returns it immediately and refreshes it from src in the background.
+// Token only waits for src when no token is cached or the cached token
+// has expired, so in a service that uses the token continuously,
+// requests never wait for a refresh.
+//
+// Only one background refresh runs at a time. If it fails, or returns
+// a token that does not expire later than the cached one, the cached
+// token keeps being returned and onError, if not nil, is called with
+// the error on the refresh's goroutine. Further background refreshes
+// are delayed by an exponential backoff, from one second up to one
+// minute, so that a failing token endpoint is not called on every call
+// to Token. Once the token expires, Token calls src synchronously and
+// returns its error.
+//
+// src must not cache tokens itself, as the sources returned by
+// Config.TokenSource and ReuseTokenSource do, or the background
+// refreshes get the cached token back and never renew it early.
+//
+// The initial token t may be nil.
+func CachedTokenSource(t *Token, src TokenSource, refreshWindow time.Duration, onError func(error)) TokenSource {
+	return &cachedTokenSource{src: src, window: refreshWindow, onError: onError, t: t}
+}
+
+type cachedTokenSource struct {
+	src     TokenSource
+	window  time.Duration
+	onError func(error)
+
+	mu         sync.Mutex // guards the fields below
+	t          *Token
+	refreshing bool      // a background refresh is in progress
+	failures   int       // consecutive failed background refreshes
+	retryAt    time.Time // no background refresh starts before retryAt
+}
+
+// Bounds of the backoff between failed background refreshes.
+const (
+	cachedTokenMinBackoff = time.Second
+	cachedTokenMaxBackoff = time.Minute
+)
+
+func (s *cachedTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.t.Valid() {
+		now := timeNow()
+		if !s.refreshing && !s.t.Expiry.IsZero() && now.Add(s.window).After(s.t.Expiry) && !now.Before(s.retryAt) {
+			s.refreshing = true
+			go s.refresh(s.t)
+		}
+		return s.t, nil
+	}
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.t = t
+	return t, nil
+}
+
+// Peek returns the cached token. See InspectableTokenSource.
+func (s *cachedTokenSource) Peek() *Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t
+}
+
+// Invalidate discards the cached token. See InspectableTokenSource.
+// A background refresh in progress may still cache its result.
+func (s *cachedTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t = nil
+}
+
+// errNotRenewed is reported when a background refresh returns a token
+// that does not expire later than the cached one.
+var errNotRenewed = errors.New("oauth2: background token refresh did not return a newer token")
+
+// refresh fetches a new token from s.src to replace old and caches it.
+func (s *cachedTokenSource) refresh(old *Token) {
+	t, err := s.src.Token()
+	s.mu.Lock()
+	s.refreshing = false
+	if err == nil && !t.Expiry.After(old.Expiry) {
+		err = errNotRenewed
+	}
+	if err != nil {
+		backoff := cachedTokenMaxBackoff
+		if s.failures < 6 {
+			backoff = cachedTokenMinBackoff << s.failures
+		}
+		s.failures++
+		s.retryAt = timeNow().Add(backoff)
+		s.mu.Unlock()
+		if s.onError != nil {
+			s.onError(err)
+		}
+		return
+	}
+	s.failures, s.retryAt = 0, time.Time{}
+	// Keep a token fetched synchronously in the meantime if it is newer.
+	if s.t == nil || t.Expiry.After(s.t.Expiry) {
+		s.t = t
+	}
+	s.mu.Unlock()
+}
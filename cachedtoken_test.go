@@ -0,0 +1,186 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chanTokenSource returns the tokens sent on its channel.
+type chanTokenSource chan *Token
+
+func (c chanTokenSource) Token() (*Token, error) {
+	t, ok := <-c
+	if !ok {
+		return nil, errors.New("closed")
+	}
+	return t, nil
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	src := make(chanTokenSource)
+	stale := &Token{AccessToken: "stale", Expiry: time.Now().Add(time.Minute)}
+	ts := CachedTokenSource(stale, src, 5*time.Minute, nil)
+
+	// The token is within the refresh window, so it is returned
+	// immediately and a refresh starts in the background.
+	tok, err := ts.Token()
+	if err != nil || tok.AccessToken != "stale" {
+		t.Fatalf("Token() = %v, %v; want stale token", tok, err)
+	}
+	// Only one background refresh runs at a time.
+	if tok, _ := ts.Token(); tok.AccessToken != "stale" {
+		t.Fatalf("Token() = %q during refresh; want stale", tok.AccessToken)
+	}
+	src <- &Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tok, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.AccessToken == "fresh" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not replace the cached token")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachedTokenSourceBackoff(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	var mu sync.Mutex
+	calls := 0
+	src := tokenSourceFunc(func() (*Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return nil, errors.New("unavailable")
+	})
+	var errs []error
+	onError := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+	ts := CachedTokenSource(&Token{AccessToken: "stale", Expiry: now.Add(time.Minute)}, src, 5*time.Minute, onError).(*cachedTokenSource)
+	waitRefreshed := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			ts.mu.Lock()
+			refreshing := ts.refreshing
+			ts.mu.Unlock()
+			if !refreshing {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("background refresh did not end")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	ts.Token()
+	waitRefreshed()
+	for i := 0; i < 10; i++ {
+		if tok, err := ts.Token(); err != nil || tok.AccessToken != "stale" {
+			t.Fatalf("Token() = %v, %v; want stale token", tok, err)
+		}
+	}
+	waitRefreshed()
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("%d refreshes within the backoff; want 1", calls)
+	}
+	mu.Unlock()
+
+	now = now.Add(cachedTokenMinBackoff)
+	ts.Token()
+	waitRefreshed()
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Errorf("%d refreshes after the backoff; want 2", calls)
+	}
+	if len(errs) != 2 {
+		t.Errorf("OnError called %d times; want 2", len(errs))
+	}
+}
+
+func TestCachedTokenSourceNotRenewed(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	stale := &Token{AccessToken: "stale", Expiry: now.Add(time.Minute)}
+	errc := make(chan error, 10)
+	calls := 0
+	// A caching source keeps returning the token it already has.
+	src := tokenSourceFunc(func() (*Token, error) {
+		calls++
+		return stale, nil
+	})
+	ts := CachedTokenSource(stale, src, 5*time.Minute, func(err error) { errc <- err })
+	ts.Token()
+	if err := <-errc; !errors.Is(err, errNotRenewed) {
+		t.Errorf("OnError(%v); want errNotRenewed", err)
+	}
+	for i := 0; i < 10; i++ {
+		ts.Token()
+	}
+	select {
+	case err := <-errc:
+		t.Errorf("background refresh within the backoff: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if calls != 1 {
+		t.Errorf("%d refreshes within the backoff; want 1", calls)
+	}
+}
+
+func TestCachedTokenSourceExpired(t *testing.T) {
+	src := make(chanTokenSource, 1)
+	src <- &Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)}
+	ts := CachedTokenSource(&Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)}, src, time.Minute, nil)
+	if tok, err := ts.Token(); err != nil || tok.AccessToken != "fresh" {
+		t.Fatalf("Token() = %v, %v; want fresh token fetched synchronously", tok, err)
+	}
+	close(src)
+	if tok, err := ts.Token(); err != nil || tok.AccessToken != "fresh" {
+		t.Fatalf("Token() = %v, %v; want cached token", tok, err)
+	}
+}
+
+func TestInspectableTokenSources(t *testing.T) {
+	for name, ts := range map[string]TokenSource{
+		"ReuseTokenSource":  ReuseTokenSource(&Token{AccessToken: "a"}, StaticTokenSource(&Token{AccessToken: "b"})),
+		"CachedTokenSource": CachedTokenSource(&Token{AccessToken: "a"}, StaticTokenSource(&Token{AccessToken: "b"}), time.Minute, nil),
+	} {
+		its, ok := ts.(InspectableTokenSource)
+		if !ok {
+			t.Errorf("%s does not implement InspectableTokenSource", name)
+			continue
+		}
+		if got := its.Peek(); got == nil || got.AccessToken != "a" {
+			t.Errorf("%s: Peek() = %v; want token a", name, got)
+		}
+		its.Invalidate()
+		if got := its.Peek(); got != nil {
+			t.Errorf("%s: Peek() after Invalidate = %v; want nil", name, got)
+		}
+		if tok, err := its.Token(); err != nil || tok.AccessToken != "b" {
+			t.Errorf("%s: Token() after Invalidate = %v, %v; want token b", name, tok, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CertificateThumbprint returns the base64url-encoded SHA-256 hash of
+// the DER encoding of cert, as used in the x5t#S256 confirmation
+// method of certificate-bound access tokens (RFC 8705).
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CertificateThumbprint returns the thumbprint of the client
+// certificate t is bound to, as described in RFC 8705 section 3, or ""
+// if t is not known to be certificate-bound. It is read from the "cnf"
+// field of the token response, if any, or else from the "cnf" claim of
+// the access token if it is a JWT. The JWT is not verified.
+func (t *Token) CertificateThumbprint() string {
+	if cnf, ok := t.Extra("cnf").(map[string]interface{}); ok {
+		if s, ok := cnf["x5t#S256"].(string); ok {
+			return s
+		}
+	}
+	parts := strings.Split(t.AccessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Cnf struct {
+			X5TS256 string `json:"x5t#S256"`
+		} `json:"cnf"`
+	}
+	if json.Unmarshal(b, &claims) != nil {
+		return ""
+	}
+	return claims.Cnf.X5TS256
+}
+
+// checkCertificateBinding returns an error if t is bound to a
+// certificate other than cert.
+func checkCertificateBinding(t *Token, cert *x509.Certificate) error {
+	want := t.CertificateThumbprint()
+	if want == "" {
+		return nil
+	}
+	if got := CertificateThumbprint(cert); got != want {
+		return fmt.Errorf("oauth2: token is bound to certificate %s, but Transport uses %s", want, got)
+	}
+	return nil
+}
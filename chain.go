@@ -0,0 +1,109 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ChainError is the error returned by a ChainTokenSource when all of its
+// sources fail. Errors holds the error of each source, in order.
+type ChainError struct {
+	Errors []error
+}
+
+func (e *ChainError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "oauth2: no token source succeeded: " + strings.Join(msgs, "; ")
+}
+
+// ChainTokenSource returns a TokenSource that tries each of sources in
+// order and returns the token of the first one that succeeds, such as
+// credentials from the environment, then a file, then a metadata server.
+//
+// The source that succeeded is remembered and tried first by later
+// calls; if it fails, the other sources are tried again in order. If
+// all sources fail, Token returns a *ChainError.
+func ChainTokenSource(sources ...TokenSource) TokenSource {
+	return &chainTokenSource{sources: sources, last: -1}
+}
+
+type chainTokenSource struct {
+	sources []TokenSource
+
+	mu   sync.Mutex
+	last int // index of the source that last succeeded, or -1
+}
+
+func (s *chainTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+
+	var errs []error
+	if last >= 0 {
+		t, err := s.sources[last].Token()
+		if err == nil {
+			return t, nil
+		}
+		errs = append(errs, err)
+	}
+	for i, src := range s.sources {
+		if i == last {
+			continue
+		}
+		t, err := src.Token()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.mu.Lock()
+		s.last = i
+		s.mu.Unlock()
+		return t, nil
+	}
+	return nil, &ChainError{Errors: errs}
+}
+
+// FallbackTokenSource returns a TokenSource that returns tokens from
+// primary, or from backup when primary fails with an error for which
+// fallback returns true.
+//
+// If fallback is nil, every error falls back except a *RetrieveError for
+// a 4xx response: it means the token endpoint rejected primary's
+// credentials, which usually needs fixing rather than masking.
+func FallbackTokenSource(primary, backup TokenSource, fallback func(error) bool) TokenSource {
+	if fallback == nil {
+		fallback = defaultFallback
+	}
+	return fallbackTokenSource{primary, backup, fallback}
+}
+
+type fallbackTokenSource struct {
+	primary, backup TokenSource
+	fallback        func(error) bool
+}
+
+func (s fallbackTokenSource) Token() (*Token, error) {
+	t, err := s.primary.Token()
+	if err == nil || !s.fallback(err) {
+		return t, err
+	}
+	return s.backup.Token()
+}
+
+func defaultFallback(err error) bool {
+	var re *RetrieveError
+	if errors.As(err, &re) && re.Response != nil {
+		code := re.Response.StatusCode
+		return code < 400 || code > 499
+	}
+	return true
+}
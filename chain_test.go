@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestChainTokenSource(t *testing.T) {
+	var calls []string
+	src := func(name string, err *error) TokenSource {
+		return tokenSourceFunc(func() (*Token, error) {
+			calls = append(calls, name)
+			if *err != nil {
+				return nil, *err
+			}
+			return &Token{AccessToken: name}, nil
+		})
+	}
+	errEnv, errFile := errors.New("no env"), error(nil)
+	ts := ChainTokenSource(src("env", &errEnv), src("file", &errFile))
+
+	for i := 0; i < 2; i++ {
+		tok, err := ts.Token()
+		if err != nil || tok.AccessToken != "file" {
+			t.Fatalf("Token() = %v, %v; want file token", tok, err)
+		}
+	}
+	if got, want := len(calls), 3; got != want {
+		t.Errorf("calls = %v; want the working source remembered", calls)
+	}
+
+	errFile = errors.New("no file")
+	_, err := ts.Token()
+	var ce *ChainError
+	if !errors.As(err, &ce) || len(ce.Errors) != 2 {
+		t.Fatalf("Token() error = %v; want ChainError with 2 errors", err)
+	}
+}
+
+func TestFallbackTokenSource(t *testing.T) {
+	backup := StaticTokenSource(&Token{AccessToken: "backup"})
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"unavailable", errors.New("connection refused"), "backup"},
+		{"server error", &RetrieveError{Response: &http.Response{StatusCode: 503}}, "backup"},
+		{"rejected", &RetrieveError{Response: &http.Response{StatusCode: 401}}, ""},
+	}
+	for _, tt := range tests {
+		tok, err := FallbackTokenSource(ErrorTokenSource(tt.err), backup, nil).Token()
+		if tt.want == "" {
+			if err != tt.err {
+				t.Errorf("%s: Token() error = %v; want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || tok.AccessToken != tt.want {
+			t.Errorf("%s: Token() = %v, %v; want %q", tt.name, tok, err, tt.want)
+		}
+	}
+
+	never := func(error) bool { return false }
+	if _, err := FallbackTokenSource(ErrorTokenSource(errors.New("x")), backup, never).Token(); err == nil {
+		t.Error("Token() fell back although fallback returned false")
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BearerChallenge is a Bearer challenge from a resource server's
+// WWW-Authenticate response header.
+// See https://datatracker.ietf.org/doc/html/rfc6750#section-3.
+type BearerChallenge struct {
+	// Realm is the "realm" parameter.
+	Realm string
+
+	// Scope is the "scope" parameter: the scopes required to access
+	// the resource, space-delimited.
+	Scope string
+
+	// Error is the "error" parameter, such as "invalid_token" or
+	// "insufficient_scope".
+	Error string
+
+	// ErrorDescription is the "error_description" parameter.
+	ErrorDescription string
+
+	// ACRValues is the "acr_values" parameter of a step-up challenge:
+	// the authentication context class references the resource
+	// requires, space-delimited, in order of preference.
+	// See https://datatracker.ietf.org/doc/html/rfc9470#section-3.
+	ACRValues string
+
+	// MaxAge is the "max_age" parameter of a step-up challenge: the
+	// maximum time in seconds since the user last authenticated. It is
+	// -1 if the challenge has no valid "max_age" parameter.
+	MaxAge int
+
+	// Params holds every parameter of the challenge, including the
+	// ones above, keyed by lowercase name.
+	Params map[string]string
+}
+
+// Scopes returns the scopes listed in c.Scope.
+func (c *BearerChallenge) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// MissingScopes returns the scopes required by c that are not in
+// granted. It is typically used to request incremental consent for
+// exactly the missing scopes after an "insufficient_scope" error.
+func (c *BearerChallenge) MissingScopes(granted []string) []string {
+	have := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		have[s] = true
+	}
+	var missing []string
+	for _, s := range c.Scopes() {
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// BearerChallengeFromResponse returns the first Bearer challenge in the
+// WWW-Authenticate headers of res if res is a 401 or 403 response, and
+// nil otherwise. It is for callers that do not use Transport, whose
+// OnChallenge field reports the same challenges.
+func BearerChallengeFromResponse(res *http.Response) *BearerChallenge {
+	if res.StatusCode != http.StatusUnauthorized && res.StatusCode != http.StatusForbidden {
+		return nil
+	}
+	for _, h := range res.Header.Values("WWW-Authenticate") {
+		for _, c := range parseChallenges(h) {
+			if strings.EqualFold(c.scheme, "Bearer") {
+				maxAge := -1
+				if n, err := strconv.Atoi(c.params["max_age"]); err == nil && n >= 0 {
+					maxAge = n
+				}
+				return &BearerChallenge{
+					Realm:            c.params["realm"],
+					Scope:            c.params["scope"],
+					Error:            c.params["error"],
+					ErrorDescription: c.params["error_description"],
+					ACRValues:        c.params["acr_values"],
+					MaxAge:           maxAge,
+					Params:           c.params,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseChallenges parses the value of a WWW-Authenticate header, which
+// may hold several comma-separated challenges, as described in
+// https://datatracker.ietf.org/doc/html/rfc9110#section-11.6.1.
+// Token68 values are ignored.
+func parseChallenges(s string) []authChallenge {
+	var cs []authChallenge
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return cs
+		}
+		// A token followed by "=" is a parameter of the current
+		// challenge; any other token starts a new challenge.
+		tok, rest := consumeToken(s)
+		if tok == "" {
+			return cs // malformed
+		}
+		rest = strings.TrimLeft(rest, " \t")
+		if strings.HasPrefix(rest, "=") && len(cs) > 0 {
+			if isToken68(rest) {
+				// The end of a token68 value, such as "Basic dXNlcg==".
+				s = strings.TrimLeft(rest, "=")
+				continue
+			}
+			var val string
+			val, rest = consumeValue(strings.TrimLeft(rest[1:], " \t"))
+			cs[len(cs)-1].params[strings.ToLower(tok)] = val
+			s = rest
+			continue
+		}
+		cs = append(cs, authChallenge{scheme: tok, params: make(map[string]string)})
+		s = rest
+	}
+}
+
+// isToken68 reports whether s begins with "=" signs ending a token68
+// value, rather than the "=" separating a parameter from its value.
+func isToken68(s string) bool {
+	if !strings.HasPrefix(s, "=") {
+		return false
+	}
+	s = strings.TrimLeft(s, "=")
+	s = strings.TrimLeft(s, " \t")
+	return s == "" || s[0] == ','
+}
+
+func consumeToken(s string) (tok, rest string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == '=' || r == '"'
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+func consumeValue(s string) (val, rest string) {
+	if !strings.HasPrefix(s, `"`) {
+		return consumeToken(s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseChallenges(t *testing.T) {
+	cases := []struct {
+		header string
+		want   []authChallenge
+	}{
+		{
+			header: `Bearer realm="example", error="insufficient_scope", scope="read write"`,
+			want: []authChallenge{{scheme: "Bearer", params: map[string]string{
+				"realm": "example", "error": "insufficient_scope", "scope": "read write",
+			}}},
+		},
+		{
+			header: `Basic dXNlcg==, Bearer error=invalid_token, error_description="expired \"token\""`,
+			want: []authChallenge{
+				{scheme: "Basic", params: map[string]string{}},
+				{scheme: "Bearer", params: map[string]string{
+					"error": "invalid_token", "error_description": `expired "token"`,
+				}},
+			},
+		},
+		{
+			header: `Bearer`,
+			want:   []authChallenge{{scheme: "Bearer", params: map[string]string{}}},
+		},
+	}
+	for _, tc := range cases {
+		if got := parseChallenges(tc.header); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseChallenges(%q) = %+v; want %+v", tc.header, got, tc.want)
+		}
+	}
+}
+
+type challengeRecorder struct {
+	c *BearerChallenge
+}
+
+func (r *challengeRecorder) HandleChallenge(res *http.Response, c *BearerChallenge) { r.c = c }
+
+func TestTransportOnChallenge(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope", scope="read write admin"`)
+		w.WriteHeader(http.StatusForbidden)
+	})
+	defer server.Close()
+
+	h := new(challengeRecorder)
+	tr := &Transport{
+		Source:      StaticTokenSource(&Token{AccessToken: "abc"}),
+		OnChallenge: h,
+	}
+	client := &http.Client{Transport: tr}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	got := h.c
+	if got == nil {
+		t.Fatal("OnChallenge was not called")
+	}
+	if got.Error != "insufficient_scope" || got.Realm != "api" {
+		t.Errorf("challenge = %+v; want insufficient_scope in realm api", got)
+	}
+	if missing, want := got.MissingScopes([]string{"read"}), []string{"write", "admin"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("MissingScopes = %q; want %q", missing, want)
+	}
+}
+
+func TestBearerChallengeFromResponse(t *testing.T) {
+	h := make(http.Header)
+	h.Add("WWW-Authenticate", `Basic realm="api"`)
+	h.Add("WWW-Authenticate", `Bearer realm="api", error="invalid_token", error_description="The access token expired"`)
+	res := &http.Response{StatusCode: http.StatusUnauthorized, Header: h}
+	c := BearerChallengeFromResponse(res)
+	if c == nil {
+		t.Fatal("BearerChallengeFromResponse = nil")
+	}
+	if c.Realm != "api" || c.Error != "invalid_token" || c.ErrorDescription != "The access token expired" {
+		t.Errorf("BearerChallengeFromResponse = %+v", c)
+	}
+	res.StatusCode = http.StatusOK
+	if c := BearerChallengeFromResponse(res); c != nil {
+		t.Errorf("BearerChallengeFromResponse of a 200 response = %+v; want nil", c)
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/internal"
@@ -48,11 +50,85 @@ type Config struct {
 	// auto-detect.
 	AuthStyle oauth2.AuthStyle
 
+	// BasicAuthEncoding optionally specifies how the client ID and
+	// client secret are encoded in the Authorization header. The
+	// zero value follows RFC 6749.
+	BasicAuthEncoding oauth2.BasicAuthEncoding
+
+	// TokenRequestTimeout optionally limits the duration of each
+	// request to the token endpoint. Zero means a default of 30
+	// seconds; a negative value means no limit other than that of
+	// the context.
+	TokenRequestTimeout time.Duration
+
+	// DisableAuthStyleProbing makes token requests fail instead of
+	// probing which auth style the token endpoint accepts, when
+	// AuthStyle is oauth2.AuthStyleAutoDetect and no style has been
+	// recorded yet.
+	DisableAuthStyleProbing bool
+
+	// RetryPolicy optionally retries token requests that fail with
+	// DNS, connection reset or TLS handshake errors. If nil, failed
+	// requests are not retried.
+	RetryPolicy *oauth2.RetryPolicy
+
+	// Limiter optionally rate limits token requests and suspends them
+	// after invalid_client errors. It may be shared by several Configs.
+	Limiter *oauth2.TokenRequestLimiter
+
+	// Logger optionally receives debug logs of requests made to the
+	// token endpoint. Secrets are never logged.
+	Logger oauth2.Logger
+
+	// TokenRequestMiddleware optionally wraps the transport used for
+	// requests to the token endpoint. See the field of the same name
+	// on oauth2.Config.
+	TokenRequestMiddleware func(next http.RoundTripper) http.RoundTripper
+
+	// RequestEncoding optionally specifies how the parameters of token
+	// requests are encoded. The zero value follows RFC 6749.
+	RequestEncoding oauth2.RequestEncoding
+
+	// LenientExpiry makes token responses whose lifetime does not
+	// follow RFC 6749 yield an Expiry instead of none. See the field
+	// of the same name on oauth2.Config.
+	LenientExpiry bool
+
+	// Now optionally returns the current time, used to compute the
+	// Expiry of tokens and to check their validity. The default is
+	// time.Now.
+	Now func() time.Time
+
+	// UseRefreshTokens makes token sources use the refresh token some
+	// servers return with client credentials tokens to get the next
+	// token, instead of repeating the client credentials grant, to
+	// reduce the load on constrained authorization servers. If the
+	// refresh fails, the client credentials grant is used.
+	UseRefreshTokens bool
+
+	// AuthStyleCache optionally specifies the cache recording which
+	// auth style to use when AuthStyle is the zero value
+	// (AuthStyleAutoDetect). It may be shared by several Configs.
+	// If nil, each Config uses its own unbounded cache.
+	//
+	// To inspect or pre-seed the auth style of the client, set
+	// AuthStyleCache and use its LookupClient and SetClient methods
+	// with TokenURL and ClientID.
+	AuthStyleCache *oauth2.AuthStyleCache
+
 	// authStyleCache caches which auth style to use when Endpoint.AuthStyle is
-	// the zero value (AuthStyleAutoDetect).
+	// the zero value (AuthStyleAutoDetect) and AuthStyleCache is nil.
 	authStyleCache internal.LazyAuthStyleCache
 }
 
+// styleCache returns the auth style cache to use for c.
+func (c *Config) styleCache() *internal.AuthStyleCache {
+	if c.AuthStyleCache != nil {
+		return internal.PublicAuthStyleCache(c.AuthStyleCache)
+	}
+	return c.authStyleCache.Get()
+}
+
 // Token uses client credentials to retrieve a token.
 //
 // The provided context optionally controls which HTTP client is used. See the oauth2.HTTPClient variable.
@@ -75,6 +151,10 @@ func (c *Config) Client(ctx context.Context) *http.Client {
 // automatically refreshing it as necessary using the provided context and the
 // client ID and client secret.
 //
+// The returned TokenSource implements oauth2.InspectableTokenSource, to
+// inspect the cached token without forcing a refresh or to invalidate
+// it, such as after rotating the client secret.
+//
 // Most users will use Config.Client instead.
 func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 	source := &tokenSource{
@@ -84,19 +164,94 @@ func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 	return oauth2.ReuseTokenSource(nil, source)
 }
 
+// TokenSourceWithInitialToken is like TokenSource, but returns t until it
+// expires instead of fetching a new token on first use. The token is
+// checked with oauth2.ValidateToken.
+func (c *Config) TokenSourceWithInitialToken(ctx context.Context, t *oauth2.Token) (oauth2.TokenSource, error) {
+	if err := oauth2.ValidateToken(t); err != nil {
+		return nil, err
+	}
+	source := &tokenSource{
+		ctx:          ctx,
+		conf:         c,
+		refreshToken: t.RefreshToken,
+	}
+	return oauth2.ReuseTokenSource(t, source), nil
+}
+
+// retrieveOptions returns the options for token requests made by c.
+func (c *Config) retrieveOptions() *internal.RetrieveOptions {
+	opts := &internal.RetrieveOptions{
+		RawBasicAuth: c.BasicAuthEncoding == oauth2.BasicAuthRaw,
+		Timeout:      c.TokenRequestTimeout,
+		DisableProbe: c.DisableAuthStyleProbing,
+		Retry:        (*internal.RetryPolicy)(c.RetryPolicy),
+	}
+	if c.Limiter != nil {
+		opts.Limiter = c.Limiter
+	}
+	if c.Logger != nil {
+		opts.Logger = c.Logger
+	}
+	opts.Middleware = c.TokenRequestMiddleware
+	opts.JSONBody = c.RequestEncoding == oauth2.RequestEncodingJSON
+	opts.Now = c.Now
+	opts.LenientExpiry = c.LenientExpiry
+	return opts
+}
+
 type tokenSource struct {
 	ctx  context.Context
 	conf *Config
+
+	// scopes and params, if not nil, replace conf.Scopes and extend
+	// conf.EndpointParams. They are set by Multiplexer.
+	scopes []string
+	params url.Values
+
+	mu           sync.Mutex // guards refreshToken
+	refreshToken string     // used if conf.UseRefreshTokens is set
 }
 
-// Token refreshes the token by using a new client credentials request.
-// tokens received this way do not include a refresh token
+// Token refreshes the token by using a new client credentials request,
+// or the refresh token of the previous one if conf.UseRefreshTokens is
+// set.
 func (c *tokenSource) Token() (*oauth2.Token, error) {
+	if !c.conf.UseRefreshTokens {
+		return c.clientCredentialsToken()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshToken != "" {
+		v := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.refreshToken},
+		}
+		if t, err := c.retrieve(v); err == nil {
+			c.refreshToken = t.RefreshToken
+			return t, nil
+		}
+		c.refreshToken = ""
+	}
+	t, err := c.clientCredentialsToken()
+	if err != nil {
+		return nil, err
+	}
+	c.refreshToken = t.RefreshToken
+	return t, nil
+}
+
+// clientCredentialsToken makes a client credentials request.
+func (c *tokenSource) clientCredentialsToken() (*oauth2.Token, error) {
 	v := url.Values{
 		"grant_type": {"client_credentials"},
 	}
-	if len(c.conf.Scopes) > 0 {
-		v.Set("scope", strings.Join(c.conf.Scopes, " "))
+	scopes := c.conf.Scopes
+	if c.scopes != nil {
+		scopes = c.scopes
+	}
+	if len(scopes) > 0 {
+		v.Set("scope", strings.Join(scopes, " "))
 	}
 	for k, p := range c.conf.EndpointParams {
 		// Allow grant_type to be overridden to allow interoperability with
@@ -106,19 +261,20 @@ func (c *tokenSource) Token() (*oauth2.Token, error) {
 		}
 		v[k] = p
 	}
+	for k, p := range c.params {
+		v[k] = p
+	}
+	return c.retrieve(v)
+}
 
-	tk, err := internal.RetrieveToken(c.ctx, c.conf.ClientID, c.conf.ClientSecret, c.conf.TokenURL, v, internal.AuthStyle(c.conf.AuthStyle), c.conf.authStyleCache.Get())
+// retrieve makes a token request with the parameters v.
+func (c *tokenSource) retrieve(v url.Values) (*oauth2.Token, error) {
+	tk, err := internal.RetrieveToken(c.ctx, c.conf.ClientID, c.conf.ClientSecret, c.conf.TokenURL, v, internal.AuthStyle(c.conf.AuthStyle), c.conf.styleCache(), c.conf.retrieveOptions())
 	if err != nil {
 		if rErr, ok := err.(*internal.RetrieveError); ok {
 			return nil, (*oauth2.RetrieveError)(rErr)
 		}
 		return nil, err
 	}
-	t := &oauth2.Token{
-		AccessToken:  tk.AccessToken,
-		TokenType:    tk.TokenType,
-		RefreshToken: tk.RefreshToken,
-		Expiry:       tk.Expiry,
-	}
-	return t.WithExtra(tk.Raw), nil
+	return internal.PublicToken(tk, c.conf.Now).(*oauth2.Token), nil
 }
@@ -6,12 +6,18 @@ package clientcredentials
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
 )
 
 func newConf(serverURL string) *Config {
@@ -136,3 +142,184 @@ func TestTokenRefreshRequest(t *testing.T) {
 	c := conf.Client(context.Background())
 	c.Get(ts.URL + "/somethingelse")
 }
+
+func TestTokenSourceWithInitialToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected token request")
+	}))
+	defer ts.Close()
+	conf := newConf(ts.URL)
+
+	if _, err := conf.TokenSourceWithInitialToken(context.Background(), &oauth2.Token{}); err == nil {
+		t.Errorf("TokenSourceWithInitialToken accepted a token without access_token")
+	}
+	src, err := conf.TokenSourceWithInitialToken(context.Background(), &oauth2.Token{
+		AccessToken: "cached",
+		Expiry:      time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := src.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tok.AccessToken, "cached"; got != want {
+		t.Errorf("AccessToken = %q; want %q", got, want)
+	}
+}
+
+func TestTokenRequestBasicAuthEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		encoding     oauth2.BasicAuthEncoding
+		wantUser     string
+		wantPassword string
+	}{
+		{oauth2.BasicAuthURLEncoded, "CLIENT+ID%3F", "SECRET%2F%3D"},
+		{oauth2.BasicAuthRaw, "CLIENT ID?", "SECRET/="},
+	} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != tc.wantUser || password != tc.wantPassword {
+				t.Errorf("encoding %v: BasicAuth() = %q, %q, %v; want %q, %q, true", tc.encoding, user, password, ok, tc.wantUser, tc.wantPassword)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"access_token": "foo"}`)
+		}))
+		conf := newConf(ts.URL)
+		conf.ClientID = "CLIENT ID?"
+		conf.ClientSecret = "SECRET/="
+		conf.AuthStyle = oauth2.AuthStyleInHeader
+		conf.BasicAuthEncoding = tc.encoding
+		if _, err := conf.Token(context.Background()); err != nil {
+			t.Error(err)
+		}
+		ts.Close()
+	}
+}
+
+func TestTokenRequestJSONEncoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Content-Type"), "application/json"; got != want {
+			t.Errorf("Content-Type = %q; want %q", got, want)
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		if req["grant_type"] != "client_credentials" || req["audience"] != "audience1" || req["scope"] != "scope1 scope2" {
+			t.Errorf("request = %v", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token": "foo"}`)
+	}))
+	defer ts.Close()
+	conf := newConf(ts.URL)
+	conf.AuthStyle = oauth2.AuthStyleInHeader
+	conf.RequestEncoding = oauth2.RequestEncodingJSON
+	if _, err := conf.Token(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSharedAuthStyleCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token": "ACCESS_TOKEN", "token_type": "bearer"}`)
+	}))
+	defer ts.Close()
+
+	conf := newConf(ts.URL)
+	conf.AuthStyleCache = oauth2.NewAuthStyleCache(0, 0)
+	if _, err := conf.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := conf.AuthStyleCache.LookupClient(ts.URL+"/token", "CLIENT_ID"); !ok || got != oauth2.AuthStyleInHeader {
+		t.Errorf("LookupClient = %v, %v; want AuthStyleInHeader, true", got, ok)
+	}
+}
+
+func TestTokenSourceInspectable(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "expires_in": 3600}`, requests)
+	}))
+	defer ts.Close()
+
+	src, ok := newConf(ts.URL).TokenSource(context.Background()).(oauth2.InspectableTokenSource)
+	if !ok {
+		t.Fatal("TokenSource does not implement oauth2.InspectableTokenSource")
+	}
+	if tok := src.Peek(); tok != nil {
+		t.Errorf("Peek() before Token = %v; want nil", tok)
+	}
+	if _, err := src.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if tok := src.Peek(); tok == nil || tok.AccessToken != "token1" {
+		t.Errorf("Peek() = %v; want token1", tok)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests; want 1, Peek must not fetch tokens", requests)
+	}
+	src.Invalidate()
+	tok, err := src.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "token2" {
+		t.Errorf("Token() after Invalidate = %q; want token2", tok.AccessToken)
+	}
+}
+
+func TestUseRefreshTokens(t *testing.T) {
+	var grants []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		grant := r.PostForm.Get("grant_type")
+		grants = append(grants, grant)
+		if grant == "refresh_token" && r.PostForm.Get("refresh_token") != "refresh1" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "refresh_token": "refresh%d"}`, len(grants), len(grants))
+	}))
+	defer ts.Close()
+
+	conf := newConf(ts.URL)
+	conf.UseRefreshTokens = true
+	src := &tokenSource{ctx: context.Background(), conf: conf}
+	for i := 0; i < 3; i++ {
+		if _, err := src.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first refresh succeeds; the second fails and falls back to
+	// the client credentials grant.
+	want := []string{"client_credentials", "refresh_token", "refresh_token", "client_credentials"}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("grant types = %q; want %q", grants, want)
+	}
+}
+
+func TestTokenHTTPResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-1")
+		io.WriteString(w, `{"access_token": "ACCESS_TOKEN", "token_type": "bearer"}`)
+	}))
+	defer ts.Close()
+
+	tok, err := newConf(ts.URL).Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := tok.HTTPResponse(); res == nil || res.Header.Get("X-Request-Id") != "req-1" {
+		t.Errorf("HTTPResponse() = %v; want the token endpoint response", res)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// A Multiplexer returns token sources for the same client that differ
+// in audience or scopes, such as for a service that calls several APIs
+// each requiring its own token. The token sources share the client's
+// credentials, Config settings and the HTTP client from the context,
+// but each caches its own token.
+//
+// A Multiplexer is safe for concurrent use.
+type Multiplexer struct {
+	ctx  context.Context
+	conf *Config
+
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource
+}
+
+// Multiplexer returns a Multiplexer for c. The provided context
+// optionally controls which HTTP client is used by all of its token
+// sources. See the oauth2.HTTPClient variable.
+func (c *Config) Multiplexer(ctx context.Context) *Multiplexer {
+	return &Multiplexer{ctx: ctx, conf: c, sources: make(map[string]oauth2.TokenSource)}
+}
+
+// TokenSource returns the token source for tokens requested with
+// params, such as an "audience" or "resource" parameter, and scopes.
+// The parameters are added to the Config's EndpointParams, replacing
+// those with the same names, and scopes, if any, replace the Config's
+// Scopes.
+//
+// Calls with the same parameters and the same set of scopes, in any
+// order, return the same token source.
+func (m *Multiplexer) TokenSource(params url.Values, scopes ...string) oauth2.TokenSource {
+	sorted := append([]string(nil), scopes...)
+	sort.Strings(sorted)
+	key := strings.Join(sorted, " ") + "\x00" + params.Encode()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ts, ok := m.sources[key]; ok {
+		return ts
+	}
+	src := &tokenSource{ctx: m.ctx, conf: m.conf, params: make(url.Values, len(params))}
+	for k, v := range params {
+		src.params[k] = append([]string(nil), v...)
+	}
+	if len(scopes) > 0 {
+		src.scopes = sorted
+	}
+	ts := oauth2.ReuseTokenSource(nil, src)
+	m.sources[key] = ts
+	return ts
+}
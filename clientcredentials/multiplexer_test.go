@@ -0,0 +1,57 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clientcredentials
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestMultiplexer(t *testing.T) {
+	requests := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		key := r.PostForm.Get("audience") + "|" + r.PostForm.Get("scope")
+		requests[key]++
+		if got := r.PostForm.Get("static"); got != "x" {
+			t.Errorf("static = %q; want EndpointParams value %q", got, "x")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"`+key+`","token_type":"bearer","expires_in":3600}`)
+	}))
+	defer ts.Close()
+
+	conf := newConf(ts.URL)
+	conf.EndpointParams = url.Values{"static": {"x"}}
+	m := conf.Multiplexer(context.Background())
+
+	for _, tt := range []struct {
+		audience string
+		scopes   []string
+		want     string
+	}{
+		{"api-a", nil, "api-a|scope1 scope2"},
+		{"api-b", []string{"w", "r"}, "api-b|r w"},
+		{"api-b", []string{"r", "w"}, "api-b|r w"},
+		{"api-a", nil, "api-a|scope1 scope2"},
+	} {
+		tok, err := m.TokenSource(url.Values{"audience": {tt.audience}}, tt.scopes...).Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.AccessToken != tt.want {
+			t.Errorf("token for %s %v = %q; want %q", tt.audience, tt.scopes, tok.AccessToken, tt.want)
+		}
+	}
+	for key, n := range requests {
+		if n != 1 {
+			t.Errorf("%d requests for %s; want 1", n, key)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientPool caches *http.Clients keyed by Config and subject, so that
+// servers acting on behalf of many users share one token-refreshing
+// Transport per (Config, subject) pair instead of building a new one for
+// every request.
+//
+// The zero value is an unbounded pool ready to use. A ClientPool must not
+// be copied after first use.
+type ClientPool struct {
+	// MaxSize optionally limits the number of cached clients. When the
+	// limit is exceeded, the least recently used client is evicted.
+	// Zero means no limit.
+	MaxSize int
+
+	// IdleTimeout optionally evicts clients that have not been
+	// returned by Client for the given duration. Zero means cached
+	// clients never expire.
+	IdleTimeout time.Duration
+
+	mu  sync.Mutex
+	m   map[clientPoolKey]*list.Element
+	lru list.List // of *clientPoolEntry, most recently used first
+}
+
+type clientPoolKey struct {
+	conf    *Config
+	subject string
+}
+
+type clientPoolEntry struct {
+	key      clientPoolKey
+	client   *http.Client
+	lastUsed time.Time
+}
+
+// Client returns the cached client for conf and subject. If there is no
+// cached client, one is created with conf.Client and t and added to the
+// pool; t is otherwise ignored.
+//
+// Pooled clients outlive the request that creates them, so they are not
+// created with ctx itself, whose cancellation would break their token
+// refreshes: they use a background context that carries only the
+// HTTPClient value of ctx, if any, for their requests and refreshes.
+//
+// Configs are compared by pointer, so callers should reuse the same
+// *Config for every call that is meant to share a client.
+func (p *ClientPool) Client(ctx context.Context, conf *Config, subject string, t *Token) *http.Client {
+	key := clientPoolKey{conf, subject}
+	now := timeNow()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evictIdleLocked(now)
+	if el, ok := p.m[key]; ok {
+		e := el.Value.(*clientPoolEntry)
+		e.lastUsed = now
+		p.lru.MoveToFront(el)
+		return e.client
+	}
+	if p.m == nil {
+		p.m = make(map[clientPoolKey]*list.Element)
+	}
+	e := &clientPoolEntry{
+		key:      key,
+		client:   conf.Client(detachContext(ctx), t),
+		lastUsed: now,
+	}
+	p.m[key] = p.lru.PushFront(e)
+	for p.MaxSize > 0 && p.lru.Len() > p.MaxSize {
+		p.removeLocked(p.lru.Back())
+	}
+	return e.client
+}
+
+// Evict removes the client cached for conf and subject, if any.
+func (p *ClientPool) Evict(conf *Config, subject string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if el, ok := p.m[clientPoolKey{conf, subject}]; ok {
+		p.removeLocked(el)
+	}
+}
+
+// Len reports the number of cached clients.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evictIdleLocked(timeNow())
+	return p.lru.Len()
+}
+
+func (p *ClientPool) evictIdleLocked(now time.Time) {
+	if p.IdleTimeout <= 0 {
+		return
+	}
+	for el := p.lru.Back(); el != nil; el = p.lru.Back() {
+		if now.Sub(el.Value.(*clientPoolEntry).lastUsed) < p.IdleTimeout {
+			return
+		}
+		p.removeLocked(el)
+	}
+}
+
+func (p *ClientPool) removeLocked(el *list.Element) {
+	e := p.lru.Remove(el).(*clientPoolEntry)
+	delete(p.m, e.key)
+}
+
+// detachContext returns a context that is never canceled and carries
+// only the HTTPClient value of ctx.
+func detachContext(ctx context.Context) context.Context {
+	if c, ok := ctx.Value(HTTPClient).(*http.Client); ok {
+		return context.WithValue(context.Background(), HTTPClient, c)
+	}
+	return context.Background()
+}
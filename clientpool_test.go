@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientPoolReusesClients(t *testing.T) {
+	var p ClientPool
+	conf := newConf("server")
+	ctx := context.Background()
+	tok := &Token{AccessToken: "abc"}
+
+	c1 := p.Client(ctx, conf, "alice", tok)
+	if c2 := p.Client(ctx, conf, "alice", nil); c1 != c2 {
+		t.Errorf("Client returned a new client for a cached subject")
+	}
+	if c3 := p.Client(ctx, conf, "bob", tok); c1 == c3 {
+		t.Errorf("Client returned the same client for different subjects")
+	}
+	if got, want := p.Len(), 2; got != want {
+		t.Errorf("Len() = %d; want %d", got, want)
+	}
+	p.Evict(conf, "alice")
+	if got, want := p.Len(), 1; got != want {
+		t.Errorf("Len() after Evict = %d; want %d", got, want)
+	}
+	if c4 := p.Client(ctx, conf, "alice", tok); c1 == c4 {
+		t.Errorf("Client returned an evicted client")
+	}
+}
+
+func TestClientPoolMaxSize(t *testing.T) {
+	p := ClientPool{MaxSize: 2}
+	conf := newConf("server")
+	ctx := context.Background()
+
+	a := p.Client(ctx, conf, "a", nil)
+	p.Client(ctx, conf, "b", nil)
+	p.Client(ctx, conf, "a", nil) // a is now the most recently used
+	p.Client(ctx, conf, "c", nil) // evicts b
+	if got, want := p.Len(), 2; got != want {
+		t.Fatalf("Len() = %d; want %d", got, want)
+	}
+	if got := p.Client(ctx, conf, "a", nil); got != a {
+		t.Errorf("least recently used client was not the one evicted")
+	}
+}
+
+func TestClientPoolIdleTimeout(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	p := ClientPool{IdleTimeout: time.Minute}
+	conf := newConf("server")
+	ctx := context.Background()
+	a := p.Client(ctx, conf, "a", nil)
+
+	now = now.Add(30 * time.Second)
+	if got := p.Client(ctx, conf, "a", nil); got != a {
+		t.Errorf("client evicted before IdleTimeout")
+	}
+	now = now.Add(2 * time.Minute)
+	if got, want := p.Len(), 0; got != want {
+		t.Errorf("Len() after IdleTimeout = %d; want %d", got, want)
+	}
+}
+
+func TestClientPoolDetachedContext(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"fresh","expires_in":3600}`))
+		}
+	})
+	defer server.Close()
+
+	var p ClientPool
+	conf := newConf(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := p.Client(ctx, conf, "alice", &Token{AccessToken: "expired", RefreshToken: "r", Expiry: time.Now().Add(-time.Hour)})
+	cancel()
+
+	res, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatalf("request after the creating request's context was canceled: %v", err)
+	}
+	res.Body.Close()
+}
@@ -0,0 +1,438 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dcrp implements OAuth 2.0 Dynamic Client Registration
+// (RFC 7591) and its management protocol (RFC 7592).
+package dcrp // import "golang.org/x/oauth2/dcrp"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/internal"
+)
+
+// Metadata is the client metadata sent to and returned by the
+// registration endpoint. It covers RFC 7591, OpenID Connect Dynamic
+// Client Registration 1.0 and the OpenID Connect logout
+// specifications.
+// See https://datatracker.ietf.org/doc/html/rfc7591#section-2 and
+// https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata.
+type Metadata struct {
+	RedirectURIs            []string        `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod string          `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes              []string        `json:"grant_types,omitempty"`
+	ResponseTypes           []string        `json:"response_types,omitempty"`
+	ClientName              string          `json:"client_name,omitempty"`
+	ClientURI               string          `json:"client_uri,omitempty"`
+	LogoURI                 string          `json:"logo_uri,omitempty"`
+	Scope                   string          `json:"scope,omitempty"`
+	Contacts                []string        `json:"contacts,omitempty"`
+	TOSURI                  string          `json:"tos_uri,omitempty"`
+	PolicyURI               string          `json:"policy_uri,omitempty"`
+	JWKSURI                 string          `json:"jwks_uri,omitempty"`
+	JWKS                    json.RawMessage `json:"jwks,omitempty"`
+	SoftwareID              string          `json:"software_id,omitempty"`
+	SoftwareVersion         string          `json:"software_version,omitempty"`
+
+	// OpenID Connect Dynamic Client Registration 1.0.
+	ApplicationType              string   `json:"application_type,omitempty"`
+	SectorIdentifierURI          string   `json:"sector_identifier_uri,omitempty"`
+	SubjectType                  string   `json:"subject_type,omitempty"`
+	IDTokenSignedResponseAlg     string   `json:"id_token_signed_response_alg,omitempty"`
+	IDTokenEncryptedResponseAlg  string   `json:"id_token_encrypted_response_alg,omitempty"`
+	IDTokenEncryptedResponseEnc  string   `json:"id_token_encrypted_response_enc,omitempty"`
+	UserinfoSignedResponseAlg    string   `json:"userinfo_signed_response_alg,omitempty"`
+	UserinfoEncryptedResponseAlg string   `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserinfoEncryptedResponseEnc string   `json:"userinfo_encrypted_response_enc,omitempty"`
+	RequestObjectSigningAlg      string   `json:"request_object_signing_alg,omitempty"`
+	TokenEndpointAuthSigningAlg  string   `json:"token_endpoint_auth_signing_alg,omitempty"`
+	DefaultMaxAge                int64    `json:"default_max_age,omitempty"`
+	RequireAuthTime              bool     `json:"require_auth_time,omitempty"`
+	DefaultACRValues             []string `json:"default_acr_values,omitempty"`
+	InitiateLoginURI             string   `json:"initiate_login_uri,omitempty"`
+	RequestURIs                  []string `json:"request_uris,omitempty"`
+
+	// OpenID Connect RP-Initiated, Front-Channel and Back-Channel Logout.
+	PostLogoutRedirectURIs            []string `json:"post_logout_redirect_uris,omitempty"`
+	FrontchannelLogoutURI             string   `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool     `json:"frontchannel_logout_session_required,omitempty"`
+	BackchannelLogoutURI              string   `json:"backchannel_logout_uri,omitempty"`
+	BackchannelLogoutSessionRequired  bool     `json:"backchannel_logout_session_required,omitempty"`
+
+	// Extra holds metadata fields without a typed field above, such
+	// as server-specific extensions. They are sent with requests and
+	// filled in from responses, so unknown fields survive a Read and
+	// Update round trip. Typed fields take precedence.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// metadataFields is the set of JSON names of Metadata's typed fields.
+var metadataFields = func() map[string]bool {
+	m := make(map[string]bool)
+	t := reflect.TypeOf(Metadata{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			m[name] = true
+		}
+	}
+	return m
+}()
+
+func (md Metadata) MarshalJSON() ([]byte, error) {
+	type Alias Metadata
+	b, err := json.Marshal(Alias(md))
+	if err != nil || len(md.Extra) == 0 {
+		return b, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range md.Extra {
+		if !metadataFields[k] {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+func (md *Metadata) UnmarshalJSON(data []byte) error {
+	type Alias Metadata
+	if err := json.Unmarshal(data, (*Alias)(md)); err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	md.Extra = nil
+	for k, v := range m {
+		if metadataFields[k] {
+			continue
+		}
+		if md.Extra == nil {
+			md.Extra = make(map[string]json.RawMessage)
+		}
+		md.Extra[k] = v
+	}
+	return nil
+}
+
+// Config describes a registration request.
+type Config struct {
+	// RegistrationURL is the authorization server's client
+	// registration endpoint.
+	RegistrationURL string
+
+	// InitialAccessToken optionally authorizes the registration
+	// request, for servers that do not allow open registration.
+	InitialAccessToken string
+
+	// SoftwareStatement is an optional signed JWT asserting the
+	// client's metadata.
+	SoftwareStatement string
+
+	// Metadata is the metadata of the client to register.
+	Metadata Metadata
+
+	// HTTPClient optionally specifies the HTTP client used for
+	// registration and management requests, for example to route
+	// them through a proxy or authenticate with mutual TLS. If nil,
+	// the client from the request's context is used, as with
+	// oauth2.HTTPClient.
+	HTTPClient *http.Client
+
+	// RequestTimeout optionally limits the duration of each
+	// registration and management request. Zero means a default of
+	// 30 seconds; a negative value means no limit other than that of
+	// the context.
+	RequestTimeout time.Duration
+}
+
+// defaultRequestTimeout is the request timeout used when
+// Config.RequestTimeout is zero.
+const defaultRequestTimeout = 30 * time.Second
+
+// Client is a registered client, as returned by the registration
+// endpoint or the client configuration endpoint.
+type Client struct {
+	// ClientID is the client identifier.
+	ClientID string
+
+	// ClientSecret is the client secret, if one was issued.
+	ClientSecret string
+
+	// ClientIDIssuedAt is when ClientID was issued, if known.
+	ClientIDIssuedAt time.Time
+
+	// ClientSecretExpiresAt is when ClientSecret expires. It is zero
+	// if the secret does not expire.
+	ClientSecretExpiresAt time.Time
+
+	// RegistrationAccessToken authorizes the management operations
+	// Read, Update and Deregister.
+	RegistrationAccessToken string
+
+	// RegistrationClientURI is the client configuration endpoint
+	// used by the management operations.
+	RegistrationClientURI string
+
+	// Metadata is the client's registered metadata, which may differ
+	// from the metadata that was requested.
+	Metadata Metadata
+
+	// conf is the Config that registered the client, whose HTTPClient
+	// and RequestTimeout are used for management requests. It is nil
+	// for Clients built by the caller.
+	conf *Config
+}
+
+// Error describes an error response from the registration endpoint or
+// the client configuration endpoint.
+// See https://datatracker.ietf.org/doc/html/rfc7591#section-3.2.2.
+type Error struct {
+	Response *http.Response
+	// Body is the body that was consumed by reading Response.Body.
+	// It may be truncated.
+	Body []byte
+	// ErrorCode is the 'error' parameter, such as
+	// "invalid_redirect_uri" or "invalid_client_metadata".
+	ErrorCode string
+	// ErrorDescription is the 'error_description' parameter.
+	ErrorDescription string
+}
+
+func (e *Error) Error() string {
+	if e.ErrorCode != "" {
+		s := fmt.Sprintf("dcrp: %q", e.ErrorCode)
+		if e.ErrorDescription != "" {
+			s += fmt.Sprintf(" %q", e.ErrorDescription)
+		}
+		return s
+	}
+	return fmt.Sprintf("dcrp: server response: %v\nResponse: %s", e.Response.Status, e.Body)
+}
+
+// Register registers a new client at c.RegistrationURL.
+func (c *Config) Register(ctx context.Context) (*Client, error) {
+	if c.RegistrationURL == "" {
+		return nil, errors.New("dcrp: RegistrationURL must be set")
+	}
+	body, err := requestBody(c.Metadata, map[string]string{"software_statement": c.SoftwareStatement})
+	if err != nil {
+		return nil, err
+	}
+	cl, _, err := c.do(ctx, "POST", c.RegistrationURL, c.InitialAccessToken, body, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	cl.conf = c
+	return cl, nil
+}
+
+// Read returns the current registration of cl.
+// See https://datatracker.ietf.org/doc/html/rfc7592#section-2.1.
+func (cl *Client) Read(ctx context.Context) (*Client, error) {
+	if err := cl.checkManageable(); err != nil {
+		return nil, err
+	}
+	return cl.update(cl.conf.do(ctx, "GET", cl.RegistrationClientURI, cl.RegistrationAccessToken, nil, http.StatusOK))
+}
+
+// Update replaces the registered metadata of cl with md. Servers may
+// rotate the client secret or registration access token in response;
+// the returned Client holds the current values.
+// See https://datatracker.ietf.org/doc/html/rfc7592#section-2.2.
+func (cl *Client) Update(ctx context.Context, md Metadata) (*Client, error) {
+	if err := cl.checkManageable(); err != nil {
+		return nil, err
+	}
+	body, err := requestBody(md, map[string]string{
+		"client_id":     cl.ClientID,
+		"client_secret": cl.ClientSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cl.update(cl.conf.do(ctx, "PUT", cl.RegistrationClientURI, cl.RegistrationAccessToken, body, http.StatusOK))
+}
+
+// Deregister deletes the registration of cl. The client's credentials
+// are invalid afterwards.
+// See https://datatracker.ietf.org/doc/html/rfc7592#section-2.3.
+func (cl *Client) Deregister(ctx context.Context) error {
+	if err := cl.checkManageable(); err != nil {
+		return err
+	}
+	_, _, err := cl.conf.do(ctx, "DELETE", cl.RegistrationClientURI, cl.RegistrationAccessToken, nil, http.StatusNoContent)
+	return err
+}
+
+func (cl *Client) checkManageable() error {
+	if cl.RegistrationClientURI == "" || cl.RegistrationAccessToken == "" {
+		return errors.New("dcrp: client has no RegistrationClientURI or RegistrationAccessToken")
+	}
+	return nil
+}
+
+// update fills in the management fields of a Read or Update response
+// that the server did not repeat.
+func (cl *Client) update(res *Client, raw map[string]json.RawMessage, err error) (*Client, error) {
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := raw["client_secret"]; !ok {
+		res.ClientSecret = cl.ClientSecret
+		res.ClientSecretExpiresAt = cl.ClientSecretExpiresAt
+	}
+	if res.RegistrationAccessToken == "" {
+		res.RegistrationAccessToken = cl.RegistrationAccessToken
+	}
+	if res.RegistrationClientURI == "" {
+		res.RegistrationClientURI = cl.RegistrationClientURI
+	}
+	res.conf = cl.conf
+	return res, nil
+}
+
+// requestBody returns md encoded as a JSON object with the non-empty
+// values of extra added.
+func requestBody(md Metadata, extra map[string]string) ([]byte, error) {
+	b, err := json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// do sends a request with an optional bearer token and JSON body, and
+// parses the client information in the response unless the method is
+// DELETE. Any status other than want is an error. A nil c uses the
+// context's HTTP client and the default timeout.
+func (c *Config) do(ctx context.Context, method, url, token string, body []byte, want int) (*Client, map[string]json.RawMessage, error) {
+	hc := internal.ContextClient(ctx)
+	timeout := defaultRequestTimeout
+	if c != nil {
+		if c.HTTPClient != nil {
+			hc = c.HTTPClient
+		}
+		if c.RequestTimeout != 0 {
+			timeout = c.RequestTimeout
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dcrp: %v", err)
+	}
+	defer res.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, nil, fmt.Errorf("dcrp: cannot read response: %v", err)
+	}
+	// Accept 200 OK from registration endpoints that do not use 201.
+	if res.StatusCode != want && !(want == http.StatusCreated && res.StatusCode == http.StatusOK) {
+		e := &Error{Response: res, Body: respBody}
+		var er struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(respBody, &er) == nil {
+			e.ErrorCode, e.ErrorDescription = er.Error, er.ErrorDescription
+		}
+		return nil, nil, e
+	}
+	if method == "DELETE" {
+		return nil, nil, nil
+	}
+	cl, raw, err := parseClient(respBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dcrp: cannot parse response: %v", err)
+	}
+	return cl, raw, nil
+}
+
+// parseClient parses a client information response.
+// See https://datatracker.ietf.org/doc/html/rfc7591#section-3.2.1.
+func parseClient(b []byte) (*Client, map[string]json.RawMessage, error) {
+	var info struct {
+		ClientID                string `json:"client_id"`
+		ClientSecret            string `json:"client_secret"`
+		ClientIDIssuedAt        int64  `json:"client_id_issued_at"`
+		ClientSecretExpiresAt   int64  `json:"client_secret_expires_at"`
+		RegistrationAccessToken string `json:"registration_access_token"`
+		RegistrationClientURI   string `json:"registration_client_uri"`
+	}
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, nil, err
+	}
+	if info.ClientID == "" {
+		return nil, nil, errors.New("missing client_id")
+	}
+	cl := &Client{
+		ClientID:                info.ClientID,
+		ClientSecret:            info.ClientSecret,
+		RegistrationAccessToken: info.RegistrationAccessToken,
+		RegistrationClientURI:   info.RegistrationClientURI,
+	}
+	if info.ClientIDIssuedAt != 0 {
+		cl.ClientIDIssuedAt = time.Unix(info.ClientIDIssuedAt, 0)
+	}
+	if info.ClientSecretExpiresAt != 0 {
+		cl.ClientSecretExpiresAt = time.Unix(info.ClientSecretExpiresAt, 0)
+	}
+	if err := json.Unmarshal(b, &cl.Metadata); err != nil {
+		return nil, nil, err
+	}
+	// The client information fields are not metadata.
+	for _, k := range []string{"client_id", "client_secret", "client_id_issued_at",
+		"client_secret_expires_at", "registration_access_token", "registration_client_uri"} {
+		delete(cl.Metadata.Extra, k)
+	}
+	if len(cl.Metadata.Extra) == 0 {
+		cl.Metadata.Extra = nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, nil, err
+	}
+	return cl, raw, nil
+}
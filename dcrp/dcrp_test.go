@@ -0,0 +1,191 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dcrp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRegisterAndManage(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /register":
+			if got, want := r.Header.Get("Authorization"), "Bearer initial"; got != want {
+				t.Errorf("Authorization = %q; want %q", got, want)
+			}
+			if req["software_statement"] != "stmt" || req["client_name"] != "app" {
+				t.Errorf("registration request = %v", req)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"client_id":"id","client_secret":"s1","client_id_issued_at":1700000000,"client_secret_expires_at":0,
+				"registration_access_token":"rat","registration_client_uri":"` + ts.URL + `/clients/id",
+				"client_name":"app","redirect_uris":["https://app/cb"]}`))
+		case "GET /clients/id":
+			if got, want := r.Header.Get("Authorization"), "Bearer rat"; got != want {
+				t.Errorf("Authorization = %q; want %q", got, want)
+			}
+			w.Write([]byte(`{"client_id":"id","client_name":"app","redirect_uris":["https://app/cb"]}`))
+		case "PUT /clients/id":
+			if req["client_id"] != "id" || req["client_secret"] != "s1" {
+				t.Errorf("update request = %v", req)
+			}
+			if got, want := req["redirect_uris"], []interface{}{"https://app/new"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("redirect_uris = %v; want %v", got, want)
+			}
+			w.Write([]byte(`{"client_id":"id","client_secret":"s2","registration_access_token":"rat2","redirect_uris":["https://app/new"]}`))
+		case "DELETE /clients/id":
+			if got, want := r.Header.Get("Authorization"), "Bearer rat2"; got != want {
+				t.Errorf("Authorization = %q; want %q", got, want)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	conf := &Config{
+		RegistrationURL:    ts.URL + "/register",
+		InitialAccessToken: "initial",
+		SoftwareStatement:  "stmt",
+		Metadata:           Metadata{ClientName: "app", RedirectURIs: []string{"https://app/cb"}},
+	}
+	cl, err := conf.Register(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.ClientID != "id" || cl.ClientSecret != "s1" || cl.ClientIDIssuedAt.Unix() != 1700000000 || !cl.ClientSecretExpiresAt.IsZero() {
+		t.Errorf("Register() = %+v", cl)
+	}
+
+	got, err := cl.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClientSecret != "s1" || got.RegistrationAccessToken != "rat" || got.Metadata.ClientName != "app" {
+		t.Errorf("Read() = %+v; want secret and registration access token kept", got)
+	}
+
+	cl, err = cl.Update(ctx, Metadata{RedirectURIs: []string{"https://app/new"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.ClientSecret != "s2" || cl.RegistrationAccessToken != "rat2" || cl.RegistrationClientURI != ts.URL+"/clients/id" {
+		t.Errorf("Update() = %+v; want rotated credentials", cl)
+	}
+
+	if err := cl.Deregister(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_redirect_uri","error_description":"bad uri"}`))
+	}))
+	defer ts.Close()
+
+	_, err := (&Config{RegistrationURL: ts.URL}).Register(context.Background())
+	var e *Error
+	if !errors.As(err, &e) || e.ErrorCode != "invalid_redirect_uri" || e.ErrorDescription != "bad uri" {
+		t.Fatalf("Register() error = %v; want invalid_redirect_uri Error", err)
+	}
+	if _, err := (&Client{ClientID: "id"}).Read(context.Background()); err == nil {
+		t.Error("Read() succeeded without registration access token")
+	}
+}
+
+func TestHTTPClientAndTimeout(t *testing.T) {
+	var requests []string
+	hc := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, r.Method)
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("%s request has no deadline", r.Method)
+		}
+		body := `{"client_id":"id","registration_access_token":"rat","registration_client_uri":"https://as/clients/id"}`
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	conf := &Config{RegistrationURL: "https://as/register", HTTPClient: hc}
+	cl, err := conf.Register(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Management requests use the Config's client too. The fake
+	// server's 201 is not a valid Read response.
+	if _, err := cl.Read(context.Background()); err == nil {
+		t.Error("Read() succeeded with status 201")
+	}
+	if want := []string{"POST", "GET"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v; want %v", requests, want)
+	}
+
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+	conf = &Config{RegistrationURL: ts.URL, RequestTimeout: 10 * time.Millisecond}
+	if _, err := conf.Register(context.Background()); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Register() error = %v; want deadline exceeded", err)
+	}
+}
+
+func TestMetadataExtraRoundTrip(t *testing.T) {
+	const in = `{"client_id":"id","client_secret":"s","redirect_uris":["https://app/cb"],
+		"post_logout_redirect_uris":["https://app/bye"],"backchannel_logout_uri":"https://app/bcl",
+		"backchannel_logout_session_required":true,"id_token_signed_response_alg":"ES256",
+		"x_tenant":"acme","x_settings":{"a":1}}`
+	cl, _, err := parseClient([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := cl.Metadata
+	if md.BackchannelLogoutURI != "https://app/bcl" || !md.BackchannelLogoutSessionRequired || md.IDTokenSignedResponseAlg != "ES256" ||
+		!reflect.DeepEqual(md.PostLogoutRedirectURIs, []string{"https://app/bye"}) {
+		t.Errorf("typed fields = %+v", md)
+	}
+	wantExtra := map[string]json.RawMessage{"x_tenant": json.RawMessage(`"acme"`), "x_settings": json.RawMessage(`{"a":1}`)}
+	if !reflect.DeepEqual(md.Extra, wantExtra) {
+		t.Errorf("Extra = %s; want %s", md.Extra, wantExtra)
+	}
+
+	md.Extra["redirect_uris"] = json.RawMessage(`["https://evil/cb"]`)
+	b, err := json.Marshal(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	json.Unmarshal(b, &out)
+	if out["x_tenant"] != "acme" || out["backchannel_logout_uri"] != "https://app/bcl" {
+		t.Errorf("Marshal() = %s", b)
+	}
+	if got, want := out["redirect_uris"], []interface{}{"https://app/cb"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("redirect_uris = %v; want typed field %v to take precedence", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package oauth2
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -37,6 +38,20 @@ type DeviceAuthResponse struct {
 	Expiry time.Time `json:"expires_in,omitempty"`
 	// Interval is the duration in seconds that Poll should wait between requests
 	Interval int64 `json:"interval,omitempty"`
+
+	// raw holds all the fields of the response, as returned by Extra.
+	// It is a pointer so that DeviceAuthResponse remains comparable.
+	raw *map[string]interface{}
+}
+
+// Extra returns a field of the device authorization response that is
+// not one of the fields of DeviceAuthResponse, such as the "message"
+// with instructions for the user returned by Microsoft, or nil.
+func (d *DeviceAuthResponse) Extra(key string) interface{} {
+	if d.raw == nil {
+		return nil
+	}
+	return (*d.raw)[key]
 }
 
 func (d DeviceAuthResponse) MarshalJSON() ([]byte, error) {
@@ -68,6 +83,11 @@ func (c *DeviceAuthResponse) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
+	c.raw = nil
+	var raw map[string]interface{}
+	if json.Unmarshal(data, &raw) == nil { // no error checks for optional fields
+		c.raw = &raw
+	}
 	if aux.ExpiresIn != 0 {
 		c.Expiry = time.Now().UTC().Add(time.Second * time.Duration(aux.ExpiresIn))
 	}
@@ -79,6 +99,11 @@ func (c *DeviceAuthResponse) UnmarshalJSON(data []byte) error {
 
 // DeviceAuth returns a device auth struct which contains a device code
 // and authorization information provided for users to enter on another device.
+//
+// Opts are added to the device authorization request. Providers that require
+// extra parameters (such as an audience, resource or tenant, passed with
+// SetParams, or a device model) usually expect them on the token polls too,
+// so the same opts should also be passed to DeviceAccessToken.
 func (c *Config) DeviceAuth(ctx context.Context, opts ...AuthCodeOption) (*DeviceAuthResponse, error) {
 	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
 	v := url.Values{
@@ -87,31 +112,41 @@ func (c *Config) DeviceAuth(ctx context.Context, opts ...AuthCodeOption) (*Devic
 	if len(c.Scopes) > 0 {
 		v.Set("scope", strings.Join(c.Scopes, " "))
 	}
-	for _, opt := range opts {
-		opt.setValue(v)
-	}
-	return retrieveDeviceAuth(ctx, c, v)
+	h := setTokenRequestValues(v, opts)
+	return retrieveDeviceAuth(ctx, c, v, h)
 }
 
-func retrieveDeviceAuth(ctx context.Context, c *Config, v url.Values) (*DeviceAuthResponse, error) {
+func retrieveDeviceAuth(ctx context.Context, c *Config, v url.Values, h http.Header) (*DeviceAuthResponse, error) {
 	if c.Endpoint.DeviceAuthURL == "" {
 		return nil, errors.New("endpoint missing DeviceAuthURL")
 	}
 
-	req, err := http.NewRequest("POST", c.Endpoint.DeviceAuthURL, strings.NewReader(v.Encode()))
+	reqBody, contentType, err := internal.EncodeBody(v, c.RequestEncoding == RequestEncodingJSON)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req, err := http.NewRequest("POST", c.Endpoint.DeviceAuthURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range h {
+		if k = http.CanonicalHeaderKey(k); k != "Authorization" {
+			req.Header[k] = append([]string(nil), vv...)
+		}
+	}
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
+	ctx, cancel := internal.WithRequestTimeout(ctx, c.TokenRequestTimeout)
+	defer cancel()
 	t := time.Now()
-	r, err := internal.ContextClient(ctx).Do(req)
+	r, err := internal.ContextClient(ctx).Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
 	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	r.Body.Close()
 	if err != nil {
 		return nil, fmt.Errorf("oauth2: cannot auth device: %v", err)
 	}
@@ -131,16 +166,25 @@ func retrieveDeviceAuth(ctx context.Context, c *Config, v url.Values) (*DeviceAu
 	if !da.Expiry.IsZero() {
 		// Make a small adjustment to account for time taken by the request
 		da.Expiry = da.Expiry.Add(-time.Since(t))
+		if c.Now != nil {
+			// UnmarshalJSON computed Expiry from time.Now.
+			da.Expiry = da.Expiry.Add(c.Now().Sub(time.Now()))
+		}
 	}
 
 	return da, nil
 }
 
 // DeviceAccessToken polls the server to exchange a device code for a token.
+//
+// Opts are added to every poll of the token endpoint, in the same way
+// DeviceAuth adds them to the device authorization request.
 func (c *Config) DeviceAccessToken(ctx context.Context, da *DeviceAuthResponse, opts ...AuthCodeOption) (*Token, error) {
 	if !da.Expiry.IsZero() {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithDeadline(ctx, da.Expiry)
+		// Expiry is relative to c.Now, while context deadlines use
+		// the wall clock.
+		ctx, cancel = context.WithTimeout(ctx, da.Expiry.Sub(c.now()))
 		defer cancel()
 	}
 
@@ -153,9 +197,7 @@ func (c *Config) DeviceAccessToken(ctx context.Context, da *DeviceAuthResponse,
 	if len(c.Scopes) > 0 {
 		v.Set("scope", strings.Join(c.Scopes, " "))
 	}
-	for _, opt := range opts {
-		opt.setValue(v)
-	}
+	h := setTokenRequestValues(v, opts)
 
 	// "If no value is provided, clients MUST use 5 as the default."
 	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
@@ -171,7 +213,7 @@ func (c *Config) DeviceAccessToken(ctx context.Context, da *DeviceAuthResponse,
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-ticker.C:
-			tok, err := retrieveToken(ctx, c, v)
+			tok, err := retrieveTokenWithHeader(ctx, c, v, h)
 			if err == nil {
 				return tok, nil
 			}
@@ -4,6 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -95,3 +100,114 @@ func ExampleConfig_DeviceAuth() {
 	}
 	fmt.Println(token)
 }
+
+func TestDeviceAuthOptionsAppliedToBothRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+			return
+		}
+		if got, want := r.PostForm.Get("model"), "tv-3000"; got != want {
+			t.Errorf("%s: model = %q; want %q", r.URL.Path, got, want)
+		}
+		if got, want := r.PostForm.Get("firmware"), "1.2.3"; got != want {
+			t.Errorf("%s: firmware = %q; want %q", r.URL.Path, got, want)
+		}
+		if got, want := r.Header.Get("X-Api-Version"), "2"; got != want {
+			t.Errorf("%s: X-Api-Version = %q; want %q", r.URL.Path, got, want)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("%s: Authorization = %q; want none", r.URL.Path, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			w.Write([]byte(`{"device_code":"dc","user_code":"uc","verification_uri":"https://example.com/device","expires_in":60,"interval":1}`))
+		case "/token":
+			if got, want := r.PostForm.Get("device_code"), "dc"; got != want {
+				t.Errorf("device_code = %q; want %q", got, want)
+			}
+			w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+		default:
+			t.Errorf("unexpected request to %q", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID: "CLIENT_ID",
+		Endpoint: Endpoint{
+			DeviceAuthURL: ts.URL + "/device",
+			TokenURL:      ts.URL + "/token",
+			AuthStyle:     AuthStyleInParams,
+		},
+	}
+	opts := []AuthCodeOption{
+		SetAuthURLParam("model", "tv-3000"),
+		SetAuthURLParam("firmware", "1.2.3"),
+		SetTokenRequestHeader("X-API-Version", "2"),
+		SetTokenRequestHeader("authorization", "Bearer secret"),
+	}
+	ctx := context.Background()
+	da, err := conf.DeviceAuth(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := conf.DeviceAccessToken(ctx, da, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tok.AccessToken, "token"; got != want {
+		t.Errorf("AccessToken = %q; want %q", got, want)
+	}
+}
+
+func TestDeviceAuthParamsAndExtra(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			w.Write([]byte(`{"device_code":"dc","user_code":"uc","verification_uri":"https://example.com/device","interval":1,"message":"Enter uc at https://example.com/device"}`))
+		case "/token":
+			w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+		}
+	}))
+	defer ts.Close()
+
+	conf := &Config{
+		ClientID: "CLIENT_ID",
+		Endpoint: Endpoint{
+			DeviceAuthURL: ts.URL + "/device",
+			TokenURL:      ts.URL + "/token",
+			AuthStyle:     AuthStyleInParams,
+		},
+	}
+	params := SetParams(url.Values{
+		"resource": {"https://api1", "https://api2"},
+		"audience": {"aud"},
+	})
+	ctx := context.Background()
+	da, err := conf.DeviceAuth(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := da.Extra("message"), "Enter uc at https://example.com/device"; got != want {
+		t.Errorf("Extra(message) = %v; want %q", got, want)
+	}
+	if copied := *da; copied != *da {
+		t.Error("copy of DeviceAuthResponse is not equal to the original")
+	}
+	if _, err := conf.DeviceAccessToken(ctx, da, params); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"audience=aud&client_id=CLIENT_ID&resource=https%3A%2F%2Fapi1&resource=https%3A%2F%2Fapi2",
+		"audience=aud&client_id=CLIENT_ID&device_code=dc&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Adevice_code&resource=https%3A%2F%2Fapi1&resource=https%3A%2F%2Fapi2",
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("request bodies = %q; want %q", bodies, want)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deviceflow implements the OAuth 2.0 Device Authorization Grant
+// (RFC 8628) for constrained devices, persisting the authorization
+// state so that a device that reboots while the user is authorizing it
+// resumes polling instead of starting over.
+package deviceflow // import "golang.org/x/oauth2/deviceflow"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Config describes a device flow client.
+type Config struct {
+	// ClientID is the application's ID.
+	ClientID string
+
+	// ClientSecret is the application's secret, if it has one.
+	ClientSecret string
+
+	// Endpoint contains the authorization server's device
+	// authorization and token endpoint URLs.
+	Endpoint oauth2.Endpoint
+
+	// Scopes specifies optional requested permissions.
+	Scopes []string
+
+	// Storage persists the authorization state across restarts.
+	// Required.
+	Storage Storage
+
+	// Prompt is called with the device authorization the user must
+	// complete, both when a new authorization starts and when an
+	// unexpired one is resumed from Storage, so the device can
+	// display the user code and verification URI again. Required.
+	Prompt func(*oauth2.DeviceAuthResponse) error
+}
+
+// State is the persisted state of a device. Either Token is set, or
+// the other fields describe a pending authorization, or State is empty.
+type State struct {
+	DeviceCode              string    `json:"device_code,omitempty"`
+	UserCode                string    `json:"user_code,omitempty"`
+	VerificationURI         string    `json:"verification_uri,omitempty"`
+	VerificationURIComplete string    `json:"verification_uri_complete,omitempty"`
+	Expiry                  time.Time `json:"expiry,omitempty"`
+	Interval                int64     `json:"interval,omitempty"`
+
+	// Token is the token obtained by the authorization, updated
+	// whenever it is refreshed.
+	Token *oauth2.Token `json:"token,omitempty"`
+}
+
+// Storage loads and saves a device's State, for example in a file or
+// in non-volatile memory.
+type Storage interface {
+	// Load returns the saved state, or nil if there is none.
+	Load() (*State, error)
+
+	// Save replaces the saved state.
+	Save(*State) error
+}
+
+// TokenSource returns a TokenSource that returns the token in c.Storage,
+// refreshing it when it expires. If there is no token, the first call
+// to Token resumes the pending authorization in c.Storage, or starts a
+// new one, and blocks until the user completes it, it expires or ctx
+// is done. A token whose refresh token is rejected with invalid_grant,
+// and a pending authorization that was denied or expired, are cleared
+// from c.Storage so that a new authorization starts.
+func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &tokenSource{ctx: ctx, conf: c})
+}
+
+type tokenSource struct {
+	ctx  context.Context
+	conf *Config
+
+	mu sync.Mutex // serializes access to Storage
+}
+
+func (c *Config) oauth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Endpoint:     c.Endpoint,
+		Scopes:       c.Scopes,
+	}
+}
+
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conf.Storage == nil || s.conf.Prompt == nil {
+		return nil, errors.New("deviceflow: Storage and Prompt must be set")
+	}
+	st, err := s.conf.Storage.Load()
+	if err != nil {
+		return nil, fmt.Errorf("deviceflow: cannot load state: %v", err)
+	}
+	if st == nil {
+		st = &State{}
+	}
+	oc := s.conf.oauth2Config()
+
+	if st.Token != nil {
+		if st.Token.Valid() {
+			return st.Token, nil
+		}
+		if st.Token.RefreshToken != "" {
+			tok, err := oc.TokenSource(s.ctx, st.Token).Token()
+			if err == nil {
+				return tok, s.save(&State{Token: tok})
+			}
+			if !hasErrorCode(err, "invalid_grant") {
+				return nil, err
+			}
+			// The refresh token was revoked or expired, so the device
+			// must be authorized again.
+		}
+		st = &State{}
+		if err := s.save(st); err != nil {
+			return nil, err
+		}
+	}
+
+	da := &oauth2.DeviceAuthResponse{
+		DeviceCode:              st.DeviceCode,
+		UserCode:                st.UserCode,
+		VerificationURI:         st.VerificationURI,
+		VerificationURIComplete: st.VerificationURIComplete,
+		Expiry:                  st.Expiry,
+		Interval:                st.Interval,
+	}
+	if da.DeviceCode == "" || (!da.Expiry.IsZero() && !da.Expiry.After(time.Now())) {
+		da, err = oc.DeviceAuth(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = s.save(&State{
+			DeviceCode:              da.DeviceCode,
+			UserCode:                da.UserCode,
+			VerificationURI:         da.VerificationURI,
+			VerificationURIComplete: da.VerificationURIComplete,
+			Expiry:                  da.Expiry,
+			Interval:                da.Interval,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.conf.Prompt(da); err != nil {
+		return nil, err
+	}
+	tok, err := oc.DeviceAccessToken(s.ctx, da)
+	if err != nil {
+		// The pending authorization cannot complete anymore; forget it
+		// so that the next call starts a new one.
+		if hasErrorCode(err, "access_denied", "expired_token") {
+			if err := s.save(&State{}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	return tok, s.save(&State{Token: tok})
+}
+
+// hasErrorCode reports whether err is an *oauth2.RetrieveError with one
+// of the given error codes.
+func hasErrorCode(err error, codes ...string) bool {
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) {
+		return false
+	}
+	for _, code := range codes {
+		if re.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *tokenSource) save(st *State) error {
+	if err := s.conf.Storage.Save(st); err != nil {
+		return fmt.Errorf("deviceflow: cannot save state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deviceflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type memStorage struct{ b []byte }
+
+func (m *memStorage) Load() (*State, error) {
+	if m.b == nil {
+		return nil, nil
+	}
+	st := new(State)
+	return st, json.Unmarshal(m.b, st)
+}
+
+func (m *memStorage) Save(st *State) error {
+	b, err := json.Marshal(st)
+	m.b = b
+	return err
+}
+
+func newServer(t *testing.T, deviceAuths *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			*deviceAuths++
+			w.Write([]byte(`{"device_code":"dc","user_code":"UC","verification_uri":"https://example.com/device","expires_in":600,"interval":1}`))
+		case "/token":
+			r.ParseForm()
+			switch r.PostForm.Get("grant_type") {
+			case "urn:ietf:params:oauth:grant-type:device_code":
+				if got := r.PostForm.Get("device_code"); got != "dc" {
+					t.Errorf("device_code = %q; want %q", got, "dc")
+				}
+				w.Write([]byte(`{"access_token":"at1","token_type":"Bearer","refresh_token":"rt","expires_in":1}`))
+			case "refresh_token":
+				w.Write([]byte(`{"access_token":"at2","token_type":"Bearer","expires_in":3600}`))
+			default:
+				t.Errorf("unexpected grant_type %q", r.PostForm.Get("grant_type"))
+			}
+		}
+	}))
+}
+
+func TestTokenSourceResumesPendingAuthorization(t *testing.T) {
+	var deviceAuths int
+	ts := newServer(t, &deviceAuths)
+	defer ts.Close()
+
+	// The device rebooted after starting an authorization.
+	storage := &memStorage{}
+	storage.Save(&State{
+		DeviceCode:      "dc",
+		UserCode:        "UC",
+		VerificationURI: "https://example.com/device",
+		Expiry:          time.Now().Add(time.Minute),
+		Interval:        1,
+	})
+	var prompted string
+	conf := &Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{DeviceAuthURL: ts.URL + "/device", TokenURL: ts.URL + "/token"},
+		Storage:  storage,
+		Prompt: func(da *oauth2.DeviceAuthResponse) error {
+			prompted = da.UserCode
+			return nil
+		},
+	}
+	tok, err := conf.TokenSource(context.Background()).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "at1" || deviceAuths != 0 || prompted != "UC" {
+		t.Errorf("got token %q after %d device authorizations, prompted with %q; want at1, 0, UC", tok.AccessToken, deviceAuths, prompted)
+	}
+
+	// After another reboot the saved token, which expires within the
+	// expiry delta, is refreshed without prompting.
+	prompted = ""
+	tok, err = conf.TokenSource(context.Background()).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, _ := storage.Load()
+	if tok.AccessToken != "at2" || st.Token.AccessToken != "at2" || st.Token.RefreshToken != "rt" || prompted != "" {
+		t.Errorf("refreshed token %q, saved %+v, prompted %q", tok.AccessToken, st.Token, prompted)
+	}
+}
+
+func TestTokenSourceStartsAuthorization(t *testing.T) {
+	var deviceAuths int
+	ts := newServer(t, &deviceAuths)
+	defer ts.Close()
+
+	storage := &memStorage{}
+	conf := &Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{DeviceAuthURL: ts.URL + "/device", TokenURL: ts.URL + "/token"},
+		Storage:  storage,
+		Prompt: func(da *oauth2.DeviceAuthResponse) error {
+			// The authorization is saved before the user is prompted.
+			if st, _ := storage.Load(); st == nil || st.DeviceCode != da.DeviceCode {
+				t.Errorf("saved state = %+v; want pending authorization", st)
+			}
+			return nil
+		},
+	}
+	if _, err := conf.TokenSource(context.Background()).Token(); err != nil {
+		t.Fatal(err)
+	}
+	if deviceAuths != 1 {
+		t.Errorf("%d device authorizations; want 1", deviceAuths)
+	}
+}
+
+func TestTokenSourceReauthorizesAfterInvalidGrant(t *testing.T) {
+	var deviceAuths int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			deviceAuths++
+			w.Write([]byte(`{"device_code":"dc","user_code":"UC","verification_uri":"https://example.com/device","expires_in":600,"interval":1}`))
+		case "/token":
+			r.ParseForm()
+			if r.PostForm.Get("grant_type") == "refresh_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"at","token_type":"Bearer","expires_in":3600}`))
+		}
+	}))
+	defer ts.Close()
+
+	// The saved refresh token was revoked.
+	storage := &memStorage{}
+	storage.Save(&State{Token: &oauth2.Token{AccessToken: "old", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)}})
+	conf := &Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{DeviceAuthURL: ts.URL + "/device", TokenURL: ts.URL + "/token"},
+		Storage:  storage,
+		Prompt:   func(*oauth2.DeviceAuthResponse) error { return nil },
+	}
+	tok, err := conf.TokenSource(context.Background()).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, _ := storage.Load()
+	if tok.AccessToken != "at" || deviceAuths != 1 || st.Token == nil || st.Token.AccessToken != "at" {
+		t.Errorf("got token %q after %d device authorizations, saved %+v; want at, 1", tok.AccessToken, deviceAuths, st)
+	}
+}
+
+func TestTokenSourceClearsDeniedAuthorization(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"access_denied"}`))
+	}))
+	defer ts.Close()
+
+	storage := &memStorage{}
+	storage.Save(&State{
+		DeviceCode:      "dc",
+		UserCode:        "UC",
+		VerificationURI: "https://example.com/device",
+		Expiry:          time.Now().Add(time.Minute),
+		Interval:        1,
+	})
+	conf := &Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{DeviceAuthURL: ts.URL + "/device", TokenURL: ts.URL + "/token"},
+		Storage:  storage,
+		Prompt:   func(*oauth2.DeviceAuthResponse) error { return nil },
+	}
+	if _, err := conf.TokenSource(context.Background()).Token(); err == nil {
+		t.Fatal("Token() succeeded after access_denied")
+	}
+	if st, _ := storage.Load(); st.DeviceCode != "" {
+		t.Errorf("saved state = %+v; want pending authorization cleared", st)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package discovery finds the endpoints of an OAuth 2.0 authorization
+// server from its OpenID Connect discovery document.
+package discovery // import "golang.org/x/oauth2/discovery"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+	"golang.org/x/oauth2/internal"
+)
+
+// Metadata holds the endpoints of an authorization server found by
+// Discover.
+type Metadata struct {
+	// Issuer is the issuer identifier reported by the server.
+	Issuer string
+
+	// Endpoint is the endpoint passed to Discover, with DeviceAuthURL
+	// filled in from the server's metadata if it was empty.
+	Endpoint oauth2.Endpoint
+
+	// RevocationURL is the URL of the RFC 7009 token revocation
+	// endpoint, if the server has one.
+	RevocationURL string
+
+	// IntrospectionURL is the URL of the RFC 7662 token introspection
+	// endpoint, if the server has one.
+	IntrospectionURL string
+}
+
+// discoveryTTL is how long Discover caches the metadata it fetches.
+var discoveryTTL = 24 * time.Hour
+
+var discoveryCache struct {
+	mu      sync.Mutex
+	entries map[oauth2.Endpoint]discoveryEntry
+}
+
+type discoveryEntry struct {
+	md      *Metadata
+	fetched time.Time
+}
+
+// Discover fetches the OpenID Connect discovery document of the server
+// with endpoint e, to find the endpoints that e lacks, such as the device
+// authorization endpoint. The issuer is the one registered for e in the
+// endpoints package if any, such as that of Google; otherwise it is
+// derived from e.AuthURL, trying each of its path prefixes in turn and,
+// for Azure AD, the v2.0 issuer of the tenant. A discovery document is only used if
+// its token endpoint is e.TokenURL.
+//
+// The HTTP client used is taken from ctx as with oauth2.Config.Exchange.
+// Successful results are cached for a day.
+func Discover(ctx context.Context, e oauth2.Endpoint) (*Metadata, error) {
+	if md := cachedMetadata(e); md != nil {
+		return md, nil
+	}
+	issuers, err := issuerCandidates(e)
+	if err != nil {
+		return nil, err
+	}
+	hc := internal.ContextClient(ctx)
+	var errs []string
+	for _, iss := range issuers {
+		md, err := fetchMetadata(ctx, hc, iss, e)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		discoveryCache.mu.Lock()
+		if discoveryCache.entries == nil {
+			discoveryCache.entries = make(map[oauth2.Endpoint]discoveryEntry)
+		}
+		discoveryCache.entries[e] = discoveryEntry{md, time.Now()}
+		discoveryCache.mu.Unlock()
+		return md.copy(), nil
+	}
+	return nil, errors.New("discovery: no usable discovery document: " + strings.Join(errs, "; "))
+}
+
+func (md *Metadata) copy() *Metadata {
+	md2 := *md
+	return &md2
+}
+
+func cachedMetadata(e oauth2.Endpoint) *Metadata {
+	discoveryCache.mu.Lock()
+	defer discoveryCache.mu.Unlock()
+	ent, ok := discoveryCache.entries[e]
+	if !ok {
+		return nil
+	}
+	if time.Since(ent.fetched) >= discoveryTTL {
+		delete(discoveryCache.entries, e)
+		return nil
+	}
+	return ent.md.copy()
+}
+
+// issuerCandidates returns the issuers that may have the endpoint e, most
+// likely first.
+func issuerCandidates(e oauth2.Endpoint) ([]string, error) {
+	for _, p := range endpoints.Providers() {
+		if p.Issuer != "" && p.Endpoint.AuthURL == e.AuthURL {
+			return []string{p.Issuer}, nil
+		}
+	}
+	u, err := url.Parse(e.AuthURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("discovery: invalid AuthURL %q", e.AuthURL)
+	}
+	base := u.Scheme + "://" + u.Host
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var issuers []string
+	// Azure AD: https://login.microsoftonline.com/{tenant}/oauth2/v2.0/authorize
+	// has the issuer https://login.microsoftonline.com/{tenant}/v2.0.
+	if len(parts) == 4 && parts[1] == "oauth2" && parts[2] == "v2.0" {
+		issuers = append(issuers, base+"/"+parts[0]+"/v2.0")
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		issuers = append(issuers, base+strings.TrimSuffix("/"+strings.Join(parts[:i], "/"), "/"))
+	}
+	return issuers, nil
+}
+
+func fetchMetadata(ctx context.Context, hc *http.Client, issuer string, e oauth2.Endpoint) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	r, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", req.URL, r.Status)
+	}
+	var doc struct {
+		Issuer                      string `json:"issuer"`
+		TokenEndpoint               string `json:"token_endpoint"`
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		RevocationEndpoint          string `json:"revocation_endpoint"`
+		IntrospectionEndpoint       string `json:"introspection_endpoint"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", req.URL, err)
+	}
+	if doc.TokenEndpoint != e.TokenURL {
+		return nil, fmt.Errorf("%s: token endpoint %q does not match %q", req.URL, doc.TokenEndpoint, e.TokenURL)
+	}
+	md := &Metadata{
+		Issuer:           doc.Issuer,
+		Endpoint:         e,
+		RevocationURL:    doc.RevocationEndpoint,
+		IntrospectionURL: doc.IntrospectionEndpoint,
+	}
+	if md.Endpoint.DeviceAuthURL == "" {
+		md.Endpoint.DeviceAuthURL = doc.DeviceAuthorizationEndpoint
+	}
+	return md, nil
+}
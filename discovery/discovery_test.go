@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestDiscover(t *testing.T) {
+	var fetches []string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches = append(fetches, r.URL.Path)
+		var prefix string
+		switch r.URL.Path {
+		case "/tenant/v2.0/.well-known/openid-configuration":
+			prefix = "/tenant/oauth2/v2.0"
+		case "/realms/test/.well-known/openid-configuration":
+			prefix = "/realms/test"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        ts.URL + prefix,
+			"authorization_endpoint":        ts.URL + prefix + "/authorize",
+			"token_endpoint":                ts.URL + prefix + "/token",
+			"device_authorization_endpoint": ts.URL + prefix + "/devicecode",
+			"revocation_endpoint":           ts.URL + prefix + "/revoke",
+			"introspection_endpoint":        ts.URL + prefix + "/introspect",
+		})
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		prefix, auth string
+		fetches      []string
+	}{
+		{"/tenant/oauth2/v2.0", "/authorize", []string{"/tenant/v2.0/.well-known/openid-configuration"}},
+		{"/realms/test", "/protocol/authorize", []string{
+			"/realms/test/protocol/.well-known/openid-configuration",
+			"/realms/test/.well-known/openid-configuration",
+		}},
+	}
+	for _, tt := range tests {
+		fetches = nil
+		e := oauth2.Endpoint{
+			AuthURL:  ts.URL + tt.prefix + tt.auth,
+			TokenURL: ts.URL + tt.prefix + "/token",
+		}
+		md, err := Discover(context.Background(), e)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.prefix, err)
+		}
+		want := &Metadata{
+			Issuer:           ts.URL + tt.prefix,
+			Endpoint:         e,
+			RevocationURL:    ts.URL + tt.prefix + "/revoke",
+			IntrospectionURL: ts.URL + tt.prefix + "/introspect",
+		}
+		want.Endpoint.DeviceAuthURL = ts.URL + tt.prefix + "/devicecode"
+		if !reflect.DeepEqual(md, want) {
+			t.Errorf("%s: Discover = %+v; want %+v", tt.prefix, md, want)
+		}
+		if !reflect.DeepEqual(fetches, tt.fetches) {
+			t.Errorf("%s: fetched %q; want %q", tt.prefix, fetches, tt.fetches)
+		}
+
+		fetches = nil
+		if _, err := Discover(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+		if len(fetches) != 0 {
+			t.Errorf("%s: cached discovery fetched %q", tt.prefix, fetches)
+		}
+	}
+
+	// The token endpoint must match.
+	_, err := Discover(context.Background(), oauth2.Endpoint{
+		AuthURL:  ts.URL + "/realms/test/authorize",
+		TokenURL: ts.URL + "/other/token",
+	})
+	if err == nil {
+		t.Error("Discover with a mismatched token endpoint succeeded")
+	}
+}
@@ -17,6 +17,13 @@ var Amazon = oauth2.Endpoint{
 	TokenURL: "https://api.amazon.com/auth/o2/token",
 }
 
+// Apple is the endpoint for Sign in with Apple.
+var Apple = oauth2.Endpoint{
+	AuthURL:   "https://appleid.apple.com/auth/authorize",
+	TokenURL:  "https://appleid.apple.com/auth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
 // Battlenet is the endpoint for Battlenet.
 var Battlenet = oauth2.Endpoint{
 	AuthURL:  "https://battle.net/oauth/authorize",
@@ -35,6 +42,18 @@ var Cern = oauth2.Endpoint{
 	TokenURL: "https://oauth.web.cern.ch/OAuth/Token",
 }
 
+// Discord is the endpoint for Discord.
+var Discord = oauth2.Endpoint{
+	AuthURL:  "https://discord.com/oauth2/authorize",
+	TokenURL: "https://discord.com/api/oauth2/token",
+}
+
+// Dropbox is the endpoint for Dropbox.
+var Dropbox = oauth2.Endpoint{
+	AuthURL:  "https://www.dropbox.com/oauth2/authorize",
+	TokenURL: "https://api.dropboxapi.com/oauth2/token",
+}
+
 // Facebook is the endpoint for Facebook.
 var Facebook = oauth2.Endpoint{
 	AuthURL:  "https://www.facebook.com/v3.2/dialog/oauth",
@@ -98,6 +117,12 @@ var KaKao = oauth2.Endpoint{
 	TokenURL: "https://kauth.kakao.com/oauth/token",
 }
 
+// LINE is the endpoint for LINE Login.
+var LINE = oauth2.Endpoint{
+	AuthURL:  "https://access.line.me/oauth2/v2.1/authorize",
+	TokenURL: "https://api.line.me/oauth2/v2.1/token",
+}
+
 // LinkedIn is the endpoint for LinkedIn.
 var LinkedIn = oauth2.Endpoint{
 	AuthURL:  "https://www.linkedin.com/oauth/v2/authorization",
@@ -158,6 +183,22 @@ var PayPalSandbox = oauth2.Endpoint{
 	TokenURL: "https://api.sandbox.paypal.com/v1/identity/openidconnect/tokenservice",
 }
 
+// QQ is the endpoint for QQ (Tencent) login. Its token endpoint only
+// accepts GET requests.
+var QQ = oauth2.Endpoint{
+	AuthURL:    "https://graph.qq.com/oauth2.0/authorize",
+	TokenURL:   "https://graph.qq.com/oauth2.0/token",
+	AuthStyle:  oauth2.AuthStyleInParams,
+	ParamNames: oauth2.ParamNames{Method: "GET"},
+}
+
+// Salesforce is the endpoint for Salesforce production orgs. Sandbox
+// orgs use test.salesforce.com instead of login.salesforce.com.
+var Salesforce = oauth2.Endpoint{
+	AuthURL:  "https://login.salesforce.com/services/oauth2/authorize",
+	TokenURL: "https://login.salesforce.com/services/oauth2/token",
+}
+
 // Slack is the endpoint for Slack.
 var Slack = oauth2.Endpoint{
 	AuthURL:  "https://slack.com/oauth/authorize",
@@ -184,8 +225,16 @@ var Strava = oauth2.Endpoint{
 
 // Twitch is the endpoint for Twitch.
 var Twitch = oauth2.Endpoint{
-	AuthURL:  "https://id.twitch.tv/oauth2/authorize",
-	TokenURL: "https://id.twitch.tv/oauth2/token",
+	AuthURL:       "https://id.twitch.tv/oauth2/authorize",
+	TokenURL:      "https://id.twitch.tv/oauth2/token",
+	DeviceAuthURL: "https://id.twitch.tv/oauth2/device",
+}
+
+// Twitter is the endpoint for X (formerly Twitter) OAuth 2.0, as used
+// by the v2 API.
+var Twitter = oauth2.Endpoint{
+	AuthURL:  "https://twitter.com/i/oauth2/authorize",
+	TokenURL: "https://api.twitter.com/2/oauth2/token",
 }
 
 // Uber is the endpoint for Uber.
@@ -200,6 +249,16 @@ var Vk = oauth2.Endpoint{
 	TokenURL: "https://oauth.vk.com/access_token",
 }
 
+// WeChat is the endpoint for WeChat (Weixin) website login. It names
+// the client ID and secret "appid" and "secret", and its token endpoint
+// only accepts GET requests.
+var WeChat = oauth2.Endpoint{
+	AuthURL:    "https://open.weixin.qq.com/connect/qrconnect",
+	TokenURL:   "https://api.weixin.qq.com/sns/oauth2/access_token",
+	AuthStyle:  oauth2.AuthStyleInParams,
+	ParamNames: oauth2.ParamNames{ClientID: "appid", ClientSecret: "secret", Method: "GET"},
+}
+
 // Yahoo is the endpoint for Yahoo.
 var Yahoo = oauth2.Endpoint{
 	AuthURL:  "https://api.login.yahoo.com/oauth2/request_auth",
@@ -234,6 +293,37 @@ func AzureAD(tenant string) oauth2.Endpoint {
 	}
 }
 
+// AzureADB2C returns a new oauth2.Endpoint for the given user flow or
+// custom policy of an Azure AD B2C tenant. The tenant may be given either
+// by its name, such as "contoso", or by its domain, such as
+// "contoso.onmicrosoft.com". Azure AD B2C does not support the device
+// authorization grant.
+//
+// For more information see:
+// https://learn.microsoft.com/en-us/azure/active-directory-b2c/openid-connect
+func AzureADB2C(tenant, policy string) oauth2.Endpoint {
+	name := strings.TrimSuffix(tenant, ".onmicrosoft.com")
+	prefix := "https://" + name + ".b2clogin.com/" + name + ".onmicrosoft.com/" + policy + "/oauth2/v2.0"
+	return oauth2.Endpoint{
+		AuthURL:  prefix + "/authorize",
+		TokenURL: prefix + "/token",
+	}
+}
+
+// ADFS returns a new oauth2.Endpoint for the Active Directory Federation
+// Services server running on the given host, such as "adfs.example.com".
+//
+// For more information see:
+// https://learn.microsoft.com/en-us/windows-server/identity/ad-fs/overview/ad-fs-openid-connect-oauth-flows-scenarios
+func ADFS(host string) oauth2.Endpoint {
+	prefix := "https://" + strings.TrimRight(host, "/") + "/adfs/oauth2"
+	return oauth2.Endpoint{
+		AuthURL:       prefix + "/authorize",
+		TokenURL:      prefix + "/token",
+		DeviceAuthURL: prefix + "/devicecode",
+	}
+}
+
 // HipChatServer returns a new oauth2.Endpoint for a HipChat Server instance
 // running on the given domain or host.
 func HipChatServer(host string) oauth2.Endpoint {
@@ -258,3 +348,88 @@ func AWSCognito(domain string) oauth2.Endpoint {
 		TokenURL: domain + "/oauth2/token",
 	}
 }
+
+// Auth0 returns a new oauth2.Endpoint for the given Auth0 tenant domain.
+//
+// Example domain: https://example.us.auth0.com
+//
+// Auth0 issues opaque access tokens unless the API they are for is named
+// by the "audience" parameter, which is passed with
+// oauth2.SetAuthURLParam("audience", api) for the authorization code and
+// device flows, and in clientcredentials.Config.EndpointParams for the
+// client credentials flow.
+//
+// For more information see:
+// https://auth0.com/docs/api/authentication
+func Auth0(domain string) oauth2.Endpoint {
+	domain = strings.TrimRight(domain, "/")
+	return oauth2.Endpoint{
+		AuthURL:       domain + "/authorize",
+		TokenURL:      domain + "/oauth/token",
+		DeviceAuthURL: domain + "/oauth/device/code",
+	}
+}
+
+// Keycloak returns a new oauth2.Endpoint for the given realm of the
+// Keycloak server at baseURL.
+//
+// Example baseURL: https://keycloak.example.com
+//
+// For more information see:
+// https://www.keycloak.org/docs/latest/securing_apps/#endpoints
+func Keycloak(baseURL, realm string) oauth2.Endpoint {
+	prefix := keycloakPrefix(baseURL, realm)
+	return oauth2.Endpoint{
+		AuthURL:       prefix + "/auth",
+		TokenURL:      prefix + "/token",
+		DeviceAuthURL: prefix + "/auth/device",
+	}
+}
+
+// Okta returns a new oauth2.Endpoint for the org authorization server of
+// the given Okta domain.
+//
+// Example domain: https://dev-123456.okta.com
+//
+// For more information see:
+// https://developer.okta.com/docs/reference/api/oidc/
+func Okta(domain string) oauth2.Endpoint {
+	domain = strings.TrimRight(domain, "/")
+	return oauth2.Endpoint{
+		AuthURL:       domain + "/oauth2/v1/authorize",
+		TokenURL:      domain + "/oauth2/v1/token",
+		DeviceAuthURL: domain + "/oauth2/v1/device/authorize",
+	}
+}
+
+// OktaAuthorizationServer returns a new oauth2.Endpoint for the custom
+// authorization server with the given ID, such as "default", of the given
+// Okta domain. Unlike the org authorization server of Okta, custom
+// authorization servers issue access tokens for the audience configured
+// on the server, so no audience parameter is needed.
+//
+// Example domain: https://dev-123456.okta.com
+//
+// For more information see:
+// https://developer.okta.com/docs/concepts/auth-servers/
+func OktaAuthorizationServer(domain, authorizationServerID string) oauth2.Endpoint {
+	prefix := strings.TrimRight(domain, "/") + "/oauth2/" + authorizationServerID + "/v1"
+	return oauth2.Endpoint{
+		AuthURL:       prefix + "/authorize",
+		TokenURL:      prefix + "/token",
+		DeviceAuthURL: prefix + "/device/authorize",
+	}
+}
+
+// Shopify returns a new oauth2.Endpoint for the given shop, such as
+// "example" for example.myshopify.com.
+//
+// For more information see:
+// https://shopify.dev/docs/apps/auth/oauth
+func Shopify(shop string) oauth2.Endpoint {
+	host := "https://" + strings.TrimSuffix(shop, ".myshopify.com") + ".myshopify.com"
+	return oauth2.Endpoint{
+		AuthURL:  host + "/admin/oauth/authorize",
+		TokenURL: host + "/admin/oauth/access_token",
+	}
+}
@@ -5,6 +5,8 @@
 package endpoints
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"golang.org/x/oauth2"
@@ -41,3 +43,114 @@ func TestAWSCognitoEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainEndpoints(t *testing.T) {
+	tests := []struct {
+		name string
+		got  oauth2.Endpoint
+		want oauth2.Endpoint
+	}{
+		{
+			name: "ADFS",
+			got:  ADFS("adfs.example.com"),
+			want: oauth2.Endpoint{
+				AuthURL:       "https://adfs.example.com/adfs/oauth2/authorize",
+				TokenURL:      "https://adfs.example.com/adfs/oauth2/token",
+				DeviceAuthURL: "https://adfs.example.com/adfs/oauth2/devicecode",
+			},
+		},
+		{
+			name: "Auth0",
+			got:  Auth0("https://example.us.auth0.com/"),
+			want: oauth2.Endpoint{
+				AuthURL:       "https://example.us.auth0.com/authorize",
+				TokenURL:      "https://example.us.auth0.com/oauth/token",
+				DeviceAuthURL: "https://example.us.auth0.com/oauth/device/code",
+			},
+		},
+		{
+			name: "AzureADB2C",
+			got:  AzureADB2C("contoso", "B2C_1_signupsignin"),
+			want: oauth2.Endpoint{
+				AuthURL:  "https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signupsignin/oauth2/v2.0/authorize",
+				TokenURL: "https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signupsignin/oauth2/v2.0/token",
+			},
+		},
+		{
+			name: "AzureADB2C domain",
+			got:  AzureADB2C("contoso.onmicrosoft.com", "B2C_1_signupsignin"),
+			want: AzureADB2C("contoso", "B2C_1_signupsignin"),
+		},
+		{
+			name: "Keycloak",
+			got:  Keycloak("https://keycloak.example.com", "master"),
+			want: oauth2.Endpoint{
+				AuthURL:       "https://keycloak.example.com/realms/master/protocol/openid-connect/auth",
+				TokenURL:      "https://keycloak.example.com/realms/master/protocol/openid-connect/token",
+				DeviceAuthURL: "https://keycloak.example.com/realms/master/protocol/openid-connect/auth/device",
+			},
+		},
+		{
+			name: "Okta",
+			got:  Okta("https://dev-123456.okta.com"),
+			want: oauth2.Endpoint{
+				AuthURL:       "https://dev-123456.okta.com/oauth2/v1/authorize",
+				TokenURL:      "https://dev-123456.okta.com/oauth2/v1/token",
+				DeviceAuthURL: "https://dev-123456.okta.com/oauth2/v1/device/authorize",
+			},
+		},
+		{
+			name: "OktaAuthorizationServer",
+			got:  OktaAuthorizationServer("https://dev-123456.okta.com/", "default"),
+			want: oauth2.Endpoint{
+				AuthURL:       "https://dev-123456.okta.com/oauth2/default/v1/authorize",
+				TokenURL:      "https://dev-123456.okta.com/oauth2/default/v1/token",
+				DeviceAuthURL: "https://dev-123456.okta.com/oauth2/default/v1/device/authorize",
+			},
+		},
+		{
+			name: "Shopify",
+			got:  Shopify("example.myshopify.com"),
+			want: oauth2.Endpoint{
+				AuthURL:  "https://example.myshopify.com/admin/oauth/authorize",
+				TokenURL: "https://example.myshopify.com/admin/oauth/access_token",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if got, ok := Lookup("github"); !ok || got != GitHub {
+		t.Errorf("Lookup(%q) = %v, %v; want %v, true", "github", got, ok, GitHub)
+	}
+	if _, ok := Lookup("nonexistent"); ok {
+		t.Errorf("Lookup(%q) succeeded", "nonexistent")
+	}
+}
+
+func TestProviders(t *testing.T) {
+	ps := Providers()
+	for i, p := range ps {
+		if i > 0 && strings.ToLower(ps[i-1].Name) >= strings.ToLower(p.Name) {
+			t.Errorf("Providers not sorted: %q before %q", ps[i-1].Name, p.Name)
+		}
+		if p.Endpoint.AuthURL == "" || p.Endpoint.TokenURL == "" {
+			t.Errorf("%s: incomplete endpoint %v", p.Name, p.Endpoint)
+		}
+	}
+	var google Provider
+	for _, p := range ps {
+		if p.Name == "Google" {
+			google = p
+		}
+	}
+	want := []string{GrantTypeAuthorizationCode, GrantTypeDeviceCode}
+	if got := google.GrantTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Google GrantTypes() = %q, want %q", got, want)
+	}
+}
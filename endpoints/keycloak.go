@@ -0,0 +1,25 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package endpoints
+
+import "strings"
+
+func keycloakPrefix(baseURL, realm string) string {
+	return strings.TrimRight(baseURL, "/") + "/realms/" + realm + "/protocol/openid-connect"
+}
+
+// KeycloakLogoutURL returns the URL of the logout endpoint of the given
+// realm of the Keycloak server at baseURL. Browsers are redirected there
+// to end the user's session; see golang.org/x/oauth2/keycloak.Logout to
+// end it without one.
+func KeycloakLogoutURL(baseURL, realm string) string {
+	return keycloakPrefix(baseURL, realm) + "/logout"
+}
+
+// KeycloakRevocationURL returns the URL of the RFC 7009 token revocation
+// endpoint of the given realm of the Keycloak server at baseURL.
+func KeycloakRevocationURL(baseURL, realm string) string {
+	return keycloakPrefix(baseURL, realm) + "/revoke"
+}
@@ -0,0 +1,16 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package endpoints
+
+import "testing"
+
+func TestKeycloakURLs(t *testing.T) {
+	if got, want := KeycloakLogoutURL("https://keycloak.example.com/", "master"), "https://keycloak.example.com/realms/master/protocol/openid-connect/logout"; got != want {
+		t.Errorf("KeycloakLogoutURL = %q; want %q", got, want)
+	}
+	if got, want := KeycloakRevocationURL("https://keycloak.example.com", "master"), "https://keycloak.example.com/realms/master/protocol/openid-connect/revoke"; got != want {
+		t.Errorf("KeycloakRevocationURL = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package endpoints
+
+import (
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Grant types reported by Provider.GrantTypes.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Provider describes a provider whose endpoint is a package-level
+// variable of this package.
+type Provider struct {
+	// Name is the name of the variable holding the endpoint, such as
+	// "GitHub".
+	Name string
+
+	// Endpoint is the provider's endpoint.
+	Endpoint oauth2.Endpoint
+
+	// Issuer is the provider's OpenID Connect issuer identifier, if it
+	// is an OpenID provider.
+	Issuer string
+
+	// ClientCredentials reports whether the provider supports the
+	// client credentials grant.
+	ClientCredentials bool
+}
+
+// GrantTypes returns the grant types the provider supports.
+func (p Provider) GrantTypes() []string {
+	gt := []string{GrantTypeAuthorizationCode}
+	if p.ClientCredentials {
+		gt = append(gt, GrantTypeClientCredentials)
+	}
+	if p.Endpoint.DeviceAuthURL != "" {
+		gt = append(gt, GrantTypeDeviceCode)
+	}
+	return gt
+}
+
+// providers lists the fixed endpoints of this package, sorted by name.
+var providers = []Provider{
+	{Name: "Amazon", Endpoint: Amazon},
+	{Name: "Apple", Endpoint: Apple, Issuer: "https://appleid.apple.com"},
+	{Name: "Battlenet", Endpoint: Battlenet},
+	{Name: "Bitbucket", Endpoint: Bitbucket},
+	{Name: "Cern", Endpoint: Cern},
+	{Name: "Discord", Endpoint: Discord, ClientCredentials: true},
+	{Name: "Dropbox", Endpoint: Dropbox},
+	{Name: "Facebook", Endpoint: Facebook},
+	{Name: "Fitbit", Endpoint: Fitbit},
+	{Name: "Foursquare", Endpoint: Foursquare},
+	{Name: "GitHub", Endpoint: GitHub},
+	{Name: "GitLab", Endpoint: GitLab, Issuer: "https://gitlab.com"},
+	{Name: "Google", Endpoint: Google, Issuer: "https://accounts.google.com"},
+	{Name: "Heroku", Endpoint: Heroku},
+	{Name: "HipChat", Endpoint: HipChat},
+	{Name: "Instagram", Endpoint: Instagram},
+	{Name: "KaKao", Endpoint: KaKao},
+	{Name: "LINE", Endpoint: LINE, Issuer: "https://access.line.me"},
+	{Name: "LinkedIn", Endpoint: LinkedIn},
+	{Name: "Mailchimp", Endpoint: Mailchimp},
+	{Name: "Mailru", Endpoint: Mailru},
+	{Name: "MediaMath", Endpoint: MediaMath},
+	{Name: "MediaMathSandbox", Endpoint: MediaMathSandbox},
+	{Name: "Microsoft", Endpoint: Microsoft},
+	{Name: "NokiaHealth", Endpoint: NokiaHealth},
+	{Name: "Odnoklassniki", Endpoint: Odnoklassniki},
+	{Name: "PayPal", Endpoint: PayPal, ClientCredentials: true},
+	{Name: "PayPalSandbox", Endpoint: PayPalSandbox, ClientCredentials: true},
+	{Name: "QQ", Endpoint: QQ},
+	{Name: "Salesforce", Endpoint: Salesforce, Issuer: "https://login.salesforce.com", ClientCredentials: true},
+	{Name: "Slack", Endpoint: Slack},
+	{Name: "Spotify", Endpoint: Spotify, ClientCredentials: true},
+	{Name: "StackOverflow", Endpoint: StackOverflow},
+	{Name: "Strava", Endpoint: Strava},
+	{Name: "Twitch", Endpoint: Twitch, Issuer: "https://id.twitch.tv/oauth2", ClientCredentials: true},
+	{Name: "Twitter", Endpoint: Twitter},
+	{Name: "Uber", Endpoint: Uber},
+	{Name: "Vk", Endpoint: Vk},
+	{Name: "WeChat", Endpoint: WeChat},
+	{Name: "Yahoo", Endpoint: Yahoo, Issuer: "https://api.login.yahoo.com"},
+	{Name: "Yandex", Endpoint: Yandex},
+	{Name: "Zoom", Endpoint: Zoom},
+}
+
+// Providers returns the providers with fixed endpoints in this package,
+// sorted by name. Endpoints that depend on a tenant or domain, such as
+// AzureAD, are not included.
+func Providers() []Provider {
+	return append([]Provider(nil), providers...)
+}
+
+// Lookup returns the endpoint of the named provider. Names are those of
+// the package-level variables, such as "GitHub", compared
+// case-insensitively.
+func Lookup(name string) (oauth2.Endpoint, bool) {
+	for _, p := range providers {
+		if strings.EqualFold(p.Name, name) {
+			return p.Endpoint, true
+		}
+	}
+	return oauth2.Endpoint{}, false
+}
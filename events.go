@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import "time"
+
+// EventHook receives notifications about token lifecycle events, so that
+// applications can record metrics and traces for their token traffic.
+//
+// An EventHook may be set on Config, in which case it observes every
+// request made to the token endpoint, or on Transport, in which case it
+// observes every call to the Transport's Source.
+//
+// Methods are called synchronously on the goroutine that performs the
+// operation and must be safe for concurrent use.
+type EventHook interface {
+	// OnTokenRequest is called before a token is requested.
+	OnTokenRequest(TokenEvent)
+
+	// OnTokenSuccess is called after a token was obtained.
+	// TokenEvent.Token and TokenEvent.Duration are set.
+	OnTokenSuccess(TokenEvent)
+
+	// OnTokenError is called after a token request failed.
+	// TokenEvent.Err and TokenEvent.Duration are set.
+	OnTokenError(TokenEvent)
+
+	// OnRefresh is called by Config's TokenSource before it uses a
+	// refresh token to renew an expired token.
+	OnRefresh(TokenEvent)
+}
+
+// Logger receives debug logs of token endpoint requests made by a
+// Config. It is implemented by *log.Logger; other logging packages can
+// be adapted with a small wrapper that logs at debug level.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// TokenEvent describes a token lifecycle event reported to an EventHook.
+type TokenEvent struct {
+	// TokenURL is the token endpoint being called.
+	// It is empty for events reported by Transport.
+	TokenURL string
+
+	// GrantType is the "grant_type" parameter of the token request,
+	// such as "authorization_code" or "refresh_token".
+	// It is empty for events reported by Transport.
+	GrantType string
+
+	// Token is the token that was obtained, if any.
+	Token *Token
+
+	// Err is the error that occurred, if any. Errors from the token
+	// endpoint are of type *RetrieveError.
+	Err error
+
+	// Duration is how long the operation took.
+	Duration time.Duration
+}
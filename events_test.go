@@ -0,0 +1,118 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingHook struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHook) record(name string, ev TokenEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, name+":"+ev.GrantType)
+}
+
+func (h *recordingHook) OnTokenRequest(ev TokenEvent) { h.record("request", ev) }
+func (h *recordingHook) OnTokenSuccess(ev TokenEvent) { h.record("success", ev) }
+func (h *recordingHook) OnTokenError(ev TokenEvent)   { h.record("error", ev) }
+func (h *recordingHook) OnRefresh(ev TokenEvent)      { h.record("refresh", ev) }
+
+func TestConfigEventHook(t *testing.T) {
+	fail := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"a","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	hook := new(recordingHook)
+	conf := newConf(ts.URL)
+	conf.Endpoint.AuthStyle = AuthStyleInHeader
+	conf.EventHook = hook
+	ctx := context.Background()
+	if _, err := conf.Exchange(ctx, "code"); err != nil {
+		t.Fatal(err)
+	}
+	fail = true
+	if _, err := conf.TokenSource(ctx, &Token{RefreshToken: "r"}).Token(); err == nil {
+		t.Fatal("Token() succeeded; want error")
+	}
+	want := []string{
+		"request:authorization_code",
+		"success:authorization_code",
+		"refresh:refresh_token",
+		"request:refresh_token",
+		"error:refresh_token",
+	}
+	if !reflect.DeepEqual(hook.events, want) {
+		t.Errorf("events = %q; want %q", hook.events, want)
+	}
+}
+
+func TestTransportEventHook(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+	hook := new(recordingHook)
+	tr := &Transport{
+		Source:    StaticTokenSource(&Token{AccessToken: "abc"}),
+		EventHook: hook,
+	}
+	client := &http.Client{Transport: tr}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if want := []string{"request:", "success:"}; !reflect.DeepEqual(hook.events, want) {
+		t.Errorf("events = %q; want %q", hook.events, want)
+	}
+}
+
+func TestConfigLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":"invalid_grant"}`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	conf := newConf(ts.URL)
+	conf.Endpoint.ParamNames = ParamNames{Method: "GET"}
+	conf.Endpoint.AuthStyle = AuthStyleInParams
+	conf.Logger = log.New(&buf, "", 0)
+	if _, err := conf.Exchange(context.Background(), "CODE"); err == nil {
+		t.Fatal("Exchange succeeded")
+	}
+	got := buf.String()
+	for _, want := range []string{`grant type "authorization_code"`, "GET " + ts.URL + "/token failed", `status 400, error "invalid_grant"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+	for _, secret := range []string{"CLIENT_SECRET", "CODE"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("log %q contains %q", got, secret)
+		}
+	}
+}
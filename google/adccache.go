@@ -0,0 +1,165 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// adcCacheRecheck is how long cached default credentials are used before
+// the credentials file is checked for modifications.
+var adcCacheRecheck = 10 * time.Second
+
+// adcCache caches the results of FindDefaultCredentialsWithParams when
+// enabled by CacheDefaultCredentials.
+var adcCache struct {
+	mu      sync.Mutex
+	enabled bool
+	entries map[string]*adcCacheEntry
+}
+
+type adcCacheEntry struct {
+	creds   *Credentials
+	envPath string // value of GOOGLE_APPLICATION_CREDENTIALS
+	path    string // credentials file watched for modifications
+	stat    fileStamp
+	checked time.Time
+}
+
+// fileStamp identifies a version of a file, or its absence.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime int64 // in nanoseconds since the Unix epoch
+}
+
+func statFile(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: fi.Size(), modTime: fi.ModTime().UnixNano()}
+}
+
+// CacheDefaultCredentials enables or disables a process-wide cache of the
+// credentials found by FindDefaultCredentials and
+// FindDefaultCredentialsWithParams, and thus by DefaultTokenSource and
+// DefaultClient. Disabling the cache discards its contents.
+//
+// While the cache is enabled, lookups with the same scopes, parameters and
+// HTTP client return the same Credentials. Cached credentials are found
+// with a background context that carries only the oauth2.HTTPClient value
+// of the lookup's context, so that their token sources outlive the
+// context of any lookup. The cached credentials are discarded
+// when the GOOGLE_APPLICATION_CREDENTIALS environment variable changes or
+// the credentials file is modified, created or removed; modifications are
+// detected within a few seconds. Lookups whose parameters include an
+// AuthHandler, PKCE or ClientCertificateSource are never cached, and
+// errors are never cached.
+func CacheDefaultCredentials(enable bool) {
+	adcCache.mu.Lock()
+	defer adcCache.mu.Unlock()
+	adcCache.enabled = enable
+	adcCache.entries = nil
+}
+
+// InvalidateDefaultCredentials discards the credentials cached since
+// CacheDefaultCredentials enabled the cache, so that the next lookup
+// searches for credentials again.
+func InvalidateDefaultCredentials() {
+	adcCache.mu.Lock()
+	defer adcCache.mu.Unlock()
+	adcCache.entries = nil
+}
+
+// cacheKey returns the cache key of params, and false if lookups with
+// params must not be cached.
+func (params CredentialsParams) cacheKey() (string, bool) {
+	if params.AuthHandler != nil || params.PKCE != nil || params.ClientCertificateSource != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%q %q %q %q %v %q %q %v",
+		strings.Join(params.Scopes, " "), params.Subject, params.State, params.TokenURL,
+		params.EarlyTokenRefresh, params.UniverseDomain, params.QuotaProjectID, params.UseSelfSignedJWT), true
+}
+
+// adcCacheContext returns the context used to find the credentials cached
+// under key for a lookup with ctx, and the key extended with the HTTP
+// client of ctx. The context is detached from ctx, so that cancelling
+// ctx does not break the token sources of the cached credentials.
+func adcCacheContext(ctx context.Context, key string) (context.Context, string) {
+	c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if !ok {
+		return context.Background(), key
+	}
+	return context.WithValue(context.Background(), oauth2.HTTPClient, c), fmt.Sprintf("%s %p", key, c)
+}
+
+// adcCachePaths returns the value of GOOGLE_APPLICATION_CREDENTIALS and the
+// credentials file that default credentials are read from.
+func adcCachePaths() (envPath, path string) {
+	envPath = os.Getenv(adcEnvVar)
+	if envPath != "" {
+		return envPath, envPath
+	}
+	return "", wellKnownFile()
+}
+
+// cachedDefaultCredentials returns the cached credentials for key, or nil.
+func cachedDefaultCredentials(key string) *Credentials {
+	adcCache.mu.Lock()
+	defer adcCache.mu.Unlock()
+	e := adcCache.entries[key]
+	if e == nil {
+		return nil
+	}
+	if envPath, _ := adcCachePaths(); envPath != e.envPath {
+		delete(adcCache.entries, key)
+		return nil
+	}
+	if now := time.Now(); now.Sub(e.checked) >= adcCacheRecheck {
+		if statFile(e.path) != e.stat {
+			delete(adcCache.entries, key)
+			return nil
+		}
+		e.checked = now
+	}
+	return e.creds
+}
+
+// cacheDefaultCredentials adds creds to the cache under key, if the cache
+// is enabled. stat is the version of the credentials file at the start of
+// the lookup that found creds.
+func cacheDefaultCredentials(key string, creds *Credentials, envPath, path string, stat fileStamp) {
+	adcCache.mu.Lock()
+	defer adcCache.mu.Unlock()
+	if !adcCache.enabled {
+		return
+	}
+	if adcCache.entries == nil {
+		adcCache.entries = make(map[string]*adcCacheEntry)
+	}
+	adcCache.entries[key] = &adcCacheEntry{
+		creds:   creds,
+		envPath: envPath,
+		path:    path,
+		stat:    stat,
+		checked: time.Now(),
+	}
+}
+
+func adcCacheEnabled() bool {
+	adcCache.mu.Lock()
+	defer adcCache.mu.Unlock()
+	return adcCache.enabled
+}
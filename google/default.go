@@ -6,6 +6,7 @@ package google
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -53,6 +54,12 @@ type Credentials struct {
 	// error other than 404, the error should be returned.
 	UniverseDomainProvider func() (string, error)
 
+	// QuotaProjectID is the project used for quota and billing of
+	// requests made with Client. It is taken from
+	// CredentialsParams.QuotaProjectID or, if that is empty, from the
+	// "quota_project_id" field of the credentials file. Optional.
+	QuotaProjectID string
+
 	udMu sync.Mutex // guards universeDomain
 	// universeDomain is the default service domain for a given Cloud universe.
 	universeDomain string
@@ -101,6 +108,42 @@ func (c *Credentials) GetUniverseDomain() (string, error) {
 	return c.universeDomain, nil
 }
 
+// Client returns an HTTP client authorized with c.TokenSource. If
+// c.QuotaProjectID is set, the client also sends it in the
+// X-Goog-User-Project header of every request, whatever the type of
+// credentials.
+func (c *Credentials) Client(ctx context.Context) *http.Client {
+	client := oauth2.NewClient(ctx, c.TokenSource)
+	if c.QuotaProjectID == "" {
+		return client
+	}
+	return &http.Client{
+		Transport: &quotaProjectTransport{
+			base:      client.Transport,
+			projectID: c.QuotaProjectID,
+		},
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+		Timeout:       client.Timeout,
+	}
+}
+
+// quotaProjectTransport sets the X-Goog-User-Project header on requests.
+type quotaProjectTransport struct {
+	base      http.RoundTripper
+	projectID string
+}
+
+func (t *quotaProjectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	req2 := req.Clone(req.Context()) // per RoundTripper contract
+	req2.Header.Set("X-Goog-User-Project", t.projectID)
+	return base.RoundTrip(req2)
+}
+
 // DefaultCredentials is the old name of Credentials.
 //
 // Deprecated: use Credentials instead.
@@ -144,6 +187,26 @@ type CredentialsParams struct {
 	// This value takes precedence over a universe domain explicitly specified
 	// in a credentials config file or by the GCE metadata server. Optional.
 	UniverseDomain string
+
+	// QuotaProjectID is the project used for quota and billing, sent
+	// in the X-Goog-User-Project header by Credentials.Client. It
+	// takes precedence over a quota project specified in a credentials
+	// file, and applies to every type of credentials. Optional.
+	QuotaProjectID string
+
+	// ClientCertificateSource is the source of the client certificate
+	// used to refresh certificate-bound external_account_authorized_user
+	// credentials over mutual TLS. Optional.
+	ClientCertificateSource func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+
+	// UseSelfSignedJWT makes service account credentials use a JWT
+	// signed with the service account key as the access token, instead
+	// of exchanging it for an access token at the token endpoint. This
+	// avoids a network round trip, but the resulting tokens are only
+	// accepted by Google APIs. It applies only when Scopes is set and
+	// Subject is empty, since domain-wide delegation requires the token
+	// endpoint. Optional.
+	UseSelfSignedJWT bool
 }
 
 func (params CredentialsParams) deepCopy() CredentialsParams {
@@ -191,16 +254,39 @@ func DefaultTokenSource(ctx context.Context, scope ...string) (oauth2.TokenSourc
 //  3. On Google Compute Engine, Google App Engine standard second generation runtimes
 //     (>= Go 1.11), and Google App Engine flexible environment, it fetches
 //     credentials from the metadata server.
+//
+// See CacheDefaultCredentials to avoid repeating the search on every call.
 func FindDefaultCredentialsWithParams(ctx context.Context, params CredentialsParams) (*Credentials, error) {
+	key, cacheable := params.cacheKey()
+	if !cacheable || !adcCacheEnabled() {
+		return findDefaultCredentials(ctx, params)
+	}
+	lookupCtx, key := adcCacheContext(ctx, key)
+	if creds := cachedDefaultCredentials(key); creds != nil {
+		return creds, nil
+	}
+	envPath, path := adcCachePaths()
+	stat := statFile(path)
+	creds, err := findDefaultCredentials(lookupCtx, params)
+	if err != nil {
+		return nil, err
+	}
+	cacheDefaultCredentials(key, creds, envPath, path, stat)
+	return creds, nil
+}
+
+// adcEnvVar is the environment variable naming a credentials file.
+const adcEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+
+func findDefaultCredentials(ctx context.Context, params CredentialsParams) (*Credentials, error) {
 	// Make defensive copy of the slices in params.
 	params = params.deepCopy()
 
 	// First, try the environment variable.
-	const envVar = "GOOGLE_APPLICATION_CREDENTIALS"
-	if filename := os.Getenv(envVar); filename != "" {
+	if filename := os.Getenv(adcEnvVar); filename != "" {
 		creds, err := readCredentialsFile(ctx, filename, params)
 		if err != nil {
-			return nil, fmt.Errorf("google: error getting credentials using %v environment variable: %v", envVar, err)
+			return nil, fmt.Errorf("google: error getting credentials using %v environment variable: %v", adcEnvVar, err)
 		}
 		return creds, nil
 	}
@@ -231,6 +317,7 @@ func FindDefaultCredentialsWithParams(ctx context.Context, params CredentialsPar
 			ProjectID:              id,
 			TokenSource:            computeTokenSource("", params.EarlyTokenRefresh, params.Scopes...),
 			UniverseDomainProvider: universeDomainProvider,
+			QuotaProjectID:         params.QuotaProjectID,
 			universeDomain:         params.UniverseDomain,
 		}, nil
 	}
@@ -265,9 +352,10 @@ func CredentialsFromJSONWithParams(ctx context.Context, jsonData []byte, params
 	config, _ := ConfigFromJSON(jsonData, params.Scopes...)
 	if config != nil {
 		return &Credentials{
-			ProjectID:   "",
-			TokenSource: authhandler.TokenSourceWithPKCE(ctx, config, params.State, params.AuthHandler, params.PKCE),
-			JSON:        jsonData,
+			ProjectID:      "",
+			TokenSource:    authhandler.TokenSourceWithPKCE(ctx, config, params.State, params.AuthHandler, params.PKCE),
+			JSON:           jsonData,
+			QuotaProjectID: params.QuotaProjectID,
 		}, nil
 	}
 
@@ -277,6 +365,9 @@ func CredentialsFromJSONWithParams(ctx context.Context, jsonData []byte, params
 		return nil, err
 	}
 
+	if params.QuotaProjectID != "" {
+		f.QuotaProjectID = params.QuotaProjectID
+	}
 	universeDomain := f.UniverseDomain
 	if params.UniverseDomain != "" {
 		universeDomain = params.UniverseDomain
@@ -295,6 +386,7 @@ func CredentialsFromJSONWithParams(ctx context.Context, jsonData []byte, params
 		ProjectID:      f.ProjectID,
 		TokenSource:    ts,
 		JSON:           jsonData,
+		QuotaProjectID: f.QuotaProjectID,
 		universeDomain: universeDomain,
 	}, nil
 }
@@ -6,12 +6,17 @@ package google
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/compute/metadata"
+	"golang.org/x/oauth2"
 )
 
 var saJSONJWT = []byte(`{
@@ -310,3 +315,183 @@ func TestComputeUniverseDomain(t *testing.T) {
 	}
 
 }
+
+func TestCredentialsFromJSONWithParams_QuotaProjectID(t *testing.T) {
+	ctx := context.Background()
+	creds, err := CredentialsFromJSONWithParams(ctx, userJSON, CredentialsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "fake_project2"; creds.QuotaProjectID != want {
+		t.Errorf("got %q, want %q", creds.QuotaProjectID, want)
+	}
+
+	for _, data := range [][]byte{userJSON, saJSONJWT} {
+		creds, err = CredentialsFromJSONWithParams(ctx, data, CredentialsParams{QuotaProjectID: "override"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "override"; creds.QuotaProjectID != want {
+			t.Errorf("got %q, want %q", creds.QuotaProjectID, want)
+		}
+	}
+}
+
+func TestCredentialsClient_QuotaProjectID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("X-Goog-User-Project"), "quota-project"; got != want {
+			t.Errorf("X-Goog-User-Project = %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("Authorization"), "Bearer abc"; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+	}))
+	defer ts.Close()
+
+	creds := &Credentials{
+		TokenSource:    oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"}),
+		QuotaProjectID: "quota-project",
+	}
+	res, err := creds.Client(context.Background()).Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestCredentialsFromJSONWithParams_ImpersonatedServiceAccount(t *testing.T) {
+	var gotImpersonation string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token":"source-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		if got, want := r.Header.Get("Authorization"), "Bearer source-token"; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		b, _ := io.ReadAll(r.Body)
+		gotImpersonation = string(b)
+		w.Write([]byte(`{"accessToken":"impersonated-token","expireTime":"2099-01-01T00:00:00Z"}`))
+	}))
+	defer ts.Close()
+
+	json := []byte(`{
+  "type": "impersonated_service_account",
+  "service_account_impersonation_url": "` + ts.URL + `/v1/projects/-/serviceAccounts/sa@example.com:generateAccessToken",
+  "delegates": ["projects/-/serviceAccounts/d@example.com"],
+  "service_account_impersonation": {"token_lifetime_seconds": 1200},
+  "source_credentials": {
+    "client_id": "abc123.apps.googleusercontent.com",
+    "client_secret": "shh",
+    "refresh_token": "refreshing",
+    "type": "authorized_user",
+    "token_uri": "` + ts.URL + `/token"
+  }
+}`)
+	params := CredentialsParams{Scopes: []string{"https://www.googleapis.com/auth/devstorage.read_only"}}
+	creds, err := CredentialsFromJSONWithParams(context.Background(), json, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := creds.TokenSource.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "impersonated-token" {
+		t.Errorf("AccessToken = %q, want %q", tok.AccessToken, "impersonated-token")
+	}
+	want := `{"delegates":["projects/-/serviceAccounts/d@example.com"],"lifetime":"1200s","scope":["https://www.googleapis.com/auth/devstorage.read_only"]}`
+	if gotImpersonation != want {
+		t.Errorf("impersonation request = %s, want %s", gotImpersonation, want)
+	}
+}
+
+func TestCacheDefaultCredentials(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(filename, userJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filename)
+	defer func(d time.Duration) { adcCacheRecheck = d }(adcCacheRecheck)
+	adcCacheRecheck = 0
+	CacheDefaultCredentials(true)
+	defer CacheDefaultCredentials(false)
+
+	ctx := context.Background()
+	find := func() *Credentials {
+		t.Helper()
+		creds, err := FindDefaultCredentials(ctx, "scope")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return creds
+	}
+	creds := find()
+	if got := find(); got != creds {
+		t.Error("second lookup did not return the cached credentials")
+	}
+	if got, err := FindDefaultCredentials(ctx, "other"); err != nil || got == creds {
+		t.Errorf("lookup with other scopes = %p, %v; want new credentials", got, err)
+	}
+
+	InvalidateDefaultCredentials()
+	if got := find(); got == creds {
+		t.Error("lookup after InvalidateDefaultCredentials returned stale credentials")
+	}
+
+	creds = find()
+	if err := os.WriteFile(filename, append(userJSON, '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := find(); got == creds {
+		t.Error("lookup after modifying the credentials file returned stale credentials")
+	}
+
+	creds = find()
+	CacheDefaultCredentials(false)
+	if got := find(); got == creds {
+		t.Error("lookup with the cache disabled returned cached credentials")
+	}
+}
+
+func TestCacheDefaultCredentialsDetachedContext(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(filename, userJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filename)
+	CacheDefaultCredentials(true)
+	defer CacheDefaultCredentials(false)
+
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`)),
+		}, nil
+	})}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), oauth2.HTTPClient, client))
+	creds, err := FindDefaultCredentials(ctx, "scope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	cached, err := FindDefaultCredentials(context.WithValue(context.Background(), oauth2.HTTPClient, client), "scope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != creds {
+		t.Fatal("second lookup did not return the cached credentials")
+	}
+	if _, err := cached.TokenSource.Token(); err != nil {
+		t.Errorf("Token after the first lookup's context was canceled: %v", err)
+	}
+	if other, err := FindDefaultCredentials(context.Background(), "scope"); err != nil || other == creds {
+		t.Errorf("lookup with another HTTP client = %p, %v; want new credentials", other, err)
+	}
+}
@@ -111,11 +111,19 @@ type DownscopingConfig struct {
 	// UniverseDomain is the default service domain for a given Cloud universe.
 	// The default value is "googleapis.com". Optional.
 	UniverseDomain string
+	// STSEndpoint is the URL of the Security Token Service used to
+	// exchange tokens, such as a regional or Private Google Access
+	// endpoint. It overrides the endpoint derived from UniverseDomain.
+	// Optional.
+	STSEndpoint string
 }
 
 // identityBindingEndpoint returns the identity binding endpoint with the
-// configured universe domain.
+// configured universe domain, unless STSEndpoint is set.
 func (dc *DownscopingConfig) identityBindingEndpoint() string {
+	if dc.STSEndpoint != "" {
+		return dc.STSEndpoint
+	}
 	if dc.UniverseDomain == "" {
 		return strings.Replace(identityBindingEndpointTemplate, universeDomainPlaceholder, defaultUniverseDomain, 1)
 	}
@@ -134,7 +142,11 @@ type downscopingTokenSource struct {
 	identityBindingEndpoint string
 }
 
-// NewTokenSource returns a configured downscopingTokenSource.
+// NewTokenSource returns a TokenSource of downscoped tokens.
+//
+// The returned TokenSource caches the downscoped token and obtains a new
+// one, using a fresh token from RootSource, shortly before it expires.
+// It is safe for concurrent use.
 func NewTokenSource(ctx context.Context, conf DownscopingConfig) (oauth2.TokenSource, error) {
 	if conf.RootSource == nil {
 		return nil, fmt.Errorf("downscope: rootSource cannot be nil")
@@ -145,6 +157,11 @@ func NewTokenSource(ctx context.Context, conf DownscopingConfig) (oauth2.TokenSo
 	if len(conf.Rules) > 10 {
 		return nil, fmt.Errorf("downscope: length of AccessBoundaryRules may not be greater than 10")
 	}
+	if conf.STSEndpoint != "" {
+		if u, err := url.Parse(conf.STSEndpoint); err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("downscope: STSEndpoint must be an absolute URL: %q", conf.STSEndpoint)
+		}
+	}
 	for _, val := range conf.Rules {
 		if val.AvailableResource == "" {
 			return nil, fmt.Errorf("downscope: all rules must have a nonempty AvailableResource: %+v", val)
@@ -153,36 +170,25 @@ func NewTokenSource(ctx context.Context, conf DownscopingConfig) (oauth2.TokenSo
 			return nil, fmt.Errorf("downscope: all rules must provide at least one permission: %+v", val)
 		}
 	}
-	return downscopingTokenSource{
+	return oauth2.ReuseTokenSource(nil, downscopingTokenSource{
 		ctx:                     ctx,
 		config:                  conf,
 		identityBindingEndpoint: conf.identityBindingEndpoint(),
-	}, nil
+	}), nil
 }
 
 // Token() uses a downscopingTokenSource to generate an oauth2 Token.
-// Do note that the returned TokenSource is an oauth2.StaticTokenSource. If you wish
-// to refresh this token automatically, then initialize a locally defined
-// TokenSource struct with the Token held by the StaticTokenSource and wrap
-// that TokenSource in an oauth2.ReuseTokenSource.
+// Every call exchanges a new token; NewTokenSource wraps the
+// downscopingTokenSource in an oauth2.ReuseTokenSource to cache it.
 func (dts downscopingTokenSource) Token() (*oauth2.Token, error) {
-
-	downscopedOptions := struct {
-		Boundary accessBoundary `json:"accessBoundary"`
-	}{
-		Boundary: accessBoundary{
-			AccessBoundaryRules: dts.config.Rules,
-		},
-	}
-
 	tok, err := dts.config.RootSource.Token()
 	if err != nil {
 		return nil, fmt.Errorf("downscope: unable to obtain root token: %v", err)
 	}
 
-	b, err := json.Marshal(downscopedOptions)
+	options, err := EncodeAccessBoundary(dts.config.Rules)
 	if err != nil {
-		return nil, fmt.Errorf("downscope: unable to marshal AccessBoundary payload %v", err)
+		return nil, err
 	}
 
 	form := url.Values{}
@@ -190,7 +196,7 @@ func (dts downscopingTokenSource) Token() (*oauth2.Token, error) {
 	form.Add("subject_token_type", "urn:ietf:params:oauth:token-type:access_token")
 	form.Add("requested_token_type", "urn:ietf:params:oauth:token-type:access_token")
 	form.Add("subject_token", tok.AccessToken)
-	form.Add("options", string(b))
+	form.Add("options", options)
 
 	myClient := oauth2.NewClient(dts.ctx, nil)
 	resp, err := myClient.PostForm(dts.identityBindingEndpoint, form)
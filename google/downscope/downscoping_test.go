@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"golang.org/x/oauth2"
@@ -60,21 +61,76 @@ func Test_DownscopedTokenSource(t *testing.T) {
 	}
 }
 
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func Test_NewTokenSourceCaches(t *testing.T) {
+	var exchanges int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		exchanges++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(standardRespBody)),
+		}, nil
+	})}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ts, err := NewTokenSource(ctx, DownscopingConfig{
+		RootSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "Mellon"}),
+		Rules: []AccessBoundaryRule{
+			{
+				AvailableResource:    "test1",
+				AvailablePermissions: []string{"Perm1", "Perm2"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTokenSource failed with error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		tok, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Token failed with error: %v", err)
+		}
+		if got, want := tok.AccessToken, "Open Sesame"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if exchanges != 1 {
+		t.Errorf("got %d token exchanges, want 1", exchanges)
+	}
+}
+
 func Test_DownscopingConfig(t *testing.T) {
 	tests := []struct {
 		universeDomain string
+		stsEndpoint    string
 		want           string
 	}{
-		{"", "https://sts.googleapis.com/v1/token"},
-		{"googleapis.com", "https://sts.googleapis.com/v1/token"},
-		{"example.com", "https://sts.example.com/v1/token"},
+		{"", "", "https://sts.googleapis.com/v1/token"},
+		{"googleapis.com", "", "https://sts.googleapis.com/v1/token"},
+		{"example.com", "", "https://sts.example.com/v1/token"},
+		{"example.com", "https://sts-private.example.com/v1/token", "https://sts-private.example.com/v1/token"},
 	}
 	for _, tt := range tests {
 		c := DownscopingConfig{
 			UniverseDomain: tt.universeDomain,
+			STSEndpoint:    tt.stsEndpoint,
 		}
 		if got := c.identityBindingEndpoint(); got != tt.want {
 			t.Errorf("got %q, want %q", got, tt.want)
 		}
 	}
 }
+
+func Test_NewTokenSourceInvalidSTSEndpoint(t *testing.T) {
+	_, err := NewTokenSource(context.Background(), DownscopingConfig{
+		RootSource:  oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "Mellon"}),
+		Rules:       []AccessBoundaryRule{{AvailableResource: "test1", AvailablePermissions: []string{"Perm1"}}},
+		STSEndpoint: "sts.example.com/v1/token",
+	})
+	if err == nil {
+		t.Errorf("NewTokenSource succeeded with a relative STSEndpoint")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package downscope
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Available permissions of the predefined Cloud Storage roles most
+// commonly used in AccessBoundaryRules.
+const (
+	RoleStorageObjectViewer       = "inRole:roles/storage.objectViewer"
+	RoleStorageObjectCreator      = "inRole:roles/storage.objectCreator"
+	RoleStorageObjectUser         = "inRole:roles/storage.objectUser"
+	RoleStorageObjectAdmin        = "inRole:roles/storage.objectAdmin"
+	RoleStorageLegacyBucketReader = "inRole:roles/storage.legacyBucketReader"
+	RoleStorageLegacyBucketWriter = "inRole:roles/storage.legacyBucketWriter"
+	RoleStorageLegacyObjectReader = "inRole:roles/storage.legacyObjectReader"
+)
+
+// maxRules is the maximum number of rules of a credential access
+// boundary.
+const maxRules = 10
+
+var (
+	// bucketResource matches the full resource name of a bucket.
+	// Bucket names are 3 to 222 characters of lowercase letters,
+	// digits, dashes, underscores and dots, starting and ending with a
+	// letter or digit.
+	bucketResource = regexp.MustCompile(`^//storage\.[a-z0-9.-]+/projects/_/buckets/[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]$`)
+
+	// rolePermission matches a predefined or custom role.
+	rolePermission = regexp.MustCompile(`^inRole:((projects|organizations)/[^/]+/)?roles/[A-Za-z0-9_.]+$`)
+)
+
+// Validate reports whether r is a well-formed rule: its resource must be
+// the full resource name of a Cloud Storage bucket, and it must have at
+// least one permission, each an IAM predefined or custom role prefixed
+// with "inRole:". A condition must have an expression.
+func (r AccessBoundaryRule) Validate() error {
+	if !bucketResource.MatchString(r.AvailableResource) {
+		return fmt.Errorf("downscope: invalid resource %q; want //storage.googleapis.com/projects/_/buckets/BUCKET", r.AvailableResource)
+	}
+	if len(r.AvailablePermissions) == 0 {
+		return fmt.Errorf("downscope: rule for %s has no permissions", r.AvailableResource)
+	}
+	for _, p := range r.AvailablePermissions {
+		if !rolePermission.MatchString(p) {
+			return fmt.Errorf("downscope: invalid permission %q; want inRole:roles/ROLE or a custom role", p)
+		}
+	}
+	if r.Condition != nil && r.Condition.Expression == "" {
+		return fmt.Errorf("downscope: condition of rule for %s has no expression", r.AvailableResource)
+	}
+	return nil
+}
+
+// RulesBuilder builds the AccessBoundaryRules of a DownscopingConfig,
+// validating each rule. Errors are reported by Build.
+type RulesBuilder struct {
+	rules []AccessBoundaryRule
+	err   error
+}
+
+// NewRulesBuilder returns an empty RulesBuilder.
+func NewRulesBuilder() *RulesBuilder {
+	return new(RulesBuilder)
+}
+
+// Bucket adds a rule making permissions available on the Cloud Storage
+// bucket of the given name.
+func (b *RulesBuilder) Bucket(bucket string, permissions ...string) *RulesBuilder {
+	return b.Resource("//storage.googleapis.com/projects/_/buckets/"+bucket, permissions...)
+}
+
+// Resource adds a rule making permissions available on the resource with
+// the given full resource name.
+func (b *RulesBuilder) Resource(resource string, permissions ...string) *RulesBuilder {
+	b.rules = append(b.rules, AccessBoundaryRule{
+		AvailableResource:    resource,
+		AvailablePermissions: append([]string(nil), permissions...),
+	})
+	return b
+}
+
+// Condition restricts the permissions of the last rule added to the
+// objects matching the IAM Conditions expression, such as
+// resource.name.startsWith('projects/_/buckets/bucket/objects/prefix').
+// The title and description are optional.
+func (b *RulesBuilder) Condition(expression, title, description string) *RulesBuilder {
+	if len(b.rules) == 0 {
+		if b.err == nil {
+			b.err = fmt.Errorf("downscope: condition %q added before any rule", expression)
+		}
+		return b
+	}
+	b.rules[len(b.rules)-1].Condition = &AvailabilityCondition{
+		Expression:  expression,
+		Title:       title,
+		Description: description,
+	}
+	return b
+}
+
+// Build returns the rules added to b, or the first error found in them.
+func (b *RulesBuilder) Build() ([]AccessBoundaryRule, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.rules) == 0 {
+		return nil, fmt.Errorf("downscope: length of AccessBoundaryRules must be at least 1")
+	}
+	if len(b.rules) > maxRules {
+		return nil, fmt.Errorf("downscope: length of AccessBoundaryRules may not be greater than %d", maxRules)
+	}
+	for _, r := range b.rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return append([]AccessBoundaryRule(nil), b.rules...), nil
+}
+
+// EncodeAccessBoundary returns the credential access boundary made of
+// rules, encoded as the "options" parameter of a token exchange with the
+// Security Token Service. It is for callers that exchange tokens
+// themselves, such as through the IAM credentials API, rather than with
+// NewTokenSource.
+func EncodeAccessBoundary(rules []AccessBoundaryRule) (string, error) {
+	opts := struct {
+		Boundary accessBoundary `json:"accessBoundary"`
+	}{
+		Boundary: accessBoundary{
+			AccessBoundaryRules: rules,
+		},
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("downscope: unable to marshal AccessBoundary payload %v", err)
+	}
+	return string(b), nil
+}
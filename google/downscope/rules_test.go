@@ -0,0 +1,72 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package downscope
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRulesBuilder(t *testing.T) {
+	rules, err := NewRulesBuilder().
+		Bucket("my-bucket", RoleStorageObjectViewer).
+		Condition("resource.name.startsWith('projects/_/buckets/my-bucket/objects/public/')", "public", "").
+		Resource("//storage.googleapis.com/projects/_/buckets/other.example.com", "inRole:projects/p/roles/custom").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AccessBoundaryRule{
+		{
+			AvailableResource:    "//storage.googleapis.com/projects/_/buckets/my-bucket",
+			AvailablePermissions: []string{"inRole:roles/storage.objectViewer"},
+			Condition: &AvailabilityCondition{
+				Expression: "resource.name.startsWith('projects/_/buckets/my-bucket/objects/public/')",
+				Title:      "public",
+			},
+		},
+		{
+			AvailableResource:    "//storage.googleapis.com/projects/_/buckets/other.example.com",
+			AvailablePermissions: []string{"inRole:projects/p/roles/custom"},
+		},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("Build = %+v; want %+v", rules, want)
+	}
+
+	got, err := EncodeAccessBoundary(rules[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantJSON = `{"accessBoundary":{"accessBoundaryRules":[{"availableResource":"//storage.googleapis.com/projects/_/buckets/other.example.com","availablePermissions":["inRole:projects/p/roles/custom"]}]}}`
+	if got != wantJSON {
+		t.Errorf("EncodeAccessBoundary = %s; want %s", got, wantJSON)
+	}
+}
+
+func TestRulesBuilderErrors(t *testing.T) {
+	tooMany := NewRulesBuilder()
+	for i := 0; i < maxRules+1; i++ {
+		tooMany.Bucket("bucket", RoleStorageObjectViewer)
+	}
+	for name, b := range map[string]*RulesBuilder{
+		"empty":                 NewRulesBuilder(),
+		"too many rules":        tooMany,
+		"bad bucket":            NewRulesBuilder().Bucket("My_Bucket!", RoleStorageObjectViewer),
+		"short bucket":          NewRulesBuilder().Bucket("ab", RoleStorageObjectViewer),
+		"bad resource":          NewRulesBuilder().Resource("test1", RoleStorageObjectViewer),
+		"no permissions":        NewRulesBuilder().Bucket("bucket"),
+		"role without prefix":   NewRulesBuilder().Bucket("bucket", "roles/storage.objectViewer"),
+		"empty condition":       NewRulesBuilder().Bucket("bucket", RoleStorageObjectViewer).Condition("", "t", "d"),
+		"condition before rule": NewRulesBuilder().Condition("true", "", "").Bucket("bucket", RoleStorageObjectViewer),
+	} {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: Build succeeded", name)
+		} else if !strings.HasPrefix(err.Error(), "downscope: ") {
+			t.Errorf("%s: error %q lacks the package prefix", name, err)
+		}
+	}
+}
@@ -15,10 +15,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -75,6 +77,16 @@ const (
 	awsSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
 	awsSessionToken    = "AWS_SESSION_TOKEN"
 
+	// Environment variables used by the shared credentials file and
+	// the ECS/EKS container credentials endpoint.
+	awsProfile                         = "AWS_PROFILE"
+	awsSharedCredentialsFile           = "AWS_SHARED_CREDENTIALS_FILE"
+	awsContainerCredentialsRelativeURI = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	awsContainerCredentialsFullURI     = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	awsContainerAuthorizationToken     = "AWS_CONTAINER_AUTHORIZATION_TOKEN"
+	awsContainerAuthorizationTokenFile = "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"
+	defaultAwsContainerCredentialsHost = "http://169.254.170.2"
+
 	awsTimeFormatLong  = "20060102T150405Z"
 	awsTimeFormatShort = "20060102"
 )
@@ -300,8 +312,22 @@ func canRetrieveSecurityCredentialFromEnvironment() bool {
 	return getenv(awsAccessKeyId) != "" && getenv(awsSecretAccessKey) != ""
 }
 
+func canRetrieveSecurityCredentialFromContainer() bool {
+	return getenv(awsContainerCredentialsRelativeURI) != "" || getenv(awsContainerCredentialsFullURI) != ""
+}
+
 func (cs awsCredentialSource) shouldUseMetadataServer() bool {
-	return cs.awsSecurityCredentialsSupplier == nil && (!canRetrieveRegionFromEnvironment() || !canRetrieveSecurityCredentialFromEnvironment())
+	if cs.awsSecurityCredentialsSupplier != nil {
+		return false
+	}
+	if !canRetrieveRegionFromEnvironment() {
+		return true
+	}
+	if canRetrieveSecurityCredentialFromEnvironment() || canRetrieveSecurityCredentialFromContainer() {
+		return false
+	}
+	creds, _ := getSharedSecurityCredentials()
+	return creds == nil
 }
 
 func (cs awsCredentialSource) credentialSourceType() string {
@@ -424,11 +450,15 @@ func (cs *awsCredentialSource) getAWSSessionToken() (string, error) {
 		return "", err
 	}
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("oauth2/google/externalaccount: unable to retrieve AWS session token - %s", string(respBody))
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return string(respBody), nil
+	case http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed:
+		// The metadata server doesn't support IMDSv2, or rejects it
+		// because session tokens are disabled. Fall back to IMDSv1.
+		return "", nil
 	}
-
-	return string(respBody), nil
+	return "", fmt.Errorf("oauth2/google/externalaccount: unable to retrieve AWS session token - %s", string(respBody))
 }
 
 func (cs *awsCredentialSource) getRegion(headers map[string]string) (string, error) {
@@ -491,6 +521,12 @@ func (cs *awsCredentialSource) getSecurityCredentials(headers map[string]string)
 			SessionToken:    getenv(awsSessionToken),
 		}, nil
 	}
+	if creds, err := getSharedSecurityCredentials(); creds != nil || err != nil {
+		return creds, err
+	}
+	if canRetrieveSecurityCredentialFromContainer() {
+		return cs.getContainerSecurityCredentials()
+	}
 
 	roleName, err := cs.getMetadataRoleName(headers)
 	if err != nil {
@@ -513,6 +549,148 @@ func (cs *awsCredentialSource) getSecurityCredentials(headers map[string]string)
 	return &credentials, nil
 }
 
+// getSharedSecurityCredentials returns the credentials of the AWS_PROFILE
+// profile, or the default profile, in the shared credentials file
+// (~/.aws/credentials by default). It returns nil credentials and no error
+// if the file or profile doesn't exist.
+func getSharedSecurityCredentials() (*AwsSecurityCredentials, error) {
+	filename := getenv(awsSharedCredentialsFile)
+	if filename == "" {
+		home := getenv("HOME")
+		if home == "" {
+			home = getenv("USERPROFILE")
+		}
+		if home == "" {
+			return nil, nil
+		}
+		filename = filepath.Join(home, ".aws", "credentials")
+	}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: unable to read AWS shared credentials file: %v", err)
+	}
+	profile := getenv(awsProfile)
+	if profile == "" {
+		profile = "default"
+	}
+	values, ok := parseAwsProfile(string(data), profile)
+	if !ok {
+		return nil, nil
+	}
+	creds := &AwsSecurityCredentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		// Profiles using SSO, role assumption or credential processes
+		// are not supported.
+		return nil, nil
+	}
+	return creds, nil
+}
+
+// parseAwsProfile returns the keys and values of the named section of an
+// AWS shared credentials file.
+func parseAwsProfile(data, profile string) (map[string]string, bool) {
+	var values map[string]string
+	inProfile := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			if inProfile && values == nil {
+				values = make(map[string]string)
+			}
+		case inProfile:
+			if k, v, ok := strings.Cut(line, "="); ok {
+				values[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return values, values != nil
+}
+
+// getContainerSecurityCredentials fetches credentials from the ECS task
+// or EKS Pod Identity credentials endpoint.
+func (cs *awsCredentialSource) getContainerSecurityCredentials() (*AwsSecurityCredentials, error) {
+	u := getenv(awsContainerCredentialsFullURI)
+	if rel := getenv(awsContainerCredentialsRelativeURI); rel != "" {
+		u = defaultAwsContainerCredentialsHost + rel
+	} else if err := validateContainerCredentialsURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := getenv(awsContainerAuthorizationToken)
+	if file := getenv(awsContainerAuthorizationTokenFile); file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2/google/externalaccount: unable to read AWS container authorization token: %v", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := cs.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: unable to retrieve AWS container credentials - %s", string(respBody))
+	}
+
+	var creds AwsSecurityCredentials
+	if err := json.Unmarshal(respBody, &creds); err != nil {
+		return nil, err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("oauth2/google/externalaccount: incomplete AWS container credentials")
+	}
+	return &creds, nil
+}
+
+// validateContainerCredentialsURL reports an error unless the container
+// credentials endpoint u, to which the container authorization token is
+// sent, uses https or is on a loopback address or the ECS or EKS Pod
+// Identity link-local address, as the AWS SDKs require.
+func validateContainerCredentialsURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("oauth2/google/externalaccount: invalid %s: %v", awsContainerCredentialsFullURI, err)
+	}
+	if parsed.Scheme == "https" {
+		return nil
+	}
+	if parsed.Scheme == "http" {
+		host := parsed.Hostname()
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			if ip.IsLoopback() || ip.Equal(net.ParseIP("169.254.170.2")) || ip.Equal(net.ParseIP("169.254.170.23")) || ip.Equal(net.ParseIP("fd00:ec2::23")) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("oauth2/google/externalaccount: %s must use https or a loopback or ECS/EKS container address, got %q", awsContainerCredentialsFullURI, u)
+}
+
 func (cs *awsCredentialSource) getMetadataSecurityCredentials(roleName string, headers map[string]string) (AwsSecurityCredentials, error) {
 	var result AwsSecurityCredentials
 
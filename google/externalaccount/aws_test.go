@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	neturl "net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -660,6 +662,62 @@ func TestAWSCredential_IMDSv2(t *testing.T) {
 	}
 }
 
+func TestAWSCredential_IMDSv2FallbackToIMDSv1(t *testing.T) {
+	server := createDefaultAwsTestServer()
+	server.imdsv2SessionTokenUrl = "/latest/api/token"
+	server.WriteIMDSv2SessionToken = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}
+	validate := func(r *http.Request) {
+		if v := r.Header.Get(awsIMDSv2SessionTokenHeader); v != "" {
+			t.Errorf("%q = %q, want empty", awsIMDSv2SessionTokenHeader, v)
+		}
+	}
+	server.WriteRolename = func(w http.ResponseWriter, r *http.Request) {
+		validate(r)
+		w.Write([]byte("gcp-aws-role"))
+	}
+	server.WriteRegion = func(w http.ResponseWriter, r *http.Request) {
+		validate(r)
+		w.Write([]byte("us-east-2b"))
+	}
+	ts := httptest.NewServer(server)
+
+	tfc := testFileConfig
+	tfc.CredentialSource = server.getCredentialSource(ts.URL)
+
+	oldGetenv := getenv
+	oldNow := now
+	defer func() {
+		getenv = oldGetenv
+		now = oldNow
+	}()
+	getenv = setEnvironment(map[string]string{})
+	now = setTime(defaultTime)
+
+	base, err := tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+
+	out, err := base.subjectToken()
+	if err != nil {
+		t.Fatalf("retrieveSubjectToken() failed: %v", err)
+	}
+
+	expected := getExpectedSubjectToken(
+		"https://sts.us-east-2.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		"us-east-2",
+		accessKeyID,
+		secretAccessKey,
+		securityToken,
+	)
+
+	if got, want := out, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("subjectToken = \n%q\n want \n%q", got, want)
+	}
+}
+
 func TestAWSCredential_BasicRequestWithoutSecurityToken(t *testing.T) {
 	server := createDefaultAwsTestServer()
 	ts := httptest.NewServer(server)
@@ -1109,6 +1167,153 @@ func TestAWSCredential_ShouldNotCallMetadataEndpointWhenCredsAreInEnv(t *testing
 	}
 }
 
+func TestAWSCredential_SharedCredentialsFile(t *testing.T) {
+	metadataTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Metadata server should not have been called.")
+	}))
+	defer metadataTs.Close()
+
+	filename := filepath.Join(t.TempDir(), "credentials")
+	data := `[default]
+aws_access_key_id = WRONG
+aws_secret_access_key = WRONG
+
+# Development credentials.
+[dev]
+aws_access_key_id = AKIDEXAMPLE
+aws_secret_access_key = wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY
+aws_session_token = ` + securityToken + `
+`
+	if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tfc := testFileConfig
+	tfc.CredentialSource = &CredentialSource{
+		EnvironmentID:               "aws1",
+		URL:                         metadataTs.URL,
+		RegionURL:                   metadataTs.URL,
+		RegionalCredVerificationURL: "https://sts.{region}.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		IMDSv2SessionTokenURL:       metadataTs.URL,
+	}
+
+	oldGetenv := getenv
+	oldNow := now
+	defer func() {
+		getenv = oldGetenv
+		now = oldNow
+	}()
+	getenv = setEnvironment(map[string]string{
+		"AWS_SHARED_CREDENTIALS_FILE": filename,
+		"AWS_PROFILE":                 "dev",
+		"AWS_REGION":                  "us-west-1",
+	})
+	now = setTime(defaultTime)
+
+	base, err := tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+
+	out, err := base.subjectToken()
+	if err != nil {
+		t.Fatalf("retrieveSubjectToken() failed: %v", err)
+	}
+
+	expected := getExpectedSubjectToken(
+		"https://sts.us-west-1.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		"us-west-1",
+		"AKIDEXAMPLE",
+		"wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		securityToken,
+	)
+
+	if got, want := out, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("subjectToken = \n%q\n want \n%q", got, want)
+	}
+}
+
+func TestAWSCredential_ContainerCredentials(t *testing.T) {
+	containerTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "container-token"; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		fmt.Fprintf(w, `{"AccessKeyId":%q,"SecretAccessKey":%q,"Token":%q,"Expiration":"2030-01-01T00:00:00Z"}`,
+			accessKeyID, secretAccessKey, securityToken)
+	}))
+	defer containerTs.Close()
+	metadataTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Metadata server should not have been called.")
+	}))
+	defer metadataTs.Close()
+
+	tfc := testFileConfig
+	tfc.CredentialSource = &CredentialSource{
+		EnvironmentID:               "aws1",
+		URL:                         metadataTs.URL,
+		RegionURL:                   metadataTs.URL,
+		RegionalCredVerificationURL: "https://sts.{region}.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		IMDSv2SessionTokenURL:       metadataTs.URL,
+	}
+
+	oldGetenv := getenv
+	oldNow := now
+	defer func() {
+		getenv = oldGetenv
+		now = oldNow
+	}()
+	getenv = setEnvironment(map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": containerTs.URL + "/creds",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "container-token",
+		"AWS_REGION":                         "us-east-2",
+	})
+	now = setTime(defaultTime)
+
+	base, err := tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+
+	out, err := base.subjectToken()
+	if err != nil {
+		t.Fatalf("retrieveSubjectToken() failed: %v", err)
+	}
+
+	expected := getExpectedSubjectToken(
+		"https://sts.us-east-2.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		"us-east-2",
+		accessKeyID,
+		secretAccessKey,
+		securityToken,
+	)
+
+	if got, want := out, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("subjectToken = \n%q\n want \n%q", got, want)
+	}
+}
+
+func TestValidateContainerCredentialsURL(t *testing.T) {
+	for _, tt := range []struct {
+		url string
+		ok  bool
+	}{
+		{"http://127.0.0.1:8080/creds", true},
+		{"http://localhost/creds", true},
+		{"http://[::1]/creds", true},
+		{"http://169.254.170.2/v2/credentials", true},
+		{"http://169.254.170.23/v1/credentials", true},
+		{"http://[fd00:ec2::23]/v1/credentials", true},
+		{"https://creds.example.com/creds", true},
+		{"http://creds.example.com/creds", false},
+		{"http://10.0.0.1/creds", false},
+		{"ftp://127.0.0.1/creds", false},
+	} {
+		if err := validateContainerCredentialsURL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("validateContainerCredentialsURL(%q) = %v, want ok = %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
 func TestAWSCredential_ShouldCallMetadataEndpointWhenNoRegion(t *testing.T) {
 	server := createDefaultAwsTestServerWithImdsv2(t)
 	ts := httptest.NewServer(server)
@@ -1278,6 +1483,73 @@ func TestAWSCredential_ProgrammaticAuth(t *testing.T) {
 	}
 }
 
+func TestAWSCredential_ProgrammaticAuthFunc(t *testing.T) {
+	tfc := testFileConfig
+	var calls int
+	supplier, err := NewAwsSecurityCredentialsSupplier("us-east-2", func(ctx context.Context) (*AwsSecurityCredentials, error) {
+		calls++
+		return &AwsSecurityCredentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    securityToken,
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewAwsSecurityCredentialsSupplier() failed %v", err)
+	}
+	tfc.AwsSecurityCredentialsSupplier = supplier
+
+	oldNow := now
+	defer func() {
+		now = oldNow
+	}()
+	now = setTime(defaultTime)
+
+	base, err := tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+
+	out, err := base.subjectToken()
+	if err != nil {
+		t.Fatalf("retrieveSubjectToken() failed: %v", err)
+	}
+
+	expected := getExpectedSubjectToken(
+		"https://sts.us-east-2.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15",
+		"us-east-2",
+		accessKeyID,
+		secretAccessKey,
+		securityToken,
+	)
+
+	if got, want := out, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("subjectToken = \n%q\n want \n%q", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("credentials func called %d times; want 1", calls)
+	}
+
+	supplier, err = NewAwsSecurityCredentialsSupplier("", func(ctx context.Context) (*AwsSecurityCredentials, error) {
+		return &AwsSecurityCredentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewAwsSecurityCredentialsSupplier() failed %v", err)
+	}
+	tfc.AwsSecurityCredentialsSupplier = supplier
+	base, err = tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+	if _, err := base.subjectToken(); err == nil {
+		t.Error("subjectToken() with an empty region succeeded")
+	}
+
+	if _, err := NewAwsSecurityCredentialsSupplier("us-east-2", nil); err == nil {
+		t.Error("NewAwsSecurityCredentialsSupplier() with nil creds succeeded")
+	}
+}
+
 func TestAWSCredential_ProgrammaticAuthNoSessionToken(t *testing.T) {
 	tfc := testFileConfig
 	securityCredentials := AwsSecurityCredentials{
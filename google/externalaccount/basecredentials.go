@@ -114,6 +114,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -154,11 +155,17 @@ type Config struct {
 	// user attributes like account identifier, eg. email, username, uid, etc). This is
 	// needed for gCloud session account identification. Optional.
 	TokenInfoURL string
+	// VerifyTokenInfo reports whether access tokens received from the security
+	// token service are checked against TokenInfoURL before they are used. A
+	// token that the token_info endpoint does not report as active is rejected.
+	// It has no effect if TokenInfoURL is empty. Optional.
+	VerifyTokenInfo bool
 	// ServiceAccountImpersonationURL is the URL for the service account impersonation request. This is only
 	// required for workload identity pools when APIs to be accessed have not integrated with UberMint. Optional.
 	ServiceAccountImpersonationURL string
 	// ServiceAccountImpersonationLifetimeSeconds is the number of seconds the service account impersonation
-	// token will be valid for. If not provided, it will default to 3600. Optional.
+	// token will be valid for. If not provided, it will default to 3600. If set, it
+	// must be between 600 and 43200. Optional.
 	ServiceAccountImpersonationLifetimeSeconds int
 	// ClientSecret is currently only required if token_info endpoint also
 	// needs to be called with the generated GCP access token. When provided, STS will be
@@ -190,6 +197,13 @@ type Config struct {
 	// This value will be used in the default STS token URL. The default value
 	// is "googleapis.com". It will not be used if TokenURL is set. Optional.
 	UniverseDomain string
+	// MetricsClientInfo is appended to the x-goog-api-client metrics header sent
+	// to the STS token endpoint, to identify the calling SDK. It is a space-separated
+	// list of name/version tokens, such as "my-sdk/1.2.0". Optional.
+	MetricsClientInfo string
+	// DisableMetricsHeader reports whether the x-goog-api-client metrics header is
+	// omitted from requests to the STS token endpoint. Optional.
+	DisableMetricsHeader bool
 }
 
 var (
@@ -200,6 +214,33 @@ func validateWorkforceAudience(input string) bool {
 	return validWorkforceAudiencePattern.MatchString(input)
 }
 
+// Bounds of the service account impersonation token lifetime accepted by
+// the IAM credentials API.
+const (
+	minLifetimeSeconds = 600
+	maxLifetimeSeconds = 43200
+)
+
+func validateLifetime(seconds int) error {
+	if seconds == 0 {
+		return nil
+	}
+	if seconds < minLifetimeSeconds || seconds > maxLifetimeSeconds {
+		return fmt.Errorf("oauth2/google/externalaccount: service account impersonation token lifetime must be between %d and %d seconds, got %d", minLifetimeSeconds, maxLifetimeSeconds, seconds)
+	}
+	return nil
+}
+
+func validateMetricsClientInfo(info string) error {
+	for _, tok := range strings.Fields(info) {
+		name, version, ok := strings.Cut(tok, "/")
+		if !ok || name == "" || version == "" || strings.ContainsAny(version, "/") {
+			return fmt.Errorf("oauth2/google/externalaccount: invalid metrics client info %q, want name/version", tok)
+		}
+	}
+	return nil
+}
+
 // NewTokenSource Returns an external account TokenSource using the provided external account config.
 func NewTokenSource(ctx context.Context, conf Config) (oauth2.TokenSource, error) {
 	if conf.Audience == "" {
@@ -214,6 +255,12 @@ func NewTokenSource(ctx context.Context, conf Config) (oauth2.TokenSource, error
 			return nil, fmt.Errorf("oauth2/google/externalaccount: Workforce pool user project should not be set for non-workforce pool credentials")
 		}
 	}
+	if err := validateLifetime(conf.ServiceAccountImpersonationLifetimeSeconds); err != nil {
+		return nil, err
+	}
+	if err := validateMetricsClientInfo(conf.MetricsClientInfo); err != nil {
+		return nil, err
+	}
 	count := 0
 	if conf.CredentialSource != nil {
 		count++
@@ -239,8 +286,10 @@ func NewTokenSource(ctx context.Context, conf Config) (oauth2.TokenSource, error
 func (c *Config) tokenSource(ctx context.Context, scheme string) (oauth2.TokenSource, error) {
 
 	ts := tokenSource{
-		ctx:  ctx,
-		conf: c,
+		ctx:     ctx,
+		conf:    c,
+		refresh: new(refreshToken),
+		source:  new(parsedSource),
 	}
 	if c.ServiceAccountImpersonationURL == "" {
 		return oauth2.ReuseTokenSource(nil, ts), nil
@@ -301,6 +350,23 @@ type CredentialSource struct {
 	URL string `json:"url"`
 	// Headers are the headers to attach to the request for URL sourced credentials.
 	Headers map[string]string `json:"headers"`
+	// Method is the HTTP method of the request for URL sourced credentials.
+	// Defaults to "GET" when not provided. Optional.
+	Method string `json:"method"`
+	// Body is the body of the request for URL sourced credentials, typically
+	// used with the "POST" Method. Optional.
+	Body string `json:"body"`
+	// CACertificateFile is the path of a PEM file of root certificates used
+	// to verify the server for URL sourced credentials, such as the CA of a
+	// corporate PKI. When not provided, the system roots are used. Optional.
+	CACertificateFile string `json:"ca_certificate_file"`
+	// TimeoutMillis is the timeout of each request for URL sourced credentials,
+	// in milliseconds. When not provided, only the context limits the request. Optional.
+	TimeoutMillis int `json:"timeout_millis"`
+	// Retries is the number of times a request for URL sourced credentials is
+	// retried after a network error or a 5xx response, with exponential
+	// backoff. Optional.
+	Retries int `json:"retries"`
 
 	// Executable is the configuration object for executable sourced credentials.
 	// One field amongst File, URL, Executable, or EnvironmentID should be provided, depending on the kind of credential in question.
@@ -334,6 +400,13 @@ type CredentialSource struct {
 	IMDSv2SessionTokenURL string `json:"imdsv2_session_token_url"`
 	// Format is the format type for the subject token. Used for File and URL sourced credentials. Expected values are "text" or "json".
 	Format Format `json:"format"`
+
+	// Certificate is the configuration object for X.509 sourced credentials.
+	// The token exchange is performed over mutual TLS with the configured
+	// client certificate, which is also sent as the subject token. When it is
+	// set, TokenURL defaults to the mTLS endpoint of the security token
+	// service and SubjectTokenType should be "urn:ietf:params:oauth:token-type:mtls".
+	Certificate *CertificateConfig `json:"certificate"`
 }
 
 // ExecutableConfig contains information needed for executable sourced credentials.
@@ -367,6 +440,34 @@ type AwsSecurityCredentialsSupplier interface {
 	AwsSecurityCredentials(ctx context.Context, options SupplierOptions) (*AwsSecurityCredentials, error)
 }
 
+// NewAwsSecurityCredentialsSupplier returns an AwsSecurityCredentialsSupplier that reports region and
+// obtains credentials from creds. It lets applications that already resolve AWS credentials, such as
+// with the credential chain of the AWS SDK, use them without the metadata server or environment
+// variables: creds typically wraps the caching Retrieve method of the SDK's credentials provider and
+// copies the access key ID, secret access key and session token it returns. creds must not be nil.
+func NewAwsSecurityCredentialsSupplier(region string, creds func(ctx context.Context) (*AwsSecurityCredentials, error)) (AwsSecurityCredentialsSupplier, error) {
+	if creds == nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: AWS security credentials func must not be nil")
+	}
+	return funcAwsSupplier{region, creds}, nil
+}
+
+type funcAwsSupplier struct {
+	region string
+	creds  func(ctx context.Context) (*AwsSecurityCredentials, error)
+}
+
+func (s funcAwsSupplier) AwsRegion(ctx context.Context, options SupplierOptions) (string, error) {
+	if s.region == "" {
+		return "", fmt.Errorf("oauth2/google/externalaccount: AWS region is empty")
+	}
+	return s.region, nil
+}
+
+func (s funcAwsSupplier) AwsSecurityCredentials(ctx context.Context, options SupplierOptions) (*AwsSecurityCredentials, error) {
+	return s.creds(ctx)
+}
+
 // SupplierOptions contains information about the requested subject token or AWS security credentials from the
 // Google external account credential.
 type SupplierOptions struct {
@@ -383,10 +484,14 @@ type SupplierOptions struct {
 // tokenURL returns the default STS token endpoint with the configured universe
 // domain.
 func (c *Config) tokenURL() string {
+	tokenURL := defaultTokenURL
+	if c.CredentialSource != nil && c.CredentialSource.Certificate != nil {
+		tokenURL = defaultMTLSTokenURL
+	}
 	if c.UniverseDomain == "" {
-		return strings.Replace(defaultTokenURL, universeDomainPlaceholder, defaultUniverseDomain, 1)
+		return strings.Replace(tokenURL, universeDomainPlaceholder, defaultUniverseDomain, 1)
 	}
-	return strings.Replace(defaultTokenURL, universeDomainPlaceholder, c.UniverseDomain, 1)
+	return strings.Replace(tokenURL, universeDomainPlaceholder, c.UniverseDomain, 1)
 }
 
 // parse determines the type of CredentialSource needed.
@@ -430,9 +535,21 @@ func (c *Config) parse(ctx context.Context) (baseCredentialSource, error) {
 	} else if c.CredentialSource.File != "" {
 		return fileCredentialSource{File: c.CredentialSource.File, Format: c.CredentialSource.Format}, nil
 	} else if c.CredentialSource.URL != "" {
-		return urlCredentialSource{URL: c.CredentialSource.URL, Headers: c.CredentialSource.Headers, Format: c.CredentialSource.Format, ctx: ctx}, nil
+		return &urlCredentialSource{
+			URL:               c.CredentialSource.URL,
+			Headers:           c.CredentialSource.Headers,
+			Method:            c.CredentialSource.Method,
+			Body:              c.CredentialSource.Body,
+			CACertificateFile: c.CredentialSource.CACertificateFile,
+			Timeout:           time.Duration(c.CredentialSource.TimeoutMillis) * time.Millisecond,
+			Retries:           c.CredentialSource.Retries,
+			Format:            c.CredentialSource.Format,
+			ctx:               ctx,
+		}, nil
 	} else if c.CredentialSource.Executable != nil {
 		return createExecutableCredential(ctx, c.CredentialSource.Executable, c)
+	} else if c.CredentialSource.Certificate != nil {
+		return newX509CredentialSource(c.CredentialSource.Certificate)
 	}
 	return nil, fmt.Errorf("oauth2/google/externalaccount: unable to parse credential source")
 }
@@ -444,8 +561,62 @@ type baseCredentialSource interface {
 
 // tokenSource is the source that handles external credentials. It is used to retrieve Tokens.
 type tokenSource struct {
-	ctx  context.Context
-	conf *Config
+	ctx     context.Context
+	conf    *Config
+	refresh *refreshToken
+	source  *parsedSource
+}
+
+// parsedSource holds the credential source parsed from a Config. It is
+// shared by the copies of a tokenSource, so that the state of the
+// credential source, such as its HTTP clients, is reused across calls to
+// Token.
+type parsedSource struct {
+	mu  sync.Mutex
+	src baseCredentialSource
+}
+
+// get returns the credential source of conf, parsing it on first use.
+// Parse errors are not cached.
+func (p *parsedSource) get(ctx context.Context, conf *Config) (baseCredentialSource, error) {
+	if p == nil {
+		return conf.parse(ctx)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.src == nil {
+		src, err := conf.parse(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.src = src
+	}
+	return p.src, nil
+}
+
+// refreshToken holds the refresh token issued by the security token
+// service, if any. It is shared by the copies of a tokenSource.
+type refreshToken struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (r *refreshToken) get() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.token
+}
+
+func (r *refreshToken) set(token string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = token
 }
 
 func getMetricsHeaderValue(conf *Config, credSource baseCredentialSource) string {
@@ -454,17 +625,52 @@ func getMetricsHeaderValue(conf *Config, credSource baseCredentialSource) string
 		"unknown",
 		credSource.credentialSourceType(),
 		conf.ServiceAccountImpersonationURL != "",
-		conf.ServiceAccountImpersonationLifetimeSeconds != 0)
+		conf.ServiceAccountImpersonationLifetimeSeconds != 0) + metricsClientInfo(conf)
+}
+
+func metricsClientInfo(conf *Config) string {
+	if info := strings.Join(strings.Fields(conf.MetricsClientInfo), " "); info != "" {
+		return " " + info
+	}
+	return ""
 }
 
 // Token allows tokenSource to conform to the oauth2.TokenSource interface.
 func (ts tokenSource) Token() (*oauth2.Token, error) {
 	conf := ts.conf
 
-	credSource, err := conf.parse(ts.ctx)
+	credSource, err := ts.source.get(ts.ctx, conf)
 	if err != nil {
 		return nil, err
 	}
+	header := make(http.Header)
+	header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if !conf.DisableMetricsHeader {
+		header.Add("x-goog-api-client", getMetricsHeaderValue(conf, credSource))
+	}
+	clientAuth := stsexchange.ClientAuthentication{
+		AuthStyle:    oauth2.AuthStyleInHeader,
+		ClientID:     conf.ClientID,
+		ClientSecret: conf.ClientSecret,
+	}
+	ctx := ts.ctx
+	if x509, ok := credSource.(*x509CredentialSource); ok {
+		if ctx, err = x509.mtlsContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Use the refresh token of a previous exchange, if the security
+	// token service issued one, instead of exchanging a new subject
+	// token. If the refresh fails, fall back to a full exchange.
+	if rt := ts.refresh.get(); rt != "" {
+		stsResp, err := stsexchange.RefreshAccessToken(ctx, conf.TokenURL, rt, clientAuth, header)
+		if err == nil {
+			return ts.verify(ctx, clientAuth, header, stsResp, rt)
+		}
+		ts.refresh.set("")
+	}
+
 	subjectToken, err := credSource.subjectToken()
 
 	if err != nil {
@@ -478,14 +684,6 @@ func (ts tokenSource) Token() (*oauth2.Token, error) {
 		SubjectToken:       subjectToken,
 		SubjectTokenType:   conf.SubjectTokenType,
 	}
-	header := make(http.Header)
-	header.Add("Content-Type", "application/x-www-form-urlencoded")
-	header.Add("x-goog-api-client", getMetricsHeaderValue(conf, credSource))
-	clientAuth := stsexchange.ClientAuthentication{
-		AuthStyle:    oauth2.AuthStyleInHeader,
-		ClientID:     conf.ClientID,
-		ClientSecret: conf.ClientSecret,
-	}
 	var options map[string]interface{}
 	// Do not pass workforce_pool_user_project when client authentication is used.
 	// The client ID is sufficient for determining the user project.
@@ -494,11 +692,35 @@ func (ts tokenSource) Token() (*oauth2.Token, error) {
 			"userProject": conf.WorkforcePoolUserProject,
 		}
 	}
-	stsResp, err := stsexchange.ExchangeToken(ts.ctx, conf.TokenURL, &stsRequest, clientAuth, header, options)
+	stsResp, err := stsexchange.ExchangeToken(ctx, conf.TokenURL, &stsRequest, clientAuth, header, options)
 	if err != nil {
 		return nil, err
 	}
+	return ts.verify(ctx, clientAuth, header, stsResp, "")
+}
 
+// verify converts stsResp to a Token and, if VerifyTokenInfo is set,
+// checks that the token_info endpoint reports it as active.
+func (ts tokenSource) verify(ctx context.Context, clientAuth stsexchange.ClientAuthentication, header http.Header, stsResp *stsexchange.Response, prev string) (*oauth2.Token, error) {
+	tok, err := ts.tokenFromResponse(stsResp, prev)
+	if err != nil || !ts.conf.VerifyTokenInfo || ts.conf.TokenInfoURL == "" {
+		return tok, err
+	}
+	info, err := stsexchange.IntrospectToken(ctx, ts.conf.TokenInfoURL, tok.AccessToken, clientAuth, header)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: failed to verify token: %v", err)
+	}
+	if !info.Active {
+		ts.refresh.set("")
+		return nil, fmt.Errorf("oauth2/google/externalaccount: token_info endpoint reported the access token as inactive")
+	}
+	return tok, nil
+}
+
+// tokenFromResponse converts a security token service response to a
+// Token, recording its refresh token for the next call to Token. The
+// previous refresh token prev is kept if the response has none.
+func (ts tokenSource) tokenFromResponse(stsResp *stsexchange.Response, prev string) (*oauth2.Token, error) {
 	accessToken := &oauth2.Token{
 		AccessToken: stsResp.AccessToken,
 		TokenType:   stsResp.TokenType,
@@ -510,8 +732,14 @@ func (ts tokenSource) Token() (*oauth2.Token, error) {
 	}
 	accessToken.Expiry = now().Add(time.Duration(stsResp.ExpiresIn) * time.Second)
 
+	accessToken.RefreshToken = prev
 	if stsResp.RefreshToken != "" {
 		accessToken.RefreshToken = stsResp.RefreshToken
 	}
+	ts.refresh.set(accessToken.RefreshToken)
+	if stsResp.Scope != "" {
+		// Surface the granted scopes through Token.Scopes.
+		accessToken = accessToken.WithExtra(map[string]interface{}{"scope": stsResp.Scope})
+	}
 	return accessToken, nil
 }
@@ -266,14 +266,11 @@ func (cs executableCredentialSource) getTokenFromOutputFile() (token string, err
 
 	token, err = cs.parseSubjectTokenFromSource(data, outputFileSource, cs.env.now().Unix())
 	if err != nil {
-		if _, ok := err.(nonCacheableError); ok {
-			// If the cached token is expired we need a new token,
-			// and if the cache contains a failure, we need to try again.
-			return "", nil
-		}
-
-		// There was an error in the cached token, and the developer should be aware of it.
-		return "", err
+		// The cached response is expired, a failure or malformed, such
+		// as when the executable was interrupted while writing it, so
+		// a new one is needed. The executable's own response is still
+		// validated, so errors in its output remain visible.
+		return "", nil
 	}
 	// Token parsing succeeded.  Use found token.
 	return token, nil
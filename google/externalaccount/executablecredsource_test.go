@@ -646,36 +646,86 @@ func TestRetrieveOutputFileSubjectTokenNotJSON(t *testing.T) {
 	}
 
 	te := testEnvironment{
-		envVars:      executablesAllowed,
-		byteResponse: []byte{},
+		envVars: executablesAllowed,
+		jsonResponse: &executableResponse{
+			Success:        Bool(true),
+			Version:        1,
+			ExpirationTime: defaultTime.Unix() + 3600,
+			TokenType:      "urn:ietf:params:oauth:token-type:jwt",
+			IdToken:        "freshtoken",
+		},
 	}
 	ecs.env = &te
 
-	if _, err = base.subjectToken(); err == nil {
-		t.Fatalf("Expected error but found none")
-	} else if got, want := err.Error(), jsonParsingError(outputFileSource, "tokentokentoken").Error(); got != want {
-		t.Errorf("Incorrect error received.\nExpected: %s\nRecieved: %s", want, got)
+	out, err := ecs.subjectToken()
+	if err != nil {
+		t.Fatalf("subjectToken() failed: %v", err)
 	}
-
-	_, deadlineSet := te.getDeadline()
-	if deadlineSet {
-		t.Errorf("Executable called when it should not have been")
+	if got, want := out, "freshtoken"; got != want {
+		t.Errorf("Incorrect token received.\nExpected: %s\nRecieved: %s", want, got)
+	}
+	if _, deadlineSet := te.getDeadline(); !deadlineSet {
+		t.Errorf("Executable not called for a malformed output file")
 	}
 }
 
-// These are errors in the output file that should be reported to the user.
-// Most of these will help the developers debug their code.
-var cacheFailureTests = []struct {
+// These tests should ignore the error in the output file, whether it is
+// expired, a failure or malformed, and check the executable.
+var invalidCacheTests = []struct {
 	name               string
 	outputFileContents executableResponse
-	expectedErr        error
 }{
+	{
+		name: "User Defined Error",
+		outputFileContents: executableResponse{
+			Success: Bool(false),
+			Version: 1,
+			Code:    "404",
+			Message: "Token Not Found",
+		},
+	},
+
+	{
+		name: "User Defined Error without Code",
+		outputFileContents: executableResponse{
+			Success: Bool(false),
+			Version: 1,
+			Message: "Token Not Found",
+		},
+	},
+
+	{
+		name: "User Defined Error without Message",
+		outputFileContents: executableResponse{
+			Success: Bool(false),
+			Version: 1,
+			Code:    "404",
+		},
+	},
+
+	{
+		name: "User Defined Error without Fields",
+		outputFileContents: executableResponse{
+			Success: Bool(false),
+			Version: 1,
+		},
+	},
+
+	{
+		name: "Expired Token",
+		outputFileContents: executableResponse{
+			Success:        Bool(true),
+			Version:        1,
+			ExpirationTime: defaultTime.Unix() - 1,
+			TokenType:      "urn:ietf:params:oauth:token-type:jwt",
+		},
+	},
+
 	{
 		name: "Missing Version",
 		outputFileContents: executableResponse{
 			Success: Bool(true),
 		},
-		expectedErr: missingFieldError(outputFileSource, "version"),
 	},
 
 	{
@@ -683,7 +733,6 @@ var cacheFailureTests = []struct {
 		outputFileContents: executableResponse{
 			Version: 1,
 		},
-		expectedErr: missingFieldError(outputFileSource, "success"),
 	},
 
 	{
@@ -692,7 +741,6 @@ var cacheFailureTests = []struct {
 			Success: Bool(true),
 			Version: 2,
 		},
-		expectedErr: unsupportedVersionError(outputFileSource, 2),
 	},
 
 	{
@@ -702,7 +750,6 @@ var cacheFailureTests = []struct {
 			Version:        1,
 			ExpirationTime: defaultTime.Unix(),
 		},
-		expectedErr: missingFieldError(outputFileSource, "token_type"),
 	},
 
 	{
@@ -712,7 +759,6 @@ var cacheFailureTests = []struct {
 			Version:   1,
 			TokenType: "urn:ietf:params:oauth:token-type:jwt",
 		},
-		expectedErr: missingFieldError(outputFileSource, "expiration_time"),
 	},
 
 	{
@@ -723,7 +769,6 @@ var cacheFailureTests = []struct {
 			ExpirationTime: defaultTime.Unix(),
 			TokenType:      "urn:ietf:params:oauth:token-type:invalid",
 		},
-		expectedErr: tokenTypeError(outputFileSource),
 	},
 
 	{
@@ -734,7 +779,6 @@ var cacheFailureTests = []struct {
 			ExpirationTime: defaultTime.Unix() + 3600,
 			TokenType:      "urn:ietf:params:oauth:token-type:jwt",
 		},
-		expectedErr: missingFieldError(outputFileSource, "id_token"),
 	},
 
 	{
@@ -745,7 +789,6 @@ var cacheFailureTests = []struct {
 			ExpirationTime: defaultTime.Unix() + 3600,
 			TokenType:      "urn:ietf:params:oauth:token-type:id_token",
 		},
-		expectedErr: missingFieldError(outputFileSource, "id_token"),
 	},
 
 	{
@@ -756,110 +799,6 @@ var cacheFailureTests = []struct {
 			ExpirationTime: defaultTime.Unix() + 3600,
 			TokenType:      "urn:ietf:params:oauth:token-type:jwt",
 		},
-		expectedErr: missingFieldError(outputFileSource, "id_token"),
-	},
-}
-
-func TestRetrieveOutputFileSubjectTokenFailureTests(t *testing.T) {
-	for _, tt := range cacheFailureTests {
-		t.Run(tt.name, func(t *testing.T) {
-			outputFile, err := ioutil.TempFile("testdata", "result.*.json")
-			if err != nil {
-				t.Fatalf("Tempfile failed: %v", err)
-			}
-			defer os.Remove(outputFile.Name())
-
-			cs := CredentialSource{
-				Executable: &ExecutableConfig{
-					Command:       "blarg",
-					TimeoutMillis: Int(5000),
-					OutputFile:    outputFile.Name(),
-				},
-			}
-
-			tfc := testFileConfig
-			tfc.CredentialSource = &cs
-
-			base, err := tfc.parse(context.Background())
-			if err != nil {
-				t.Fatalf("parse() failed %v", err)
-			}
-
-			ecs, ok := base.(executableCredentialSource)
-			if !ok {
-				t.Fatalf("Wrong credential type created.")
-			}
-			te := testEnvironment{
-				envVars:      executablesAllowed,
-				byteResponse: []byte{},
-			}
-			ecs.env = &te
-			if err = json.NewEncoder(outputFile).Encode(tt.outputFileContents); err != nil {
-				t.Errorf("Error encoding to file: %v", err)
-				return
-			}
-			if _, err = ecs.subjectToken(); err == nil {
-				t.Errorf("Expected error but found none")
-			} else if got, want := err.Error(), tt.expectedErr.Error(); got != want {
-				t.Errorf("Incorrect error received.\nExpected: %s\nRecieved: %s", want, got)
-			}
-
-			if _, deadlineSet := te.getDeadline(); deadlineSet {
-				t.Errorf("Executable called when it should not have been")
-			}
-		})
-	}
-}
-
-// These tests should ignore the error in the output file, and check the executable.
-var invalidCacheTests = []struct {
-	name               string
-	outputFileContents executableResponse
-}{
-	{
-		name: "User Defined Error",
-		outputFileContents: executableResponse{
-			Success: Bool(false),
-			Version: 1,
-			Code:    "404",
-			Message: "Token Not Found",
-		},
-	},
-
-	{
-		name: "User Defined Error without Code",
-		outputFileContents: executableResponse{
-			Success: Bool(false),
-			Version: 1,
-			Message: "Token Not Found",
-		},
-	},
-
-	{
-		name: "User Defined Error without Message",
-		outputFileContents: executableResponse{
-			Success: Bool(false),
-			Version: 1,
-			Code:    "404",
-		},
-	},
-
-	{
-		name: "User Defined Error without Fields",
-		outputFileContents: executableResponse{
-			Success: Bool(false),
-			Version: 1,
-		},
-	},
-
-	{
-		name: "Expired Token",
-		outputFileContents: executableResponse{
-			Success:        Bool(true),
-			Version:        1,
-			ExpirationTime: defaultTime.Unix() - 1,
-			TokenType:      "urn:ietf:params:oauth:token-type:jwt",
-		},
 	},
 }
 
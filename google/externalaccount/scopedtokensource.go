@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package externalaccount
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// ScopedTokenSources hands out external account token sources for
+// different sets of scopes that share one Config.
+//
+// A single exchange can produce a token usable against several Google
+// services: set Config.Scopes to the union of the scopes those services
+// need (or to https://www.googleapis.com/auth/cloud-platform) and use
+// NewTokenSource. When services must instead receive narrowly scoped
+// tokens, use ScopedTokenSources to perform one exchange per set of
+// scopes. Token sources are cached per set of scopes, so tokens are
+// reused only for the exact scopes, and the Audience, they were
+// requested for.
+type ScopedTokenSources struct {
+	ctx  context.Context
+	conf Config
+
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource // keyed by normalized scopes
+}
+
+// NewScopedTokenSources returns a ScopedTokenSources for conf.
+// conf is validated as in NewTokenSource; conf.Scopes is ignored.
+func NewScopedTokenSources(ctx context.Context, conf Config) (*ScopedTokenSources, error) {
+	if _, err := NewTokenSource(ctx, conf); err != nil {
+		return nil, err
+	}
+	conf.Scopes = nil
+	return &ScopedTokenSources{
+		ctx:     ctx,
+		conf:    conf,
+		sources: make(map[string]oauth2.TokenSource),
+	}, nil
+}
+
+// TokenSource returns a token source whose tokens are requested with the
+// given scopes. Calls with the same scopes, in any order, share one
+// caching token source.
+func (s *ScopedTokenSources) TokenSource(scopes ...string) (oauth2.TokenSource, error) {
+	scopes = normalizeScopes(scopes)
+	key := strings.Join(scopes, " ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ts, ok := s.sources[key]; ok {
+		return ts, nil
+	}
+	conf := s.conf
+	conf.Scopes = scopes
+	ts, err := conf.tokenSource(s.ctx, "https")
+	if err != nil {
+		return nil, err
+	}
+	s.sources[key] = ts
+	return ts, nil
+}
+
+// normalizeScopes returns a sorted copy of scopes without duplicates.
+func normalizeScopes(scopes []string) []string {
+	out := append([]string(nil), scopes...)
+	sort.Strings(out)
+	n := 0
+	for i, s := range out {
+		if i > 0 && s == out[n-1] {
+			continue
+		}
+		out[n] = s
+		n++
+	}
+	return out[:n]
+}
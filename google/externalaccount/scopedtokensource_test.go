@@ -0,0 +1,53 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package externalaccount
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScopedTokenSources(t *testing.T) {
+	var gotScopes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotScopes = append(gotScopes, r.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(baseCredsResponseBody))
+	}))
+	defer server.Close()
+
+	conf := testConfig
+	conf.TokenURL = server.URL
+	sources, err := NewScopedTokenSources(context.Background(), conf)
+	if err != nil {
+		t.Fatalf("NewScopedTokenSources() failed: %v", err)
+	}
+
+	for _, scopes := range [][]string{
+		{"b", "a"},
+		{"a", "b", "a"},
+		{"c"},
+	} {
+		ts, err := sources.TokenSource(scopes...)
+		if err != nil {
+			t.Fatalf("TokenSource(%q) failed: %v", scopes, err)
+		}
+		if _, err := ts.Token(); err != nil {
+			t.Fatalf("Token() for %q failed: %v", scopes, err)
+		}
+	}
+	if got, want := len(gotScopes), 2; got != want {
+		t.Fatalf("got %d exchanges, want %d", got, want)
+	}
+	if got, want := gotScopes[0], "a b"; got != want {
+		t.Errorf("first exchange scope = %q, want %q", got, want)
+	}
+	if got, want := gotScopes[1], "c"; got != want {
+		t.Errorf("second exchange scope = %q, want %q", got, want)
+	}
+}
@@ -6,50 +6,77 @@ package externalaccount
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/internal"
 )
 
 type urlCredentialSource struct {
-	URL     string
-	Headers map[string]string
-	Format  Format
-	ctx     context.Context
+	URL               string
+	Headers           map[string]string
+	Method            string
+	Body              string
+	CACertificateFile string
+	Timeout           time.Duration
+	Retries           int
+	Format            Format
+	ctx               context.Context
+
+	// clientOnce guards httpClient and clientErr, the HTTP client for URL
+	// built on first use, so that its connections are reused.
+	clientOnce sync.Once
+	httpClient *http.Client
+	clientErr  error
 }
 
-func (cs urlCredentialSource) credentialSourceType() string {
+const (
+	// urlRetryMinBackoff and urlRetryMaxBackoff bound the exponential
+	// backoff between retries of the request for the subject token.
+	urlRetryMinBackoff = 100 * time.Millisecond
+	urlRetryMaxBackoff = 5 * time.Second
+)
+
+func (cs *urlCredentialSource) credentialSourceType() string {
 	return "url"
 }
 
-func (cs urlCredentialSource) subjectToken() (string, error) {
-	client := oauth2.NewClient(cs.ctx, nil)
-	req, err := http.NewRequest("GET", cs.URL, nil)
-	if err != nil {
-		return "", fmt.Errorf("oauth2/google/externalaccount: HTTP request for URL-sourced credential failed: %v", err)
+func (cs *urlCredentialSource) subjectToken() (string, error) {
+	cs.clientOnce.Do(func() {
+		cs.httpClient, cs.clientErr = cs.client()
+	})
+	if cs.clientErr != nil {
+		return "", cs.clientErr
 	}
-	req = req.WithContext(cs.ctx)
-
-	for key, val := range cs.Headers {
-		req.Header.Add(key, val)
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("oauth2/google/externalaccount: invalid response when retrieving subject token: %v", err)
+	var respBody []byte
+	var err error
+	backoff := urlRetryMinBackoff
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		respBody, retryable, err = cs.fetch(cs.httpClient)
+		if err == nil || !retryable || attempt >= cs.Retries {
+			break
+		}
+		select {
+		case <-cs.ctx.Done():
+			return "", err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > urlRetryMaxBackoff {
+			backoff = urlRetryMaxBackoff
+		}
 	}
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		return "", fmt.Errorf("oauth2/google/externalaccount: invalid body in subject token URL query: %v", err)
-	}
-	if c := resp.StatusCode; c < 200 || c > 299 {
-		return "", fmt.Errorf("oauth2/google/externalaccount: status code %d: %s", c, respBody)
+		return "", err
 	}
 
 	switch cs.Format.Type {
@@ -77,3 +104,64 @@ func (cs urlCredentialSource) subjectToken() (string, error) {
 	}
 
 }
+
+// client returns the HTTP client for the credential URL, trusting
+// CACertificateFile if it is set.
+func (cs *urlCredentialSource) client() (*http.Client, error) {
+	if cs.CACertificateFile == "" {
+		return oauth2.NewClient(cs.ctx, nil), nil
+	}
+	pemCerts, err := ioutil.ReadFile(cs.CACertificateFile)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: failed to read CA certificate file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: no certificates found in %q", cs.CACertificateFile)
+	}
+	tr := internal.CloneContextTransport(cs.ctx)
+	tr.TLSClientConfig.RootCAs = pool
+	return &http.Client{Transport: tr}, nil
+}
+
+// fetch makes one request for the subject token. It reports whether a
+// failed request may be retried.
+func (cs *urlCredentialSource) fetch(client *http.Client) (body []byte, retryable bool, err error) {
+	method := cs.Method
+	if method == "" {
+		method = "GET"
+	}
+	var reqBody io.Reader
+	if cs.Body != "" {
+		reqBody = strings.NewReader(cs.Body)
+	}
+	req, err := http.NewRequest(method, cs.URL, reqBody)
+	if err != nil {
+		return nil, false, fmt.Errorf("oauth2/google/externalaccount: HTTP request for URL-sourced credential failed: %v", err)
+	}
+	ctx := cs.ctx
+	if cs.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cs.Timeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	for key, val := range cs.Headers {
+		req.Header.Add(key, val)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, cs.ctx.Err() == nil, fmt.Errorf("oauth2/google/externalaccount: invalid response when retrieving subject token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, true, fmt.Errorf("oauth2/google/externalaccount: invalid body in subject token URL query: %v", err)
+	}
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return nil, c >= 500, fmt.Errorf("oauth2/google/externalaccount: status code %d: %s", c, body)
+	}
+	return body, false, nil
+}
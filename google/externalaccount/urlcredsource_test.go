@@ -7,8 +7,12 @@ package externalaccount
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -112,6 +116,118 @@ func TestRetrieveURLSubjectToken_JSON(t *testing.T) {
 	}
 }
 
+func TestRetrieveURLSubjectToken_PostWithCAAndRetries(t *testing.T) {
+	var attempts int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Method != "POST" {
+			t.Errorf("Unexpected request method, %v is found", r.Method)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != "audience=test" {
+			t.Errorf("Unexpected request body %q", body)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("testTokenValue"))
+	}))
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cs := CredentialSource{
+		URL:               ts.URL,
+		Method:            "POST",
+		Body:              "audience=test",
+		CACertificateFile: caFile,
+		TimeoutMillis:     5000,
+		Retries:           2,
+	}
+	tfc := testFileConfig
+	tfc.CredentialSource = &cs
+
+	base, err := tfc.parse(context.Background())
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+	out, err := base.subjectToken()
+	if err != nil {
+		t.Fatalf("retrieveSubjectToken() failed: %v", err)
+	}
+	if out != myURLToken {
+		t.Errorf("got %v but want %v", out, myURLToken)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts but want 3", attempts)
+	}
+
+	// Client errors are not retried.
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	attempts = 0
+	if _, err := base.subjectToken(); err == nil {
+		t.Errorf("retrieveSubjectToken() succeeded, want error")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts but want 1", attempts)
+	}
+}
+
+func TestRetrieveURLSubjectToken_RetryStopsOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	tfc := testFileConfig
+	tfc.CredentialSource = &CredentialSource{URL: ts.URL, Retries: 5}
+	base, err := tfc.parse(ctx)
+	if err != nil {
+		t.Fatalf("parse() failed %v", err)
+	}
+	if _, err := base.subjectToken(); err == nil {
+		t.Errorf("retrieveSubjectToken() succeeded, want error")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts but want 1", attempts)
+	}
+}
+
+func TestURLCredential_ReusesClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("testTokenValue"))
+	}))
+	defer ts.Close()
+
+	tfc := testFileConfig
+	tfc.CredentialSource = &CredentialSource{URL: ts.URL, TimeoutMillis: 5000}
+	source := new(parsedSource)
+	var clients []*http.Client
+	for i := 0; i < 2; i++ {
+		base, err := source.get(context.Background(), &tfc)
+		if err != nil {
+			t.Fatalf("parse() failed %v", err)
+		}
+		if _, err := base.subjectToken(); err != nil {
+			t.Fatalf("retrieveSubjectToken() failed: %v", err)
+		}
+		clients = append(clients, base.(*urlCredentialSource).httpClient)
+	}
+	if clients[0] == nil || clients[0] != clients[1] {
+		t.Errorf("got HTTP clients %p and %p, want the same client", clients[0], clients[1])
+	}
+}
+
 func TestURLCredential_CredentialSourceType(t *testing.T) {
 	cs := CredentialSource{
 		URL:    "http://example.com",
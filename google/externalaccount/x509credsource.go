@@ -0,0 +1,163 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package externalaccount
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/internal"
+)
+
+const (
+	// certificateConfigEnv is the environment variable naming the
+	// certificate configuration file used when
+	// CertificateConfig.UseDefaultCertificateConfig is true.
+	certificateConfigEnv = "GOOGLE_API_CERTIFICATE_CONFIG"
+
+	// defaultMTLSTokenURL is the STS endpoint used for X.509 sourced
+	// credentials, which requires mutual TLS.
+	defaultMTLSTokenURL = "https://sts.mtls.UNIVERSE_DOMAIN/v1/token"
+)
+
+// CertificateConfig configures X.509 sourced credentials. The client
+// certificate is sent, as a subject token, to the security token service
+// over mutual TLS.
+type CertificateConfig struct {
+	// UseDefaultCertificateConfig uses the certificate configuration
+	// file named by the GOOGLE_API_CERTIFICATE_CONFIG environment
+	// variable, or the gcloud default location
+	// (~/.config/gcloud/certificate_config.json) if it is unset.
+	// One of UseDefaultCertificateConfig and CertificateConfigLocation
+	// must be set.
+	UseDefaultCertificateConfig bool `json:"use_default_certificate_config"`
+	// CertificateConfigLocation is the path of the certificate
+	// configuration file.
+	CertificateConfigLocation string `json:"certificate_config_location"`
+}
+
+// certificateConfigFile is the certificate configuration file written by
+// gcloud and the workload certificate tooling.
+type certificateConfigFile struct {
+	CertConfigs struct {
+		Workload *struct {
+			CertPath string `json:"cert_path"`
+			KeyPath  string `json:"key_path"`
+		} `json:"workload"`
+	} `json:"cert_configs"`
+}
+
+type x509CredentialSource struct {
+	CertPath string
+	KeyPath  string
+
+	// clientOnce guards mtlsClient, the HTTP client presenting the client
+	// certificate, built on first use so that its connections are reused.
+	clientOnce sync.Once
+	mtlsClient *http.Client
+}
+
+func newX509CredentialSource(conf *CertificateConfig) (*x509CredentialSource, error) {
+	location := conf.CertificateConfigLocation
+	switch {
+	case conf.UseDefaultCertificateConfig && location != "":
+		return nil, errors.New("oauth2/google/externalaccount: only one of UseDefaultCertificateConfig and CertificateConfigLocation may be set")
+	case conf.UseDefaultCertificateConfig:
+		location = defaultCertificateConfigLocation()
+	case location == "":
+		return nil, errors.New("oauth2/google/externalaccount: one of UseDefaultCertificateConfig and CertificateConfigLocation must be set")
+	}
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: failed to read certificate config: %v", err)
+	}
+	var f certificateConfigFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: failed to parse certificate config: %v", err)
+	}
+	w := f.CertConfigs.Workload
+	if w == nil || w.CertPath == "" || w.KeyPath == "" {
+		return nil, errors.New("oauth2/google/externalaccount: certificate config has no workload cert_path and key_path")
+	}
+	return &x509CredentialSource{CertPath: w.CertPath, KeyPath: w.KeyPath}, nil
+}
+
+func defaultCertificateConfigLocation() string {
+	if f := os.Getenv(certificateConfigEnv); f != "" {
+		return f
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "certificate_config.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud", "certificate_config.json")
+}
+
+func (cs *x509CredentialSource) credentialSourceType() string {
+	return "x509"
+}
+
+// subjectToken returns the certificate chain as a JSON array of
+// base64-encoded DER certificates, leaf first.
+func (cs *x509CredentialSource) subjectToken() (string, error) {
+	data, err := ioutil.ReadFile(cs.CertPath)
+	if err != nil {
+		return "", fmt.Errorf("oauth2/google/externalaccount: failed to read certificate: %v", err)
+	}
+	var chain []string
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, base64.StdEncoding.EncodeToString(block.Bytes))
+		}
+	}
+	if len(chain) == 0 {
+		return "", fmt.Errorf("oauth2/google/externalaccount: no certificates found in %q", cs.CertPath)
+	}
+	b, err := json.Marshal(chain)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// mtlsContext returns a context whose HTTP client presents the client
+// certificate, for the token exchange with the security token service.
+// The client is built once per credential source. The certificate is
+// loaded on each TLS handshake, so that rotated certificates are picked
+// up by new connections.
+func (cs *x509CredentialSource) mtlsContext(ctx context.Context) (context.Context, error) {
+	if _, err := cs.loadCertificate(nil); err != nil {
+		return nil, err
+	}
+	cs.clientOnce.Do(func() {
+		cs.mtlsClient = internal.MTLSClient(ctx, cs.loadCertificate)
+	})
+	return context.WithValue(ctx, oauth2.HTTPClient, cs.mtlsClient), nil
+}
+
+func (cs *x509CredentialSource) loadCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(cs.CertPath, cs.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google/externalaccount: failed to load client certificate: %v", err)
+	}
+	return &cert, nil
+}
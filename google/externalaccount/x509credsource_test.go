@@ -0,0 +1,137 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package externalaccount
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// writeTestCertificate writes a self-signed client certificate and its
+// key, and a certificate config file naming them, to dir.
+func writeTestCertificate(t *testing.T, dir string) (configPath string, der []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "workload"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err = x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath = filepath.Join(dir, "certificate_config.json")
+	config := fmt.Sprintf(`{"cert_configs":{"workload":{"cert_path":%q,"key_path":%q}}}`, certPath, keyPath)
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath, der
+}
+
+func TestX509CredentialSource(t *testing.T) {
+	configPath, der := writeTestCertificate(t, t.TempDir())
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) != 1 {
+			t.Errorf("got %d client certificates, want 1", len(r.TLS.PeerCertificates))
+		}
+		var chain []string
+		if err := json.Unmarshal([]byte(r.FormValue("subject_token")), &chain); err != nil {
+			t.Errorf("subject_token is not a JSON array: %v", err)
+		}
+		if want := base64.StdEncoding.EncodeToString(der); len(chain) != 1 || chain[0] != want {
+			t.Errorf("subject_token = %v, want [%v]", chain, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(baseCredsResponseBody))
+	}))
+	var conns int
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns++
+		}
+	}
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	config := Config{
+		Audience:         "32555940559.apps.googleusercontent.com",
+		SubjectTokenType: "urn:ietf:params:oauth:token-type:mtls",
+		TokenURL:         server.URL,
+		CredentialSource: &CredentialSource{
+			Certificate: &CertificateConfig{CertificateConfigLocation: configPath},
+		},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, server.Client())
+	ts := tokenSource{ctx: ctx, conf: &config, source: new(parsedSource)}
+	for i := 0; i < 2; i++ {
+		tok, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Token() failed: %v", err)
+		}
+		if got, want := tok.AccessToken, correctAT; got != want {
+			t.Errorf("AccessToken = %q, want %q", got, want)
+		}
+	}
+	// The mTLS client, and so its connection, is reused by the second
+	// token exchange.
+	if conns != 1 {
+		t.Errorf("got %d connections, want 1", conns)
+	}
+}
+
+func TestX509CredentialSourceConfig(t *testing.T) {
+	for _, conf := range []*CertificateConfig{
+		{},
+		{UseDefaultCertificateConfig: true, CertificateConfigLocation: "cert.json"},
+		{CertificateConfigLocation: filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if _, err := newX509CredentialSource(conf); err == nil {
+			t.Errorf("newX509CredentialSource(%+v) = nil error, want error", conf)
+		}
+	}
+
+	c := Config{CredentialSource: &CredentialSource{Certificate: &CertificateConfig{}}}
+	if got, want := c.tokenURL(), "https://sts.mtls.googleapis.com/v1/token"; got != want {
+		t.Errorf("tokenURL() = %q, want %q", got, want)
+	}
+}
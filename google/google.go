@@ -18,6 +18,7 @@ import (
 	"golang.org/x/oauth2/google/externalaccount"
 	"golang.org/x/oauth2/google/internal/externalaccountauthorizeduser"
 	"golang.org/x/oauth2/google/internal/impersonate"
+	"golang.org/x/oauth2/jws"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -42,29 +43,10 @@ const JWTTokenURL = "https://oauth2.googleapis.com/token"
 // application credentials in the JSON format and provide the contents of the
 // file as jsonKey.
 func ConfigFromJSON(jsonKey []byte, scope ...string) (*oauth2.Config, error) {
-	type cred struct {
-		ClientID     string   `json:"client_id"`
-		ClientSecret string   `json:"client_secret"`
-		RedirectURIs []string `json:"redirect_uris"`
-		AuthURI      string   `json:"auth_uri"`
-		TokenURI     string   `json:"token_uri"`
-	}
-	var j struct {
-		Web       *cred `json:"web"`
-		Installed *cred `json:"installed"`
-	}
-	if err := json.Unmarshal(jsonKey, &j); err != nil {
+	c, err := parseClientJSON(jsonKey)
+	if err != nil {
 		return nil, err
 	}
-	var c *cred
-	switch {
-	case j.Web != nil:
-		c = j.Web
-	case j.Installed != nil:
-		c = j.Installed
-	default:
-		return nil, fmt.Errorf("oauth2/google: no credentials found")
-	}
 	if len(c.RedirectURIs) < 1 {
 		return nil, errors.New("oauth2/google: missing redirect URL in the client_credentials.json")
 	}
@@ -80,10 +62,78 @@ func ConfigFromJSON(jsonKey []byte, scope ...string) (*oauth2.Config, error) {
 	}, nil
 }
 
+// ConfigFromClientJSON is like ConfigFromJSON, but returns a Config that
+// is ready to use with every flow Google supports for the client:
+//
+//   - A redirect URL is optional, as clients used only with the device
+//     flow don't have one.
+//   - Missing endpoint URLs default to those of Endpoint, and
+//     Endpoint.DeviceAuthURL and Endpoint.AuthStyle are always set.
+//
+// The client ID is required, and endpoint URLs must be absolute.
+func ConfigFromClientJSON(jsonKey []byte, scope ...string) (*oauth2.Config, error) {
+	c, err := parseClientJSON(jsonKey)
+	if err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		return nil, errors.New("oauth2/google: missing client_id in the client_credentials.json")
+	}
+	conf := &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       scope,
+		Endpoint:     Endpoint,
+	}
+	if len(c.RedirectURIs) > 0 {
+		conf.RedirectURL = c.RedirectURIs[0]
+	}
+	if c.AuthURI != "" {
+		conf.Endpoint.AuthURL = c.AuthURI
+	}
+	if c.TokenURI != "" {
+		conf.Endpoint.TokenURL = c.TokenURI
+	}
+	for _, u := range []string{conf.Endpoint.AuthURL, conf.Endpoint.TokenURL} {
+		if pu, err := url.Parse(u); err != nil || !pu.IsAbs() {
+			return nil, fmt.Errorf("oauth2/google: invalid endpoint URL %q in the client_credentials.json", u)
+		}
+	}
+	return conf, nil
+}
+
+type clientJSON struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURIs []string `json:"redirect_uris"`
+	AuthURI      string   `json:"auth_uri"`
+	TokenURI     string   `json:"token_uri"`
+}
+
+// parseClientJSON returns the "web" or "installed" client in jsonKey.
+func parseClientJSON(jsonKey []byte) (*clientJSON, error) {
+	var j struct {
+		Web       *clientJSON `json:"web"`
+		Installed *clientJSON `json:"installed"`
+	}
+	if err := json.Unmarshal(jsonKey, &j); err != nil {
+		return nil, err
+	}
+	switch {
+	case j.Web != nil:
+		return j.Web, nil
+	case j.Installed != nil:
+		return j.Installed, nil
+	}
+	return nil, fmt.Errorf("oauth2/google: no credentials found")
+}
+
 // JWTConfigFromJSON uses a Google Developers service account JSON key file to read
 // the credentials that authorize and authenticate the requests.
 // Create a service account on "Credentials" for your project at
 // https://console.developers.google.com to download a JSON key file.
+// To use self-signed JWTs as access tokens instead, see
+// JWTAccessTokenSourceWithScope or CredentialsParams.UseSelfSignedJWT.
 func JWTConfigFromJSON(jsonKey []byte, scope ...string) (*jwt.Config, error) {
 	var f credentialsFile
 	if err := json.Unmarshal(jsonKey, &f); err != nil {
@@ -167,6 +217,9 @@ func (f *credentialsFile) tokenSource(ctx context.Context, params CredentialsPar
 	switch f.Type {
 	case serviceAccountKey:
 		cfg := f.jwtConfig(params.Scopes, params.Subject)
+		if params.UseSelfSignedJWT && len(params.Scopes) > 0 && params.Subject == "" {
+			return newJWTAccessTokenSource(cfg, "", params.Scopes)
+		}
 		return cfg.TokenSource(ctx), nil
 	case userCredentialsKey:
 		cfg := &oauth2.Config{
@@ -205,6 +258,10 @@ func (f *credentialsFile) tokenSource(ctx context.Context, params CredentialsPar
 			QuotaProjectID:           f.QuotaProjectID,
 			Scopes:                   params.Scopes,
 			WorkforcePoolUserProject: f.WorkforcePoolUserProject,
+			UniverseDomain:           f.UniverseDomain,
+		}
+		if params.UniverseDomain != "" {
+			cfg.UniverseDomain = params.UniverseDomain
 		}
 		return externalaccount.NewTokenSource(ctx, *cfg)
 	case externalAccountAuthorizedUserKey:
@@ -218,6 +275,12 @@ func (f *credentialsFile) tokenSource(ctx context.Context, params CredentialsPar
 			RevokeURL:      f.RevokeURL,
 			QuotaProjectID: f.QuotaProjectID,
 			Scopes:         params.Scopes,
+			UniverseDomain: f.UniverseDomain,
+
+			ClientCertificateSource: params.ClientCertificateSource,
+		}
+		if params.UniverseDomain != "" {
+			cfg.UniverseDomain = params.UniverseDomain
 		}
 		return cfg.TokenSource(ctx)
 	case impersonatedServiceAccount:
@@ -225,16 +288,21 @@ func (f *credentialsFile) tokenSource(ctx context.Context, params CredentialsPar
 			return nil, errors.New("missing 'source_credentials' field or 'service_account_impersonation_url' in credentials")
 		}
 
-		ts, err := f.SourceCredentials.tokenSource(ctx, params)
+		// The source credentials only need to call the IAM credentials
+		// API; the requested scopes apply to the impersonated account.
+		sourceParams := params.deepCopy()
+		sourceParams.Scopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+		ts, err := f.SourceCredentials.tokenSource(ctx, sourceParams)
 		if err != nil {
 			return nil, err
 		}
 		imp := impersonate.ImpersonateTokenSource{
-			Ctx:       ctx,
-			URL:       f.ServiceAccountImpersonationURL,
-			Scopes:    params.Scopes,
-			Ts:        ts,
-			Delegates: f.Delegates,
+			Ctx:                  ctx,
+			URL:                  f.ServiceAccountImpersonationURL,
+			Scopes:               params.Scopes,
+			Ts:                   ts,
+			Delegates:            f.Delegates,
+			TokenLifetimeSeconds: f.ServiceAccountImpersonation.TokenLifetimeSeconds,
 		}
 		return oauth2.ReuseTokenSource(nil, imp), nil
 	case "":
@@ -258,13 +326,53 @@ func ComputeTokenSource(account string, scope ...string) oauth2.TokenSource {
 	return computeTokenSource(account, earlyExpirySecs, scope...)
 }
 
+// ComputeTokenSourceOptions configures ComputeTokenSourceWithOptions.
+type ComputeTokenSourceOptions struct {
+	// Account is the service account to use. If empty, "default" is used.
+	Account string
+
+	// Scopes optionally specifies the scopes to request. Older
+	// metadata servers ignore them and return tokens with the
+	// instance's scopes.
+	Scopes []string
+
+	// IDTokenAudience, if set, makes the token source return identity
+	// tokens for this audience from the metadata server's identity
+	// endpoint instead of access tokens. Scopes are ignored.
+	IDTokenAudience string
+
+	// EarlyExpiry is how long before a token expires it is refreshed.
+	// If zero, the same value as ComputeTokenSource is used.
+	EarlyExpiry time.Duration
+}
+
+// ComputeTokenSourceWithOptions is like ComputeTokenSource, but
+// additionally supports identity tokens.
+//
+// A token source cannot set request headers, so to send a quota project
+// in the X-Goog-User-Project header, use the client of Credentials with
+// the returned token source and QuotaProjectID set.
+func ComputeTokenSourceWithOptions(opts ComputeTokenSourceOptions) oauth2.TokenSource {
+	earlyExpiry := opts.EarlyExpiry
+	if earlyExpiry == 0 {
+		earlyExpiry = 225 * time.Second
+	}
+	cs := computeSource{
+		account:         opts.Account,
+		scopes:          opts.Scopes,
+		idTokenAudience: opts.IDTokenAudience,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, cs, earlyExpiry)
+}
+
 func computeTokenSource(account string, earlyExpiry time.Duration, scope ...string) oauth2.TokenSource {
 	return oauth2.ReuseTokenSourceWithExpiry(nil, computeSource{account: account, scopes: scope}, earlyExpiry)
 }
 
 type computeSource struct {
-	account string
-	scopes  []string
+	account         string
+	scopes          []string
+	idTokenAudience string
 }
 
 func (cs computeSource) Token() (*oauth2.Token, error) {
@@ -275,6 +383,27 @@ func (cs computeSource) Token() (*oauth2.Token, error) {
 	if acct == "" {
 		acct = "default"
 	}
+	var tok *oauth2.Token
+	var err error
+	if cs.idTokenAudience != "" {
+		tok, err = cs.idToken(acct)
+	} else {
+		tok, err = cs.accessToken(acct)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// NOTE(cbro): add hidden metadata about where the token is from.
+	// This is needed for detection by client libraries to know that credentials come from the metadata server.
+	// This may be removed in a future version of this library.
+	extra := map[string]interface{}{
+		"oauth2.google.tokenSource":    "compute-metadata",
+		"oauth2.google.serviceAccount": acct,
+	}
+	return tok.WithExtra(extra), nil
+}
+
+func (cs computeSource) accessToken(acct string) (*oauth2.Token, error) {
 	tokenURI := "instance/service-accounts/" + acct + "/token"
 	if len(cs.scopes) > 0 {
 		v := url.Values{}
@@ -297,16 +426,32 @@ func (cs computeSource) Token() (*oauth2.Token, error) {
 	if res.ExpiresInSec == 0 || res.AccessToken == "" {
 		return nil, fmt.Errorf("oauth2/google: incomplete token received from metadata")
 	}
-	tok := &oauth2.Token{
+	return &oauth2.Token{
 		AccessToken: res.AccessToken,
 		TokenType:   res.TokenType,
 		Expiry:      time.Now().Add(time.Duration(res.ExpiresInSec) * time.Second),
+	}, nil
+}
+
+func (cs computeSource) idToken(acct string) (*oauth2.Token, error) {
+	v := url.Values{}
+	v.Set("audience", cs.idTokenAudience)
+	v.Set("format", "full")
+	idToken, err := metadata.Get("instance/service-accounts/" + acct + "/identity?" + v.Encode())
+	if err != nil {
+		return nil, err
 	}
-	// NOTE(cbro): add hidden metadata about where the token is from.
-	// This is needed for detection by client libraries to know that credentials come from the metadata server.
-	// This may be removed in a future version of this library.
-	return tok.WithExtra(map[string]interface{}{
-		"oauth2.google.tokenSource":    "compute-metadata",
-		"oauth2.google.serviceAccount": acct,
-	}), nil
+	idToken = strings.TrimSpace(idToken)
+	claims, err := jws.Decode(idToken)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google: invalid identity token from metadata: %v", err)
+	}
+	if claims.Exp == 0 {
+		return nil, fmt.Errorf("oauth2/google: identity token from metadata has no expiry")
+	}
+	return &oauth2.Token{
+		AccessToken: idToken,
+		TokenType:   "Bearer",
+		Expiry:      time.Unix(claims.Exp, 0),
+	}, nil
 }
@@ -5,10 +5,13 @@
 package google
 
 import (
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 var webJSONKey = []byte(`
@@ -95,6 +98,40 @@ func TestConfigFromJSON_Installed(t *testing.T) {
 	}
 }
 
+func TestConfigFromClientJSON(t *testing.T) {
+	conf, err := ConfigFromClientJSON([]byte(`{"installed":{"client_id":"tv.apps.googleusercontent.com","client_secret":"s"}}`), "scope1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := conf.RedirectURL, ""; got != want {
+		t.Errorf("RedirectURL = %q; want %q", got, want)
+	}
+	if got, want := conf.Endpoint, Endpoint; got != want {
+		t.Errorf("Endpoint = %+v; want %+v", got, want)
+	}
+
+	conf, err = ConfigFromClientJSON(webJSONKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := conf.Endpoint.TokenURL, "https://google.com/o/oauth2/token"; got != want {
+		t.Errorf("TokenURL = %q; want %q", got, want)
+	}
+	if got, want := conf.Endpoint.DeviceAuthURL, Endpoint.DeviceAuthURL; got != want {
+		t.Errorf("DeviceAuthURL = %q; want %q", got, want)
+	}
+
+	for _, bad := range []string{
+		`{"web":{"client_secret":"s"}}`,
+		`{"web":{"client_id":"c","token_uri":"/relative"}}`,
+		`{"other":{}}`,
+	} {
+		if _, err := ConfigFromClientJSON([]byte(bad)); err == nil {
+			t.Errorf("ConfigFromClientJSON(%s) succeeded; want error", bad)
+		}
+	}
+}
+
 func TestJWTConfigFromJSON(t *testing.T) {
 	conf, err := JWTConfigFromJSON(jwtJSONKey, "scope1", "scope2")
 	if err != nil {
@@ -157,3 +194,33 @@ func TestComputeTokenSource(t *testing.T) {
 		t.Errorf("ts.Token() = %v", err)
 	}
 }
+
+func TestComputeTokenSourceWithOptions_IDToken(t *testing.T) {
+	identityPath := "/computeMetadata/v1/instance/service-accounts/default/identity"
+	exp := time.Now().Add(time.Hour).Unix()
+	idToken := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"aud":"https://example.com","exp":%d}`, exp))) + ".sig"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != identityPath {
+			t.Errorf("got %s, want %s", r.URL.Path, identityPath)
+		}
+		if got, want := r.URL.Query().Get("audience"), "https://example.com"; got != want {
+			t.Errorf("audience = %q, want %q", got, want)
+		}
+		w.Write([]byte(idToken))
+	}))
+	defer s.Close()
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(s.URL, "http://"))
+	ts := ComputeTokenSourceWithOptions(ComputeTokenSourceOptions{
+		IDTokenAudience: "https://example.com",
+	})
+	tok, err := ts.Token()
+	if err != nil {
+		t.Fatalf("ts.Token() = %v", err)
+	}
+	if tok.AccessToken != idToken {
+		t.Errorf("AccessToken = %q, want %q", tok.AccessToken, idToken)
+	}
+	if got := tok.Expiry.Unix(); got != exp {
+		t.Errorf("Expiry = %d, want %d", got, exp)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package impersonate uses the IAM Service Account Credentials API to act
+// as a Google service account: minting identity tokens for it and signing
+// blobs and JWTs with its system-managed keys.
+//
+// The caller's credentials, supplied as an oauth2.TokenSource, must be
+// granted roles/iam.serviceAccountTokenCreator on the target service
+// account, or on the first service account of the delegation chain.
+package impersonate // import "golang.org/x/oauth2/google/impersonate"
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+	internalimpersonate "golang.org/x/oauth2/google/internal/impersonate"
+	"golang.org/x/oauth2/jws"
+)
+
+// IDTokenConfig configures IDTokenSource.
+type IDTokenConfig struct {
+	// TargetPrincipal is the email address of the service account to
+	// impersonate. Required.
+	TargetPrincipal string
+	// Audience is the "aud" claim of the identity tokens. Required.
+	Audience string
+	// IncludeEmail adds the "email" and "email_verified" claims to the
+	// identity tokens. Optional.
+	IncludeEmail bool
+	// Delegates are the service account email addresses in a delegation
+	// chain. Each service account must be granted
+	// roles/iam.serviceAccountTokenCreator on the next service account
+	// in the chain. Optional.
+	Delegates []string
+	// UniverseDomain is the default service domain for a given Cloud
+	// universe. The default value is "googleapis.com". Optional.
+	UniverseDomain string
+	// DisableCaching makes every call to Token request a new identity
+	// token. By default, each TokenSource returned by IDTokenSource
+	// caches its own token until it expires. Optional.
+	DisableCaching bool
+}
+
+// IDTokenSource returns a TokenSource of identity tokens for
+// config.TargetPrincipal, as used to call services behind
+// Identity-Aware Proxy or Cloud Run. Tokens are obtained with ts.
+//
+// The returned TokenSource is safe for concurrent use. Tokens are cached
+// per TokenSource, never shared between TokenSources.
+func IDTokenSource(ctx context.Context, config IDTokenConfig, ts oauth2.TokenSource) (oauth2.TokenSource, error) {
+	if config.TargetPrincipal == "" {
+		return nil, errors.New("impersonate: TargetPrincipal must be set")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("impersonate: Audience must be set")
+	}
+	if ts == nil {
+		return nil, errors.New("impersonate: source TokenSource must be set")
+	}
+	src := idTokenSource{ctx: ctx, conf: config, ts: ts}
+	if config.DisableCaching {
+		return src, nil
+	}
+	return oauth2.ReuseTokenSource(nil, src), nil
+}
+
+type idTokenSource struct {
+	ctx  context.Context
+	conf IDTokenConfig
+	ts   oauth2.TokenSource
+}
+
+func (s idTokenSource) Token() (*oauth2.Token, error) {
+	req := struct {
+		Audience     string   `json:"audience"`
+		IncludeEmail bool     `json:"includeEmail"`
+		Delegates    []string `json:"delegates,omitempty"`
+	}{
+		Audience:     s.conf.Audience,
+		IncludeEmail: s.conf.IncludeEmail,
+		Delegates:    formatDelegates(s.conf.Delegates),
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := call(s.ctx, s.ts, s.conf.UniverseDomain, s.conf.TargetPrincipal, "generateIdToken", req, &resp); err != nil {
+		return nil, err
+	}
+	claims, err := jws.Decode(resp.Token)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate: unable to parse identity token: %v", err)
+	}
+	return &oauth2.Token{
+		AccessToken: resp.Token,
+		TokenType:   "Bearer",
+		Expiry:      time.Unix(claims.Exp, 0),
+	}, nil
+}
+
+// Signer signs data as a service account using its system-managed keys.
+type Signer struct {
+	// UniverseDomain is the default service domain for a given Cloud
+	// universe. The default value is "googleapis.com". It must be set,
+	// if at all, before the Signer is first used.
+	UniverseDomain string
+
+	ts              oauth2.TokenSource
+	targetPrincipal string
+	delegates       []string
+}
+
+// NewSigner returns a Signer acting as targetPrincipal through the
+// optional delegation chain delegates. Requests are authorized with ts.
+func NewSigner(targetPrincipal string, delegates []string, ts oauth2.TokenSource) (*Signer, error) {
+	if targetPrincipal == "" {
+		return nil, errors.New("impersonate: target principal must be set")
+	}
+	if ts == nil {
+		return nil, errors.New("impersonate: source TokenSource must be set")
+	}
+	return &Signer{
+		ts:              ts,
+		targetPrincipal: targetPrincipal,
+		delegates:       formatDelegates(delegates),
+	}, nil
+}
+
+// SignBlob signs payload with one of the service account's keys and
+// returns the ID of the key used and the signature. ctx is used for the
+// HTTP request and is passed to ts.
+func (s *Signer) SignBlob(ctx context.Context, payload []byte) (keyID string, signature []byte, err error) {
+	req := struct {
+		Payload   string   `json:"payload"`
+		Delegates []string `json:"delegates,omitempty"`
+	}{
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Delegates: s.delegates,
+	}
+	var resp struct {
+		KeyID      string `json:"keyId"`
+		SignedBlob string `json:"signedBlob"`
+	}
+	if err := call(ctx, s.ts, s.UniverseDomain, s.targetPrincipal, "signBlob", req, &resp); err != nil {
+		return "", nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.SignedBlob)
+	if err != nil {
+		return "", nil, fmt.Errorf("impersonate: unable to decode signature: %v", err)
+	}
+	return resp.KeyID, sig, nil
+}
+
+// SignJWT signs the JSON-encoded JWT claim set claims with one of the
+// service account's keys and returns the ID of the key used and the
+// signed JWT. ctx is used for the HTTP request and is passed to ts.
+func (s *Signer) SignJWT(ctx context.Context, claims []byte) (keyID, jwt string, err error) {
+	req := struct {
+		Payload   string   `json:"payload"`
+		Delegates []string `json:"delegates,omitempty"`
+	}{
+		Payload:   string(claims),
+		Delegates: s.delegates,
+	}
+	var resp struct {
+		KeyID     string `json:"keyId"`
+		SignedJWT string `json:"signedJwt"`
+	}
+	if err := call(ctx, s.ts, s.UniverseDomain, s.targetPrincipal, "signJwt", req, &resp); err != nil {
+		return "", "", err
+	}
+	return resp.KeyID, resp.SignedJWT, nil
+}
+
+func formatDelegates(delegates []string) []string {
+	var out []string
+	for _, d := range delegates {
+		out = append(out, "projects/-/serviceAccounts/"+d)
+	}
+	return out
+}
+
+// call invokes method on the service account principal and decodes the
+// JSON response into resp.
+func call(ctx context.Context, ts oauth2.TokenSource, universeDomain, principal, method string, reqBody, resp interface{}) error {
+	url := internalimpersonate.CredentialsURL(universeDomain) + "projects/-/serviceAccounts/" + principal + ":" + method
+	if err := internalimpersonate.Call(ctx, ts, url, reqBody, resp); err != nil {
+		return fmt.Errorf("impersonate: %s: %v", method, err)
+	}
+	return nil
+}
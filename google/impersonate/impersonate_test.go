@@ -0,0 +1,150 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impersonate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	internalimpersonate "golang.org/x/oauth2/google/internal/impersonate"
+)
+
+func newTestServer(t *testing.T, wantPath string, check func(req map[string]interface{}), response string) func() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != wantPath {
+			t.Errorf("path = %q, want %q", r.URL.Path, wantPath)
+		}
+		if got, want := r.Header.Get("Authorization"), "Bearer source-token"; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		check(req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	old := internalimpersonate.IAMCredentialsURL
+	internalimpersonate.IAMCredentialsURL = ts.URL + "/v1/"
+	return func() {
+		internalimpersonate.IAMCredentialsURL = old
+		ts.Close()
+	}
+}
+
+var sourceTS = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "source-token"})
+
+func TestIDTokenSource(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	idToken := "e30." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp))) + ".sig"
+	defer newTestServer(t, "/v1/projects/-/serviceAccounts/sa@example.com:generateIdToken", func(req map[string]interface{}) {
+		if req["audience"] != "https://service" || req["includeEmail"] != true {
+			t.Errorf("request = %v", req)
+		}
+		if got, want := req["delegates"], []interface{}{"projects/-/serviceAccounts/d@example.com"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("delegates = %v, want %v", got, want)
+		}
+	}, fmt.Sprintf(`{"token":%q}`, idToken))()
+
+	ts, err := IDTokenSource(context.Background(), IDTokenConfig{
+		TargetPrincipal: "sa@example.com",
+		Audience:        "https://service",
+		IncludeEmail:    true,
+		Delegates:       []string{"d@example.com"},
+	}, sourceTS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := ts.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != idToken || tok.Expiry.Unix() != exp {
+		t.Errorf("Token() = %q expiring %v, want %q expiring %v", tok.AccessToken, tok.Expiry.Unix(), idToken, exp)
+	}
+}
+
+func TestIDTokenSourceCaching(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	idToken := "e30." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp))) + ".sig"
+	var calls int
+	defer newTestServer(t, "/v1/projects/-/serviceAccounts/sa@example.com:generateIdToken", func(req map[string]interface{}) {
+		calls++
+	}, fmt.Sprintf(`{"token":%q}`, idToken))()
+
+	for _, tt := range []struct {
+		disable   bool
+		wantCalls int
+	}{
+		{false, 2}, // one per TokenSource
+		{true, 4},  // one per Token call
+	} {
+		calls = 0
+		conf := IDTokenConfig{TargetPrincipal: "sa@example.com", Audience: "aud", DisableCaching: tt.disable}
+		for i := 0; i < 2; i++ {
+			ts, err := IDTokenSource(context.Background(), conf, sourceTS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := 0; j < 2; j++ {
+				if _, err := ts.Token(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("DisableCaching = %v: %d requests, want %d", tt.disable, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestSignBlob(t *testing.T) {
+	defer newTestServer(t, "/v1/projects/-/serviceAccounts/sa@example.com:signBlob", func(req map[string]interface{}) {
+		if got, want := req["payload"], base64.StdEncoding.EncodeToString([]byte("data")); got != want {
+			t.Errorf("payload = %v, want %v", got, want)
+		}
+	}, `{"keyId":"k1","signedBlob":"c2ln"}`)()
+
+	s, err := NewSigner("sa@example.com", nil, sourceTS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, sig, err := s.SignBlob(context.Background(), []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "k1" || string(sig) != "sig" {
+		t.Errorf("SignBlob() = %q, %q, want %q, %q", keyID, sig, "k1", "sig")
+	}
+}
+
+func TestSignJWT(t *testing.T) {
+	defer newTestServer(t, "/v1/projects/-/serviceAccounts/sa@example.com:signJwt", func(req map[string]interface{}) {
+		if got, want := req["payload"], `{"sub":"x"}`; got != want {
+			t.Errorf("payload = %v, want %v", got, want)
+		}
+	}, `{"keyId":"k1","signedJwt":"a.b.c"}`)()
+
+	s, err := NewSigner("sa@example.com", nil, sourceTS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, jwt, err := s.SignJWT(context.Background(), []byte(`{"sub":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "k1" || jwt != "a.b.c" {
+		t.Errorf("SignJWT() = %q, %q, want %q, %q", keyID, jwt, "k1", "a.b.c")
+	}
+}
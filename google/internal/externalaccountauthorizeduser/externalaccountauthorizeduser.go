@@ -6,11 +6,16 @@ package externalaccountauthorizeduser
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google/internal/stsexchange"
+	"golang.org/x/oauth2/internal"
 )
 
 // now aliases time.Now for testing.
@@ -49,8 +54,19 @@ type Config struct {
 	// project used to create the credentials.
 	QuotaProjectID string
 	Scopes         []string
+	// UniverseDomain is the default service domain for a given Cloud
+	// universe. The default value is "googleapis.com". Optional.
+	UniverseDomain string
+	// ClientCertificateSource is the optional source of the client
+	// certificate presented to the STS when refreshing certificate-bound
+	// tokens. When it is set, the refresh uses mutual TLS and a TokenURL
+	// on the STS of UniverseDomain (sts.UNIVERSE_DOMAIN) is rewritten to
+	// its mTLS variant (sts.mtls.UNIVERSE_DOMAIN).
+	ClientCertificateSource func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
 }
 
+const defaultUniverseDomain = "googleapis.com"
+
 func (c *Config) canRefresh() bool {
 	return c.ClientID != "" && c.ClientSecret != "" && c.RefreshToken != "" && c.TokenURL != ""
 }
@@ -72,6 +88,9 @@ func (c *Config) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
 		ctx:  ctx,
 		conf: c,
 	}
+	if c.ClientCertificateSource != nil {
+		ts.mtlsClient = internal.MTLSClient(ctx, c.ClientCertificateSource)
+	}
 
 	return oauth2.ReuseTokenSource(&token, ts), nil
 }
@@ -79,6 +98,9 @@ func (c *Config) TokenSource(ctx context.Context) (oauth2.TokenSource, error) {
 type tokenSource struct {
 	ctx  context.Context
 	conf *Config
+	// mtlsClient is the HTTP client presenting the client certificate,
+	// if conf.ClientCertificateSource is set.
+	mtlsClient *http.Client
 }
 
 func (ts tokenSource) Token() (*oauth2.Token, error) {
@@ -93,7 +115,16 @@ func (ts tokenSource) Token() (*oauth2.Token, error) {
 		ClientSecret: conf.ClientSecret,
 	}
 
-	stsResponse, err := stsexchange.RefreshAccessToken(ts.ctx, conf.TokenURL, conf.RefreshToken, clientAuth, nil)
+	ctx, tokenURL := ts.ctx, conf.TokenURL
+	if ts.mtlsClient != nil {
+		var err error
+		if tokenURL, err = mtlsTokenURL(tokenURL, conf.UniverseDomain); err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, ts.mtlsClient)
+	}
+
+	stsResponse, err := stsexchange.RefreshAccessToken(ctx, tokenURL, conf.RefreshToken, clientAuth, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -112,3 +143,20 @@ func (ts tokenSource) Token() (*oauth2.Token, error) {
 	}
 	return token, nil
 }
+
+// mtlsTokenURL returns the mTLS variant of the STS endpoint of
+// universeDomain, such as https://sts.mtls.googleapis.com for
+// https://sts.googleapis.com. Other endpoints are returned unchanged.
+func mtlsTokenURL(tokenURL, universeDomain string) (string, error) {
+	u, err := url.Parse(tokenURL)
+	if err != nil {
+		return "", errors.New("oauth2/google: invalid token_url: " + err.Error())
+	}
+	if universeDomain == "" {
+		universeDomain = defaultUniverseDomain
+	}
+	if u.Hostname() == "sts."+universeDomain {
+		u.Host = strings.Replace(u.Host, "sts.", "sts.mtls.", 1)
+	}
+	return u.String(), nil
+}
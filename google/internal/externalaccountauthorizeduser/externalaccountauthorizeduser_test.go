@@ -6,9 +6,15 @@ package externalaccountauthorizeduser
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -257,3 +263,65 @@ func (trts *testRefreshTokenServer) close(t *testing.T) error {
 	trts.server = nil
 	return nil
 }
+
+func TestExernalAccountAuthorizedUser_MTLSRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) != 1 {
+			t.Errorf("got %d client certificates, want 1", len(r.TLS.PeerCertificates))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"AAAAAAA","expires_in":3600}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	config := &Config{
+		RefreshToken: "BBBBBBBBB",
+		TokenURL:     server.URL,
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		ClientCertificateSource: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, server.Client())
+	ts, err := config.TokenSource(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := ts.Token()
+	if err != nil {
+		t.Fatalf("Error retrieving Token: %v", err)
+	}
+	if got, want := token.AccessToken, "AAAAAAA"; got != want {
+		t.Errorf("Unexpected access token, got %v, want %v", got, want)
+	}
+}
+
+func TestMTLSTokenURL(t *testing.T) {
+	for _, tt := range []struct{ in, universeDomain, want string }{
+		{"https://sts.googleapis.com/v1/oauthtoken", "", "https://sts.mtls.googleapis.com/v1/oauthtoken"},
+		{"https://sts.mtls.googleapis.com/v1/oauthtoken", "", "https://sts.mtls.googleapis.com/v1/oauthtoken"},
+		{"https://sts.example.com/v1/oauthtoken", "example.com", "https://sts.mtls.example.com/v1/oauthtoken"},
+		{"https://sts.example.com/token", "", "https://sts.example.com/token"},
+		{"https://sts.googleapis.com.example.com/token", "", "https://sts.googleapis.com.example.com/token"},
+		{"https://example.com/token", "", "https://example.com/token"},
+	} {
+		got, err := mtlsTokenURL(tt.in, tt.universeDomain)
+		if err != nil || got != tt.want {
+			t.Errorf("mtlsTokenURL(%q, %q) = %q, %v, want %q", tt.in, tt.universeDomain, got, err, tt.want)
+		}
+	}
+}
@@ -12,11 +12,63 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
 )
 
+// IAMCredentialsURL is the base URL of the IAM Service Account
+// Credentials API, with UNIVERSE_DOMAIN standing for the universe domain.
+// It is a variable for tests.
+var IAMCredentialsURL = "https://iamcredentials.UNIVERSE_DOMAIN/v1/"
+
+const (
+	universeDomainPlaceholder = "UNIVERSE_DOMAIN"
+	defaultUniverseDomain     = "googleapis.com"
+)
+
+// CredentialsURL returns the base URL of the IAM Service Account
+// Credentials API in universeDomain, which defaults to "googleapis.com".
+func CredentialsURL(universeDomain string) string {
+	if universeDomain == "" {
+		universeDomain = defaultUniverseDomain
+	}
+	return strings.Replace(IAMCredentialsURL, universeDomainPlaceholder, universeDomain, 1)
+}
+
+// Call POSTs the JSON encoding of reqBody to url, authorized with ts,
+// and decodes the JSON response into resp.
+func Call(ctx context.Context, ts oauth2.TokenSource, url string, reqBody, resp interface{}) error {
+	b, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("unable to marshal request: %v", err)
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("unable to create impersonation request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := oauth2.NewClient(ctx, ts).Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send impersonation request: %v", err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("unable to read body: %v", err)
+	}
+	if c := res.StatusCode; c < 200 || c > 299 {
+		return fmt.Errorf("status code %d: %s", c, body)
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("unable to parse response: %v", err)
+	}
+	return nil
+}
+
 // generateAccesstokenReq is used for service account impersonation
 type generateAccessTokenReq struct {
 	Delegates []string `json:"delegates,omitempty"`
@@ -64,34 +116,9 @@ func (its ImpersonateTokenSource) Token() (*oauth2.Token, error) {
 		Scope:     its.Scopes,
 		Delegates: its.Delegates,
 	}
-	b, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("oauth2/google: unable to marshal request: %v", err)
-	}
-	client := oauth2.NewClient(its.Ctx, its.Ts)
-	req, err := http.NewRequest("POST", its.URL, bytes.NewReader(b))
-	if err != nil {
-		return nil, fmt.Errorf("oauth2/google: unable to create impersonation request: %v", err)
-	}
-	req = req.WithContext(its.Ctx)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("oauth2/google: unable to generate access token: %v", err)
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
-	if err != nil {
-		return nil, fmt.Errorf("oauth2/google: unable to read body: %v", err)
-	}
-	if c := resp.StatusCode; c < 200 || c > 299 {
-		return nil, fmt.Errorf("oauth2/google: status code %d: %s", c, body)
-	}
-
 	var accessTokenResp impersonateTokenResponse
-	if err := json.Unmarshal(body, &accessTokenResp); err != nil {
-		return nil, fmt.Errorf("oauth2/google: unable to parse response: %v", err)
+	if err := Call(its.Ctx, its.Ts, its.URL, reqBody, &accessTokenResp); err != nil {
+		return nil, fmt.Errorf("oauth2/google: %v", err)
 	}
 	expiry, err := time.Parse(time.RFC3339, accessTokenResp.ExpireTime)
 	if err != nil {
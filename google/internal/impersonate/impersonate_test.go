@@ -0,0 +1,20 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package impersonate
+
+import "testing"
+
+func TestCredentialsURL(t *testing.T) {
+	for _, tt := range []struct {
+		universeDomain, want string
+	}{
+		{"", "https://iamcredentials.googleapis.com/v1/"},
+		{"example.com", "https://iamcredentials.example.com/v1/"},
+	} {
+		if got := CredentialsURL(tt.universeDomain); got != tt.want {
+			t.Errorf("CredentialsURL(%q) = %q, want %q", tt.universeDomain, got, tt.want)
+		}
+	}
+}
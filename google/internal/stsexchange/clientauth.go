@@ -31,7 +31,7 @@ func (c *ClientAuthentication) InjectAuthentication(values url.Values, headers h
 	switch c.AuthStyle {
 	case oauth2.AuthStyleInHeader: // AuthStyleInHeader corresponds to basic authentication as defined in rfc7617#2
 		plainHeader := c.ClientID + ":" + c.ClientSecret
-		headers.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(plainHeader)))
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(plainHeader)))
 	case oauth2.AuthStyleInParams: // AuthStyleInParams corresponds to request-body authentication with ClientID and ClientSecret in the message body.
 		values.Set("client_id", c.ClientID)
 		values.Set("client_secret", c.ClientSecret)
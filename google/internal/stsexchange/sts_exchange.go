@@ -55,7 +55,40 @@ func RefreshAccessToken(ctx context.Context, endpoint string, refreshToken strin
 	return makeRequest(ctx, endpoint, data, authentication, headers)
 }
 
+// IntrospectToken asks the token_info endpoint whether token is active,
+// as defined in RFC 7662.
+func IntrospectToken(ctx context.Context, endpoint string, token string, authentication ClientAuthentication, headers http.Header) (*IntrospectionResponse, error) {
+	data := url.Values{}
+	data.Set("token", token)
+	data.Set("token_type_hint", "access_token")
+
+	body, err := doRequest(ctx, endpoint, data, authentication, headers)
+	if err != nil {
+		return nil, err
+	}
+	var resp IntrospectionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("oauth2/google: failed to unmarshal response body from token_info endpoint: %v", err)
+	}
+	return &resp, nil
+}
+
 func makeRequest(ctx context.Context, endpoint string, data url.Values, authentication ClientAuthentication, headers http.Header) (*Response, error) {
+	body, err := doRequest(ctx, endpoint, data, authentication, headers)
+	if err != nil {
+		return nil, err
+	}
+	var stsResp Response
+	err = json.Unmarshal(body, &stsResp)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google: failed to unmarshal response body from Secure Token Server: %v", err)
+
+	}
+
+	return &stsResp, nil
+}
+
+func doRequest(ctx context.Context, endpoint string, data url.Values, authentication ClientAuthentication, headers http.Header) ([]byte, error) {
 	if headers == nil {
 		headers = defaultHeader()
 	}
@@ -89,14 +122,7 @@ func makeRequest(ctx context.Context, endpoint string, data url.Values, authenti
 	if c := resp.StatusCode; c < 200 || c > 299 {
 		return nil, fmt.Errorf("oauth2/google: status code %d: %s", c, body)
 	}
-	var stsResp Response
-	err = json.Unmarshal(body, &stsResp)
-	if err != nil {
-		return nil, fmt.Errorf("oauth2/google: failed to unmarshal response body from Secure Token Server: %v", err)
-
-	}
-
-	return &stsResp, nil
+	return body, nil
 }
 
 // TokenExchangeRequest contains fields necessary to make an oauth2 token exchange.
@@ -123,3 +149,12 @@ type Response struct {
 	Scope           string `json:"scope"`
 	RefreshToken    string `json:"refresh_token"`
 }
+
+// IntrospectionResponse is used to decode the token_info endpoint response.
+type IntrospectionResponse struct {
+	Active   bool   `json:"active"`
+	ClientID string `json:"client_id"`
+	Username string `json:"username"`
+	Scope    string `json:"scope"`
+	Exp      int64  `json:"exp"`
+}
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/internal"
 	"golang.org/x/oauth2/jws"
+	"golang.org/x/oauth2/jwt"
 )
 
 // JWTAccessTokenSourceFromJSON uses a Google Developers service account JSON
@@ -51,6 +52,16 @@ func newJWTSource(jsonKey []byte, audience string, scopes []string) (oauth2.Toke
 	if err != nil {
 		return nil, fmt.Errorf("google: could not parse JSON key: %v", err)
 	}
+	ts, err := newJWTAccessTokenSource(cfg, audience, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return newErrWrappingTokenSource(ts), nil
+}
+
+// newJWTAccessTokenSource returns a TokenSource of self-signed JWTs for the
+// service account described by cfg.
+func newJWTAccessTokenSource(cfg *jwt.Config, audience string, scopes []string) (oauth2.TokenSource, error) {
 	pk, err := internal.ParseKey(cfg.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("google: could not parse key: %v", err)
@@ -66,8 +77,7 @@ func newJWTSource(jsonKey []byte, audience string, scopes []string) (oauth2.Toke
 	if err != nil {
 		return nil, err
 	}
-	rts := newErrWrappingTokenSource(oauth2.ReuseTokenSource(tok, ts))
-	return rts, nil
+	return oauth2.ReuseTokenSource(tok, ts), nil
 }
 
 type jwtAccessTokenSource struct {
@@ -6,17 +6,21 @@ package google
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
+	"net/http"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jws"
 )
 
@@ -138,6 +142,41 @@ func TestJWTAccessTokenSourceWithScope(t *testing.T) {
 	}
 }
 
+func TestCredentialsFromJSONWithParams_SelfSignedJWT(t *testing.T) {
+	setupDummyKey(t)
+
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request to %v", r.URL)
+		return nil, errors.New("unexpected request")
+	})}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	creds, err := CredentialsFromJSONWithParams(ctx, jsonKey, CredentialsParams{
+		Scopes:           []string{"scope1", "scope2"},
+		UseSelfSignedJWT: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := creds.TokenSource.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if err := jws.Verify(tok.AccessToken, &privateKey.PublicKey); err != nil {
+		t.Errorf("jws.Verify on AccessToken: %v", err)
+	}
+	claim, err := jws.Decode(tok.AccessToken)
+	if err != nil {
+		t.Fatalf("jws.Decode on AccessToken: %v", err)
+	}
+	if got, want := claim.Scope, "scope1 scope2"; got != want {
+		t.Errorf("Scope = %q, want %q", got, want)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
 func setupDummyKey(t *testing.T) {
 	once.Do(func() {
 		// Generate a key we can use in the test data.
@@ -0,0 +1,128 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenInfoURL is Google's token information endpoint, with
+// UNIVERSE_DOMAIN standing for the credentials' universe domain. It is
+// a variable for tests.
+var tokenInfoURL = "https://oauth2.UNIVERSE_DOMAIN/tokeninfo"
+
+// TokenInfo describes the access token currently returned by a
+// Credentials' TokenSource, as reported by Google.
+type TokenInfo struct {
+	// Scopes are the scopes granted to the token.
+	Scopes []string
+	// Audience is the client the token was issued to.
+	Audience string
+	// Email is the email address of the principal the token
+	// represents, if the token has the email scope.
+	Email string
+	// Expiry is when the token expires.
+	Expiry time.Time
+}
+
+type tokenInfoResponse struct {
+	Active   *bool           `json:"active"` // introspection only
+	Scope    string          `json:"scope"`
+	Aud      json.RawMessage `json:"aud"`
+	ClientID string          `json:"client_id"`
+	Email    string          `json:"email"`
+	Username string          `json:"username"`
+	Exp      json.Number     `json:"exp"`
+}
+
+// TokenInfo fetches a token from c.TokenSource and asks Google to
+// describe it, which helps to debug permission errors. For external
+// account credentials whose file sets "token_info_url", the token is
+// described by that introspection endpoint; otherwise the tokeninfo
+// endpoint of c's universe domain is used. The token is sent in the
+// request body, never in the URL.
+func (c *Credentials) TokenInfo(ctx context.Context) (*TokenInfo, error) {
+	tok, err := c.TokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	var f credentialsFile
+	if len(c.JSON) > 0 {
+		json.Unmarshal(c.JSON, &f) // best effort
+	}
+	introspect := f.Type == externalAccountKey && f.TokenInfoURL != ""
+	var endpoint string
+	var form url.Values
+	if introspect {
+		endpoint = f.TokenInfoURL
+		form = url.Values{
+			"token":           {tok.AccessToken},
+			"token_type_hint": {"access_token"},
+		}
+	} else {
+		ud, err := c.GetUniverseDomain()
+		if err != nil {
+			return nil, err
+		}
+		endpoint = strings.Replace(tokenInfoURL, "UNIVERSE_DOMAIN", ud, 1)
+		form = url.Values{"access_token": {tok.AccessToken}}
+	}
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if introspect && f.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(f.ClientID), url.QueryEscape(f.ClientSecret))
+	}
+	resp, err := oauth2.NewClient(ctx, nil).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google: cannot fetch token info: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2/google: cannot fetch token info: %v", err)
+	}
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return nil, fmt.Errorf("oauth2/google: cannot fetch token info: %v\nResponse: %s", resp.Status, body)
+	}
+	var r tokenInfoResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("oauth2/google: cannot parse token info: %v", err)
+	}
+	if r.Active != nil && !*r.Active {
+		return nil, errors.New("oauth2/google: token is not active")
+	}
+	info := &TokenInfo{
+		Scopes:   strings.Fields(r.Scope),
+		Audience: r.ClientID,
+		Email:    r.Email,
+	}
+	if info.Email == "" {
+		info.Email = r.Username
+	}
+	var aud string
+	var auds []string
+	if json.Unmarshal(r.Aud, &aud) == nil && aud != "" {
+		info.Audience = aud
+	} else if json.Unmarshal(r.Aud, &auds) == nil && len(auds) > 0 {
+		info.Audience = auds[0]
+	}
+	if exp, err := r.Exp.Int64(); err == nil && exp > 0 {
+		info.Expiry = time.Unix(exp, 0)
+	}
+	return info, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestCredentialsTokenInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/example.com" || r.URL.RawQuery != "" {
+			t.Errorf("got %s %s, want POST /example.com without query", r.Method, r.URL)
+		}
+		if got, want := r.PostFormValue("access_token"), "abc"; got != want {
+			t.Errorf("access_token = %q, want %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"aud":"client.apps.googleusercontent.com","scope":"openid email","exp":"1700000000","email":"gopher@example.com"}`))
+	}))
+	defer ts.Close()
+	defer func(old string) { tokenInfoURL = old }(tokenInfoURL)
+	tokenInfoURL = ts.URL + "/UNIVERSE_DOMAIN"
+
+	creds := &Credentials{
+		TokenSource:    oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"}),
+		universeDomain: "example.com",
+	}
+	info, err := creds.TokenInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"openid", "email"}; !reflect.DeepEqual(info.Scopes, want) {
+		t.Errorf("Scopes = %v, want %v", info.Scopes, want)
+	}
+	if want := "client.apps.googleusercontent.com"; info.Audience != want {
+		t.Errorf("Audience = %q, want %q", info.Audience, want)
+	}
+	if want := "gopher@example.com"; info.Email != want {
+		t.Errorf("Email = %q, want %q", info.Email, want)
+	}
+	if want := int64(1700000000); info.Expiry.Unix() != want {
+		t.Errorf("Expiry = %v, want %v", info.Expiry.Unix(), want)
+	}
+}
+
+func TestCredentialsTokenInfo_ExternalAccount(t *testing.T) {
+	active := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.FormValue("token") != "abc" {
+			t.Errorf("got %s with token %q, want POST with token %q", r.Method, r.FormValue("token"), "abc")
+		}
+		if user, _, _ := r.BasicAuth(); user != "client" {
+			t.Errorf("client ID = %q, want %q", user, "client")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"active":%t,"scope":"https://www.googleapis.com/auth/cloud-platform","client_id":"client","username":"principal","exp":1700000000}`, active)
+	}))
+	defer ts.Close()
+
+	creds := &Credentials{
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"}),
+		JSON:        []byte(fmt.Sprintf(`{"type":"external_account","token_info_url":%q,"client_id":"client","client_secret":"secret"}`, ts.URL)),
+	}
+	info, err := creds.TokenInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TokenInfo{
+		Scopes:   []string{"https://www.googleapis.com/auth/cloud-platform"},
+		Audience: "client",
+		Email:    "principal",
+		Expiry:   info.Expiry,
+	}
+	if !reflect.DeepEqual(info, want) || info.Expiry.Unix() != 1700000000 {
+		t.Errorf("TokenInfo = %+v, want %+v", info, want)
+	}
+
+	active = false
+	if _, err := creds.TokenInfo(context.Background()); err == nil {
+		t.Errorf("TokenInfo succeeded for an inactive token")
+	}
+}
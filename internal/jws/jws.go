@@ -0,0 +1,81 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jws extends golang.org/x/oauth2/jws for the packages of this
+// module, without growing the API of that deprecated package.
+package jws
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2/jws"
+)
+
+// EncodeWithExtraHeader is like jws.EncodeWithSigner, but adds the header
+// parameters in extra, such as ones private to a provider. They must not
+// repeat the parameters set by header.
+func EncodeWithExtraHeader(header *jws.Header, extra map[string]interface{}, c *jws.ClaimSet, sg jws.Signer) (string, error) {
+	if len(extra) == 0 {
+		return jws.EncodeWithSigner(header, c, sg)
+	}
+	head, err := encodeHeader(header, extra)
+	if err != nil {
+		return "", err
+	}
+	// The claim set is encoded by jws, which fills in its defaults; the
+	// signer only captures the signing input, whose second part it is.
+	var input string
+	if _, err := jws.EncodeWithSigner(header, c, func(data []byte) ([]byte, error) {
+		input = string(data)
+		return nil, nil
+	}); err != nil {
+		return "", err
+	}
+	_, cs, _ := strings.Cut(input, ".")
+	ss := head + "." + cs
+	sig, err := sg([]byte(ss))
+	if err != nil {
+		return "", err
+	}
+	return ss + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// encodeHeader encodes h with the additional parameters in extra.
+func encodeHeader(h *jws.Header, extra map[string]interface{}) (string, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	for k, v := range extra {
+		if _, ok := m[k]; ok {
+			return "", fmt.Errorf("jws: extra header parameter %q is already set", k)
+		}
+		m[k] = v
+	}
+	if b, err = json.Marshal(m); err != nil {
+		return "", fmt.Errorf("jws: invalid extra header parameters: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// RS256Signer returns a jws.Signer using crypto/rsa.SignPKCS1v15 with
+// key and SHA-256, as used by jws.Encode.
+func RS256Signer(key *rsa.PrivateKey) jws.Signer {
+	return func(data []byte) (sig []byte, err error) {
+		h := sha256.New()
+		h.Write(data)
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h.Sum(nil))
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jws
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2/jws"
+)
+
+func TestEncodeWithExtraHeader(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &jws.Header{Algorithm: "RS256", Typ: "JWT", X5T: "thumb"}
+	payload := &jws.ClaimSet{Iss: "http://google.com/", Exp: 3610, Iat: 10}
+	token, err := EncodeWithExtraHeader(header, map[string]interface{}{"private": "p"}, payload, RS256Signer(privateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jws.Verify(token, &privateKey.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"alg":"RS256","private":"p","typ":"JWT","x5t":"thumb"}`; got != want {
+		t.Errorf("header = %s; want %s", got, want)
+	}
+	claims, err := jws.Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Iss != "http://google.com/" || claims.Exp != 3610 {
+		t.Errorf("claims = %+v; want the encoded claim set", claims)
+	}
+
+	if _, err := EncodeWithExtraHeader(header, map[string]interface{}{"alg": "none"}, payload, RS256Signer(privateKey)); err == nil {
+		t.Error("EncodeWithExtraHeader overriding alg succeeded")
+	}
+}
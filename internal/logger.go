@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Logger is a copy of the golang.org/x/oauth2 package's Logger type.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+func (o *RetrieveOptions) logf(format string, v ...interface{}) {
+	if o != nil && o.Logger != nil {
+		o.Logger.Printf(format, v...)
+	}
+}
+
+// logTokenRequest logs the outcome of a token request. Credentials are
+// never logged: the URL is logged without its query, which holds the
+// request parameters of GET token requests.
+func (o *RetrieveOptions) logTokenRequest(req *http.Request, d time.Duration, err error) {
+	if o == nil || o.Logger == nil {
+		return
+	}
+	target := req.Method + " " + redactURL(req.URL)
+	var rerr *RetrieveError
+	var uerr *url.Error
+	switch {
+	case err == nil:
+		o.logf("oauth2: %s succeeded in %v", target, d)
+	case errors.As(err, &rerr) && rerr.Response != nil:
+		o.logf("oauth2: %s failed in %v: status %d, error %q", target, d, rerr.Response.StatusCode, rerr.ErrorCode)
+	case errors.As(err, &uerr):
+		o.logf("oauth2: %s failed in %v: %v", target, d, uerr.Err)
+	default:
+		o.logf("oauth2: %s failed in %v: %v", target, d, err)
+	}
+}
+
+// redactURL returns u without its query, fragment and user info.
+func redactURL(u *url.URL) string {
+	r := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	return r.String()
+}
@@ -18,9 +18,41 @@ import (
 // from PEM container before conversion. It only supports PEM
 // containers with no passphrase.
 func ParseKey(key []byte) (*rsa.PrivateKey, error) {
+	return ParseKeyWithPassword(key, nil)
+}
+
+// ParseKeyWithPassword is like ParseKey, but additionally decrypts
+// private keys encrypted with password. Encrypted PKCS #8 keys
+// ("ENCRYPTED PRIVATE KEY" PEM blocks, as produced by
+// "openssl pkcs8 -topk8") are supported when they use PBES2 with PBKDF2
+// and AES-CBC. Legacy RFC 1423 PEM encryption ("Proc-Type: 4,ENCRYPTED"
+// headers, as produced by "openssl rsa -aes256") is still accepted for
+// compatibility, but it is unauthenticated and should not be used for new
+// keys. If the key is not encrypted, password is ignored.
+func ParseKeyWithPassword(key, password []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(key)
 	if block != nil {
 		key = block.Bytes
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if len(password) == 0 {
+				return nil, errors.New("private key is encrypted and no password was provided")
+			}
+			decrypted, err := decryptPKCS8(block.Bytes, password)
+			if err != nil {
+				return nil, err
+			}
+			key = decrypted
+		case x509.IsEncryptedPEMBlock(block):
+			if len(password) == 0 {
+				return nil, errors.New("private key is encrypted and no password was provided")
+			}
+			decrypted, err := x509.DecryptPEMBlock(block, password)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decrypt private key: %v", err)
+			}
+			key = decrypted
+		}
 	}
 	parsedKey, err := x509.ParsePKCS8PrivateKey(key)
 	if err != nil {
@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyWithPassword(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("passw0rd"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := pem.EncodeToMemory(block)
+
+	if _, err := ParseKey(encrypted); err == nil {
+		t.Errorf("ParseKey succeeded on an encrypted key without a password")
+	}
+	if _, err := ParseKeyWithPassword(encrypted, []byte("wrong")); err == nil {
+		t.Errorf("ParseKeyWithPassword succeeded with the wrong password")
+	}
+	got, err := ParseKeyWithPassword(encrypted, []byte("passw0rd"))
+	if err != nil {
+		t.Fatalf("ParseKeyWithPassword: %v", err)
+	}
+	if !got.Equal(key) {
+		t.Errorf("ParseKeyWithPassword returned a different key")
+	}
+
+	plain := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if _, err := ParseKeyWithPassword(plain, []byte("ignored")); err != nil {
+		t.Errorf("ParseKeyWithPassword on unencrypted key: %v", err)
+	}
+}
+
+func TestParseKeyWithPasswordPKCS8(t *testing.T) {
+	plain, err := os.ReadFile("testdata/pkcs8_plain.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseKey(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"pkcs8_aes256.pem", "pkcs8_aes128_sha1.pem"} {
+		t.Run(file, func(t *testing.T) {
+			encrypted, err := os.ReadFile("testdata/" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseKey(encrypted); err == nil {
+				t.Errorf("ParseKey succeeded on an encrypted key without a password")
+			}
+			if _, err := ParseKeyWithPassword(encrypted, []byte("wrong")); err == nil {
+				t.Errorf("ParseKeyWithPassword succeeded with the wrong password")
+			}
+			got, err := ParseKeyWithPassword(encrypted, []byte("passw0rd"))
+			if err != nil {
+				t.Fatalf("ParseKeyWithPassword: %v", err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("ParseKeyWithPassword returned a different key")
+			}
+		})
+	}
+}
+
+func TestParseKeyWithPasswordPKCS8Unsupported(t *testing.T) {
+	encrypted, err := os.ReadFile("testdata/pkcs8_pbes1.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseKeyWithPassword(encrypted, []byte("passw0rd"))
+	if err == nil || !strings.Contains(err.Error(), "only PBES2 is supported") {
+		t.Errorf("ParseKeyWithPassword = %v, want unsupported encryption scheme error", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy is a copy of the golang.org/x/oauth2 package's RetryPolicy
+// type.
+type RetryPolicy struct {
+	DNSRetries          int
+	ConnResetRetries    int
+	TLSHandshakeRetries int
+	Backoff             time.Duration
+}
+
+// netErrorClass is a class of transport-level failure with its own retry
+// budget.
+type netErrorClass int
+
+const (
+	netErrorOther netErrorClass = iota
+	netErrorDNS
+	netErrorConnReset
+	netErrorTLSHandshake
+)
+
+// classifyNetError reports which retryable class err belongs to, if any.
+// Permanent failures, such as a host that does not exist, are not
+// retryable, as retrying them would only hide a misconfiguration.
+func classifyNetError(err error) netErrorClass {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsTemporary || dnsErr.IsTimeout {
+			return netErrorDNS
+		}
+	case errors.Is(err, syscall.ECONNRESET):
+		return netErrorConnReset
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		// net/http doesn't export its TLS handshake timeout error.
+		return netErrorTLSHandshake
+	}
+	return netErrorOther
+}
+
+// budget returns the number of retries p allows for class.
+func (p *RetryPolicy) budget(class netErrorClass) int {
+	switch class {
+	case netErrorDNS:
+		return p.DNSRetries
+	case netErrorConnReset:
+		return p.ConnResetRetries
+	case netErrorTLSHandshake:
+		return p.TLSHandshakeRetries
+	}
+	return 0
+}
+
+// doWithRetries sends req with ContextClient(ctx), retrying transport-level
+// failures as allowed by opts.Retry. HTTP responses, whatever their
+// status, are never retried. Each attempt is limited by opts.Timeout.
+// Requests without a body are sent again as they are; requests whose
+// body cannot be rewound with GetBody are not retried.
+func doWithRetries(ctx context.Context, req *http.Request, opts *RetrieveOptions) (*Token, error) {
+	p := opts.retry()
+	retries := make(map[netErrorClass]int)
+	for {
+		token, err := doTokenRoundTripWithTimeout(ctx, req, opts)
+		if err == nil || p == nil || (hasBody(req) && req.GetBody == nil) {
+			return token, err
+		}
+		var uerr *url.Error // transport-level failures from the HTTP client
+		if !errors.As(err, &uerr) {
+			return nil, err
+		}
+		class := classifyNetError(err)
+		if class == netErrorOther || retries[class] >= p.budget(class) {
+			return nil, err
+		}
+		retries[class]++
+		opts.logf("oauth2: retrying token request after %v (retry %d of %d)", p.Backoff, retries[class], p.budget(class))
+		if hasBody(req) {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+		t := time.NewTimer(p.Backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		case <-t.C:
+		}
+	}
+}
+
+// hasBody reports whether req has a body that must be rewound before
+// the request is sent again.
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
@@ -5,6 +5,7 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -53,6 +54,25 @@ type Token struct {
 	// Raw optionally contains extra metadata from the server
 	// when updating a token.
 	Raw interface{}
+
+	// Response holds the status and headers of the response of the
+	// token endpoint, as returned by ResponseMetadata.
+	Response *http.Response
+}
+
+// ResponseMetadata returns a copy of the status and headers of r. Its
+// Body and Request are nil: the request carries the client credentials
+// and the grant, and neither should outlive the token request in the
+// tokens that keep the response.
+func ResponseMetadata(r *http.Response) *http.Response {
+	return &http.Response{
+		Status:     r.Status,
+		StatusCode: r.StatusCode,
+		Proto:      r.Proto,
+		ProtoMajor: r.ProtoMajor,
+		ProtoMinor: r.ProtoMinor,
+		Header:     r.Header.Clone(),
+	}
 }
 
 // tokenJSON is the struct representing the HTTP response from OAuth2
@@ -70,9 +90,9 @@ type tokenJSON struct {
 	ErrorURI         string `json:"error_uri"`
 }
 
-func (e *tokenJSON) expiry() (t time.Time) {
+func (e *tokenJSON) expiry(now func() time.Time) (t time.Time) {
 	if v := e.ExpiresIn; v != 0 {
-		return time.Now().Add(time.Duration(v) * time.Second)
+		return now().Add(time.Duration(v) * time.Second)
 	}
 	return
 }
@@ -99,6 +119,48 @@ func (e *expirationTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// lenientTokenJSON is a tokenJSON whose lifetime may be a number or a
+// string, possibly with a fraction, in expires_in or expires.
+type lenientTokenJSON struct {
+	tokenJSON
+	ExpiresIn json.RawMessage `json:"expires_in"`
+	Expires   json.RawMessage `json:"expires"`
+}
+
+func (e *lenientTokenJSON) expiresIn() expirationTime {
+	raw := e.ExpiresIn
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = e.Expires
+	}
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		s = string(raw)
+	}
+	return expirationTime(lenientSeconds(s))
+}
+
+// lenientSeconds parses a number of seconds such as "3600", " 3600 " or
+// "3600.0". It returns 0 if s is not a number.
+func lenientSeconds(s string) int32 {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if i > math.MaxInt32 {
+			i = math.MaxInt32
+		}
+		return int32(i)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	switch {
+	case err != nil || math.IsNaN(f):
+		return 0
+	case f > math.MaxInt32:
+		return math.MaxInt32
+	case f < math.MinInt32:
+		return math.MinInt32
+	}
+	return int32(f)
+}
+
 // RegisterBrokenAuthHeaderProvider previously did something. It is now a no-op.
 //
 // Deprecated: this function no longer does anything. Caller code that
@@ -132,6 +194,17 @@ type LazyAuthStyleCache struct {
 	v atomic.Value // of *AuthStyleCache
 }
 
+// PublicAuthStyleCache returns the cache held by an
+// *oauth2.AuthStyleCache, or nil if c is nil. It is set by the oauth2
+// package, which clientcredentials cannot otherwise reach into.
+var PublicAuthStyleCache func(c interface{}) *AuthStyleCache
+
+// PublicToken converts t to an *oauth2.Token whose Valid method uses now,
+// if non-nil, as the current time. It is set by the oauth2 package, so
+// that packages retrieving tokens themselves, such as jwt and
+// clientcredentials, can set its unexported fields.
+var PublicToken func(t *Token, now func() time.Time) interface{}
+
 func (lc *LazyAuthStyleCache) Get() *AuthStyleCache {
 	if c, ok := lc.v.Load().(*AuthStyleCache); ok {
 		return c
@@ -143,33 +216,109 @@ func (lc *LazyAuthStyleCache) Get() *AuthStyleCache {
 	return c
 }
 
-// AuthStyleCache is the set of tokenURLs we've successfully used via
-// RetrieveToken and which style auth we ended up using.
-// It's called a cache, but it doesn't (yet?) shrink. It's expected that
-// the set of OAuth2 servers a program contacts over time is fixed and
-// small.
+// AuthStyleCache is the set of tokenURLs and client IDs we've
+// successfully used via RetrieveToken and which style auth we ended up
+// using. Entries are recorded per client, since clients registered with
+// the same server may use different auth methods; an entry with an
+// empty client ID applies to every client without one of its own.
+// By default it doesn't shrink, as it's expected that the set of OAuth2
+// servers a program contacts over time is fixed and small; MaxSize and
+// TTL can bound it otherwise.
 type AuthStyleCache struct {
+	// MaxSize optionally limits the number of entries. When it is
+	// exceeded, the oldest entry is evicted. Zero means no limit.
+	MaxSize int
+
+	// TTL optionally limits how long an entry is used after it was
+	// set. Zero means entries never expire.
+	TTL time.Duration
+
 	mu sync.Mutex
-	m  map[string]AuthStyle // keyed by tokenURL
+	m  map[AuthStyleKey]authStyleEntry
+}
+
+// AuthStyleKey identifies an entry of an AuthStyleCache.
+type AuthStyleKey struct {
+	TokenURL string
+	ClientID string
+}
+
+type authStyleEntry struct {
+	style AuthStyle
+	set   time.Time
 }
 
-// lookupAuthStyle reports which auth style we last used with tokenURL
-// when calling RetrieveToken and whether we have ever done so.
-func (c *AuthStyleCache) lookupAuthStyle(tokenURL string) (style AuthStyle, ok bool) {
+// timeNow is time.Now but pulled out as a variable for tests.
+var timeNow = time.Now
+
+// LookupAuthStyle reports which auth style we last used with tokenURL
+// and clientID when calling RetrieveToken and whether we have ever done
+// so, falling back to the entry for tokenURL with an empty client ID.
+func (c *AuthStyleCache) LookupAuthStyle(tokenURL, clientID string) (style AuthStyle, ok bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	style, ok = c.m[tokenURL]
-	return
+	if style, ok := c.lookupLocked(AuthStyleKey{tokenURL, clientID}); ok || clientID == "" {
+		return style, ok
+	}
+	return c.lookupLocked(AuthStyleKey{TokenURL: tokenURL})
+}
+
+func (c *AuthStyleCache) lookupLocked(k AuthStyleKey) (style AuthStyle, ok bool) {
+	e, ok := c.m[k]
+	if ok && c.TTL > 0 && timeNow().Sub(e.set) >= c.TTL {
+		delete(c.m, k)
+		return 0, false
+	}
+	return e.style, ok
 }
 
-// setAuthStyle adds an entry to authStyleCache, documented above.
-func (c *AuthStyleCache) setAuthStyle(tokenURL string, v AuthStyle) {
+// SetAuthStyle adds an entry to the cache, documented above.
+func (c *AuthStyleCache) SetAuthStyle(tokenURL, clientID string, v AuthStyle) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.m == nil {
-		c.m = make(map[string]AuthStyle)
+		c.m = make(map[AuthStyleKey]authStyleEntry)
+	}
+	c.m[AuthStyleKey{tokenURL, clientID}] = authStyleEntry{style: v, set: timeNow()}
+	for c.MaxSize > 0 && len(c.m) > c.MaxSize {
+		var oldest AuthStyleKey
+		first := true
+		for k, e := range c.m {
+			if first || e.set.Before(c.m[oldest].set) {
+				oldest, first = k, false
+			}
+		}
+		delete(c.m, oldest)
 	}
-	c.m[tokenURL] = v
+}
+
+// DeleteAuthStyle removes an entry from the cache.
+func (c *AuthStyleCache) DeleteAuthStyle(tokenURL, clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, AuthStyleKey{tokenURL, clientID})
+}
+
+// Entries returns a copy of the unexpired entries in the cache.
+func (c *AuthStyleCache) Entries() map[AuthStyleKey]AuthStyle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := make(map[AuthStyleKey]AuthStyle, len(c.m))
+	for k, e := range c.m {
+		if c.TTL > 0 && timeNow().Sub(e.set) >= c.TTL {
+			continue
+		}
+		m[k] = e.style
+	}
+	return m
+}
+
+// NewClientRequest returns a POST request to endpointURL, such as a
+// revocation or logout endpoint of the authorization server, with the
+// form parameters v and the client credentials sent as in token requests
+// with authStyle, which must not be AuthStyleUnknown.
+func NewClientRequest(endpointURL, clientID, clientSecret string, v url.Values, authStyle AuthStyle, rawBasicAuth bool) (*http.Request, error) {
+	return newTokenRequest(endpointURL, clientID, clientSecret, v, authStyle, &RetrieveOptions{RawBasicAuth: rawBasicAuth})
 }
 
 // newTokenRequest returns a new *http.Request to retrieve a new token
@@ -180,23 +329,61 @@ func (c *AuthStyleCache) setAuthStyle(tokenURL string, v AuthStyle) {
 // as the POST body. An 'inParams' value of true means to send it in
 // the POST body (along with any values in v); false means to send it
 // in the Authorization header.
-func newTokenRequest(tokenURL, clientID, clientSecret string, v url.Values, authStyle AuthStyle) (*http.Request, error) {
-	if authStyle == AuthStyleInParams {
+func newTokenRequest(tokenURL, clientID, clientSecret string, v url.Values, authStyle AuthStyle, opts *RetrieveOptions) (*http.Request, error) {
+	names := opts.paramNames()
+	if authStyle == AuthStyleInParams || names != nil {
 		v = cloneURLValues(v)
+	}
+	if authStyle == AuthStyleInParams {
 		if clientID != "" {
-			v.Set("client_id", clientID)
+			v.Set(names.clientID(), clientID)
 		}
 		if clientSecret != "" {
-			v.Set("client_secret", clientSecret)
+			v.Set(names.clientSecret(), clientSecret)
 		}
 	}
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(v.Encode()))
+	if name := names.grantType(); name != "grant_type" {
+		if gt, ok := v["grant_type"]; ok {
+			delete(v, "grant_type")
+			v[name] = gt
+		}
+	}
+	method := names.method()
+	var body io.Reader
+	contentType := ""
+	if method == http.MethodGet {
+		sep := "?"
+		if strings.Contains(tokenURL, "?") {
+			sep = "&"
+		}
+		tokenURL += sep + v.Encode()
+	} else {
+		b, ct, err := EncodeBody(v, opts != nil && opts.JSONBody)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = bytes.NewReader(b), ct
+	}
+	req, err := http.NewRequest(method, tokenURL, body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if opts != nil {
+		for k, vv := range opts.Header {
+			if k = http.CanonicalHeaderKey(k); k != "Authorization" {
+				req.Header[k] = append([]string(nil), vv...)
+			}
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
 	if authStyle == AuthStyleInHeader {
-		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+		if opts != nil && opts.RawBasicAuth {
+			req.SetBasicAuth(clientID, clientSecret)
+		} else {
+			req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+		}
 	}
 	return req, nil
 }
@@ -209,21 +396,225 @@ func cloneURLValues(v url.Values) url.Values {
 	return v2
 }
 
-func RetrieveToken(ctx context.Context, clientID, clientSecret, tokenURL string, v url.Values, authStyle AuthStyle, styleCache *AuthStyleCache) (*Token, error) {
+// RetrieveOptions holds optional settings for RetrieveToken.
+// A nil *RetrieveOptions is valid and means the defaults.
+type RetrieveOptions struct {
+	// RawBasicAuth sends the client ID and secret in the
+	// Authorization header without URL-encoding them first.
+	RawBasicAuth bool
+
+	// Timeout limits the duration of each token endpoint request,
+	// including reading the response. Zero means
+	// DefaultTokenRequestTimeout; a negative value means no limit
+	// other than that of the context.
+	Timeout time.Duration
+
+	// DisableProbe makes RetrieveToken fail instead of probing for the
+	// auth style when it is AuthStyleUnknown and not in the cache.
+	DisableProbe bool
+
+	// Retry optionally retries transport-level failures.
+	Retry *RetryPolicy
+
+	// Limiter optionally gates token requests.
+	Limiter RequestLimiter
+
+	// ParseResponse optionally parses successful token responses
+	// instead of the standard RFC 6749 parser.
+	ParseResponse func(contentType string, body []byte) (*Token, error)
+
+	// Header optionally holds extra headers to send with token
+	// requests. It cannot override Content-Type or Authorization.
+	Header http.Header
+
+	// ParamNames optionally overrides parameter names and the HTTP
+	// method of token requests.
+	ParamNames *ParamNames
+
+	// Logger optionally receives redacted debug logs of token
+	// requests.
+	Logger Logger
+
+	// Middleware optionally wraps the transport of the HTTP client
+	// used for token requests.
+	Middleware func(http.RoundTripper) http.RoundTripper
+
+	// JSONBody sends the parameters of POST token requests as a JSON
+	// object instead of a form-encoded body.
+	JSONBody bool
+
+	// Now optionally returns the current time, used to compute the
+	// Expiry of tokens. The default is time.Now.
+	Now func() time.Time
+
+	// LenientExpiry makes the standard parser accept token lifetimes
+	// given as strings or with a fraction, and in an "expires" field
+	// when "expires_in" is missing.
+	LenientExpiry bool
+}
+
+func (o *RetrieveOptions) now() func() time.Time {
+	if o == nil || o.Now == nil {
+		return time.Now
+	}
+	return o.Now
+}
+
+// EncodeBody returns the body and Content-Type of a request with the
+// parameters v, encoded as a JSON object if asJSON is set and as a form
+// otherwise. In the JSON object, parameters with a single value are
+// strings, and parameters with several values are arrays of strings.
+func EncodeBody(v url.Values, asJSON bool) (body []byte, contentType string, err error) {
+	if !asJSON {
+		return []byte(v.Encode()), "application/x-www-form-urlencoded", nil
+	}
+	m := make(map[string]interface{}, len(v))
+	for k, vv := range v {
+		if len(vv) == 1 {
+			m[k] = vv[0]
+		} else {
+			m[k] = vv
+		}
+	}
+	body, err = json.Marshal(m)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, "application/json", nil
+}
+
+// withMiddleware returns ctx with an HTTP client whose transport is that
+// of ContextClient(ctx) wrapped by opts.Middleware, if it is set.
+func withMiddleware(ctx context.Context, opts *RetrieveOptions) context.Context {
+	if opts == nil || opts.Middleware == nil {
+		return ctx
+	}
+	hc := *ContextClient(ctx)
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = opts.Middleware(base)
+	return context.WithValue(ctx, HTTPClient, &hc)
+}
+
+// ParamNames is a copy of the golang.org/x/oauth2 package's ParamNames type.
+type ParamNames struct {
+	ClientID     string
+	ClientSecret string
+	GrantType    string
+	Method       string
+}
+
+func (p *ParamNames) clientID() string {
+	if p == nil || p.ClientID == "" {
+		return "client_id"
+	}
+	return p.ClientID
+}
+
+func (p *ParamNames) clientSecret() string {
+	if p == nil || p.ClientSecret == "" {
+		return "client_secret"
+	}
+	return p.ClientSecret
+}
+
+func (p *ParamNames) grantType() string {
+	if p == nil || p.GrantType == "" {
+		return "grant_type"
+	}
+	return p.GrantType
+}
+
+func (p *ParamNames) method() string {
+	if p == nil || p.Method == "" {
+		return http.MethodPost
+	}
+	return p.Method
+}
+
+// renamesCredentials reports whether p renames the client ID or secret
+// parameter, which only makes sense with AuthStyleInParams.
+func (p *ParamNames) renamesCredentials() bool {
+	return p != nil && (p.ClientID != "" || p.ClientSecret != "")
+}
+
+func (o *RetrieveOptions) paramNames() *ParamNames {
+	if o == nil {
+		return nil
+	}
+	return o.ParamNames
+}
+
+// RequestLimiter gates token requests. It is implemented by
+// oauth2.TokenRequestLimiter.
+type RequestLimiter interface {
+	// Allow returns an error if a request may not be made now.
+	Allow() error
+
+	// Record records the result of an allowed request.
+	Record(err error)
+}
+
+// DefaultTokenRequestTimeout is the timeout applied to token endpoint
+// requests when none is configured.
+const DefaultTokenRequestTimeout = 30 * time.Second
+
+// WithRequestTimeout returns a context limited by timeout, interpreted
+// as documented on RetrieveOptions.Timeout.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	if timeout == 0 {
+		timeout = DefaultTokenRequestTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (o *RetrieveOptions) timeout() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.Timeout
+}
+
+func (o *RetrieveOptions) retry() *RetryPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Retry
+}
+
+func RetrieveToken(ctx context.Context, clientID, clientSecret, tokenURL string, v url.Values, authStyle AuthStyle, styleCache *AuthStyleCache, opts *RetrieveOptions) (*Token, error) {
+	if authStyle == 0 && opts.paramNames().renamesCredentials() {
+		authStyle = AuthStyleInParams
+	}
 	needsAuthStyleProbe := authStyle == 0
 	if needsAuthStyleProbe {
-		if style, ok := styleCache.lookupAuthStyle(tokenURL); ok {
+		if style, ok := styleCache.LookupAuthStyle(tokenURL, clientID); ok {
 			authStyle = style
 			needsAuthStyleProbe = false
+		} else if opts != nil && opts.DisableProbe {
+			return nil, fmt.Errorf("oauth2: auth style probing is disabled and no auth style is known for %q", tokenURL)
 		} else {
 			authStyle = AuthStyleInHeader // the first way we'll try
 		}
 	}
-	req, err := newTokenRequest(tokenURL, clientID, clientSecret, v, authStyle)
+	req, err := newTokenRequest(tokenURL, clientID, clientSecret, v, authStyle, opts)
 	if err != nil {
 		return nil, err
 	}
-	token, err := doTokenRoundTrip(ctx, req)
+	if opts != nil && opts.Limiter != nil {
+		if err := opts.Limiter.Allow(); err != nil {
+			opts.logf("oauth2: token request to %s not sent: %v", redactURL(req.URL), err)
+			return nil, err
+		}
+	}
+	opts.logf("oauth2: requesting token from %s with grant type %q", redactURL(req.URL), v.Get("grant_type"))
+	ctx = withMiddleware(ctx, opts)
+	token, err := doWithRetries(ctx, req, opts)
 	if err != nil && needsAuthStyleProbe {
 		// If we get an error, assume the server wants the
 		// clientID & clientSecret in a different form.
@@ -238,11 +629,15 @@ func RetrieveToken(ctx context.Context, clientID, clientSecret, tokenURL string,
 		// they went, but maintaining it didn't scale & got annoying.
 		// So just try both ways.
 		authStyle = AuthStyleInParams // the second way we'll try
-		req, _ = newTokenRequest(tokenURL, clientID, clientSecret, v, authStyle)
-		token, err = doTokenRoundTrip(ctx, req)
+		opts.logf("oauth2: retrying token request with client credentials in parameters")
+		req, _ = newTokenRequest(tokenURL, clientID, clientSecret, v, authStyle, opts)
+		token, err = doWithRetries(ctx, req, opts)
 	}
 	if needsAuthStyleProbe && err == nil {
-		styleCache.setAuthStyle(tokenURL, authStyle)
+		styleCache.SetAuthStyle(tokenURL, clientID, authStyle)
+	}
+	if opts != nil && opts.Limiter != nil {
+		opts.Limiter.Record(err)
 	}
 	// Don't overwrite `RefreshToken` with an empty value
 	// if this was a token refreshing request.
@@ -252,7 +647,26 @@ func RetrieveToken(ctx context.Context, clientID, clientSecret, tokenURL string,
 	return token, err
 }
 
-func doTokenRoundTrip(ctx context.Context, req *http.Request) (*Token, error) {
+func doTokenRoundTripWithTimeout(ctx context.Context, req *http.Request, opts *RetrieveOptions) (*Token, error) {
+	ctx, cancel := WithRequestTimeout(ctx, opts.timeout())
+	defer cancel()
+	start := time.Now()
+	token, err := doTokenRoundTrip(ctx, req, opts)
+	opts.logTokenRequest(req, time.Since(start), err)
+	return token, err
+}
+
+// doTokenRoundTrip sends req and parses the token in the response,
+// using opts.ParseResponse if set instead of the standard RFC 6749
+// parser.
+func doTokenRoundTrip(ctx context.Context, req *http.Request, opts *RetrieveOptions) (*Token, error) {
+	var parse func(string, []byte) (*Token, error)
+	var lenient bool
+	if opts != nil {
+		parse = opts.ParseResponse
+		lenient = opts.LenientExpiry
+	}
+	now := opts.now()
 	r, err := ContextClient(ctx).Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
@@ -270,6 +684,18 @@ func doTokenRoundTrip(ctx context.Context, req *http.Request) (*Token, error) {
 		// attempt to populate error detail below
 	}
 
+	if parse != nil && !failureStatus {
+		token, err := parse(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil || token.AccessToken == "" {
+			return nil, errors.New("oauth2: server response missing access_token")
+		}
+		token.Response = ResponseMetadata(r)
+		return token, nil
+	}
+
 	var token *Token
 	content, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	switch content {
@@ -293,12 +719,26 @@ func doTokenRoundTrip(ctx context.Context, req *http.Request) (*Token, error) {
 		}
 		e := vals.Get("expires_in")
 		expires, _ := strconv.Atoi(e)
+		if lenient && expires == 0 {
+			if e == "" {
+				e = vals.Get("expires")
+			}
+			expires = int(lenientSeconds(e))
+		}
 		if expires != 0 {
-			token.Expiry = time.Now().Add(time.Duration(expires) * time.Second)
+			token.Expiry = now().Add(time.Duration(expires) * time.Second)
 		}
 	default:
 		var tj tokenJSON
-		if err = json.Unmarshal(body, &tj); err != nil {
+		if lenient {
+			var lj lenientTokenJSON
+			err = json.Unmarshal(body, &lj)
+			tj = lj.tokenJSON
+			tj.ExpiresIn = lj.expiresIn()
+		} else {
+			err = json.Unmarshal(body, &tj)
+		}
+		if err != nil {
 			if failureStatus {
 				return nil, retrieveError
 			}
@@ -311,7 +751,7 @@ func doTokenRoundTrip(ctx context.Context, req *http.Request) (*Token, error) {
 			AccessToken:  tj.AccessToken,
 			TokenType:    tj.TokenType,
 			RefreshToken: tj.RefreshToken,
-			Expiry:       tj.expiry(),
+			Expiry:       tj.expiry(now),
 			Raw:          make(map[string]interface{}),
 		}
 		json.Unmarshal(body, &token.Raw) // no error checks for optional fields
@@ -325,6 +765,7 @@ func doTokenRoundTrip(ctx context.Context, req *http.Request) (*Token, error) {
 	if token.AccessToken == "" {
 		return nil, errors.New("oauth2: server response missing access_token")
 	}
+	token.Response = ResponseMetadata(r)
 	return token, nil
 }
 
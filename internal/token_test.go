@@ -9,10 +9,14 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestRetrieveToken_InParams(t *testing.T) {
@@ -29,7 +33,7 @@ func TestRetrieveToken_InParams(t *testing.T) {
 		io.WriteString(w, `{"access_token": "ACCESS_TOKEN", "token_type": "bearer"}`)
 	}))
 	defer ts.Close()
-	_, err := RetrieveToken(context.Background(), clientID, "", ts.URL, url.Values{}, AuthStyleInParams, styleCache)
+	_, err := RetrieveToken(context.Background(), clientID, "", ts.URL, url.Values{}, AuthStyleInParams, styleCache, nil)
 	if err != nil {
 		t.Errorf("RetrieveToken = %v; want no error", err)
 	}
@@ -45,7 +49,7 @@ func TestRetrieveTokenWithContexts(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	_, err := RetrieveToken(context.Background(), clientID, "", ts.URL, url.Values{}, AuthStyleUnknown, styleCache)
+	_, err := RetrieveToken(context.Background(), clientID, "", ts.URL, url.Values{}, AuthStyleUnknown, styleCache, nil)
 	if err != nil {
 		t.Errorf("RetrieveToken (with background context) = %v; want no error", err)
 	}
@@ -58,7 +62,7 @@ func TestRetrieveTokenWithContexts(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	_, err = RetrieveToken(ctx, clientID, "", cancellingts.URL, url.Values{}, AuthStyleUnknown, styleCache)
+	_, err = RetrieveToken(ctx, clientID, "", cancellingts.URL, url.Values{}, AuthStyleUnknown, styleCache, nil)
 	close(retrieved)
 	if err == nil {
 		t.Errorf("RetrieveToken (with cancelled context) = nil; want error")
@@ -75,3 +79,195 @@ func TestExpiresInUpperBound(t *testing.T) {
 		t.Errorf("expiration time = %v; want %v", e, want)
 	}
 }
+
+func TestLenientExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		contentType, body string
+		strict, lenient   time.Duration
+	}{
+		{"application/json", `{"access_token":"a","expires_in":"3600"}`, time.Hour, time.Hour},
+		{"application/json", `{"access_token":"a","expires_in":3600.0}`, -1, time.Hour},
+		{"application/json", `{"access_token":"a","expires_in":" 3600 "}`, -1, time.Hour},
+		{"application/json", `{"access_token":"a","expires":"3600"}`, 0, time.Hour},
+		{"application/json", `{"access_token":"a","expires_in":"never"}`, -1, 0},
+		{"application/x-www-form-urlencoded", `access_token=a&expires=3600`, 0, time.Hour},
+		{"application/x-www-form-urlencoded", `access_token=a&expires_in=3600.5`, 0, time.Hour},
+	}
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			io.WriteString(w, tt.body)
+		}))
+		for _, lenient := range []bool{false, true} {
+			want := tt.strict
+			if lenient {
+				want = tt.lenient
+			}
+			opts := &RetrieveOptions{Now: func() time.Time { return now }, LenientExpiry: lenient}
+			tok, err := RetrieveToken(context.Background(), "id", "", ts.URL, url.Values{}, AuthStyleInParams, nil, opts)
+			switch {
+			case want < 0:
+				if err == nil {
+					t.Errorf("%s (lenient=%v): RetrieveToken succeeded; want error", tt.body, lenient)
+				}
+			case err != nil:
+				t.Errorf("%s (lenient=%v): %v", tt.body, lenient, err)
+			case want == 0 && !tok.Expiry.IsZero():
+				t.Errorf("%s (lenient=%v): Expiry = %v; want none", tt.body, lenient, tok.Expiry)
+			case want > 0 && !tok.Expiry.Equal(now.Add(want)):
+				t.Errorf("%s (lenient=%v): Expiry = %v; want %v", tt.body, lenient, tok.Expiry, now.Add(want))
+			}
+		}
+		ts.Close()
+	}
+}
+
+func TestAuthStyleCacheLimits(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	c := &AuthStyleCache{MaxSize: 2, TTL: time.Hour}
+	c.SetAuthStyle("a", "", AuthStyleInHeader)
+	now = now.Add(time.Second)
+	c.SetAuthStyle("b", "", AuthStyleInParams)
+	now = now.Add(time.Second)
+	c.SetAuthStyle("c", "", AuthStyleInParams)
+	if _, ok := c.LookupAuthStyle("a", ""); ok {
+		t.Errorf("oldest entry was not evicted at MaxSize")
+	}
+	if got, ok := c.LookupAuthStyle("b", ""); !ok || got != AuthStyleInParams {
+		t.Errorf("LookupAuthStyle(b) = %v, %v; want %v, true", got, ok, AuthStyleInParams)
+	}
+	now = now.Add(time.Hour)
+	if _, ok := c.LookupAuthStyle("c", ""); ok {
+		t.Errorf("entry was used after TTL")
+	}
+	if got := c.Entries(); len(got) != 0 {
+		t.Errorf("Entries() after TTL = %v; want empty", got)
+	}
+}
+
+func TestAuthStyleCachePerClient(t *testing.T) {
+	c := new(AuthStyleCache)
+	c.SetAuthStyle("url", "", AuthStyleInHeader)
+	c.SetAuthStyle("url", "client1", AuthStyleInParams)
+	if got, ok := c.LookupAuthStyle("url", "client1"); !ok || got != AuthStyleInParams {
+		t.Errorf("LookupAuthStyle(url, client1) = %v, %v; want %v, true", got, ok, AuthStyleInParams)
+	}
+	if got, ok := c.LookupAuthStyle("url", "client2"); !ok || got != AuthStyleInHeader {
+		t.Errorf("LookupAuthStyle(url, client2) = %v, %v; want fallback %v, true", got, ok, AuthStyleInHeader)
+	}
+	if _, ok := c.LookupAuthStyle("other", "client1"); ok {
+		t.Errorf("LookupAuthStyle(other, client1) found an entry for another URL")
+	}
+}
+
+func TestRetrieveTokenTimeout(t *testing.T) {
+	styleCache := new(AuthStyleCache)
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	opts := &RetrieveOptions{Timeout: 50 * time.Millisecond}
+	_, err := RetrieveToken(context.Background(), "client-id", "", ts.URL, url.Values{}, AuthStyleInParams, styleCache, opts)
+	if err == nil {
+		t.Errorf("RetrieveToken = nil; want timeout error")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRetrieveTokenRetries(t *testing.T) {
+	errs := []error{
+		&net.DNSError{Err: "server misbehaving", IsTemporary: true},
+		syscall.ECONNRESET,
+		syscall.ECONNRESET,
+	}
+	var attempts int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if got, want := string(body), "client_id=client-id&grant_type=password"; got != want {
+			t.Errorf("attempt %d: body = %q; want %q", attempts, got, want)
+		}
+		if len(errs) > 0 {
+			err := errs[0]
+			errs = errs[1:]
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"tok","token_type":"bearer"}`)),
+		}, nil
+	})}
+	ctx := context.WithValue(context.Background(), HTTPClient, client)
+	v := url.Values{"grant_type": {"password"}}
+
+	opts := &RetrieveOptions{Retry: &RetryPolicy{DNSRetries: 1, ConnResetRetries: 2}}
+	tok, err := RetrieveToken(ctx, "client-id", "", "https://example.com/token", v, AuthStyleInParams, nil, opts)
+	if err != nil {
+		t.Fatalf("RetrieveToken: %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("AccessToken = %q; want %q", tok.AccessToken, "tok")
+	}
+	if attempts != 4 {
+		t.Errorf("attempts = %d; want 4", attempts)
+	}
+
+	// Connection resets don't use up the DNS budget, and vice versa.
+	errs = []error{syscall.ECONNRESET, syscall.ECONNRESET}
+	attempts = 0
+	opts.Retry = &RetryPolicy{DNSRetries: 5, ConnResetRetries: 1}
+	if _, err := RetrieveToken(ctx, "client-id", "", "https://example.com/token", v, AuthStyleInParams, nil, opts); err == nil {
+		t.Errorf("RetrieveToken = nil error; want connection reset")
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d; want 2", attempts)
+	}
+
+	// Hosts that do not exist are not retried.
+	errs = []error{&net.DNSError{Err: "no such host", IsNotFound: true}}
+	attempts = 0
+	if _, err := RetrieveToken(ctx, "client-id", "", "https://example.com/token", v, AuthStyleInParams, nil, opts); err == nil {
+		t.Errorf("RetrieveToken = nil error; want DNS error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d; want 1", attempts)
+	}
+}
+
+func TestDoWithRetriesNoBody(t *testing.T) {
+	var attempts int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, syscall.ECONNRESET
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"tok","token_type":"bearer"}`)),
+		}, nil
+	})}
+	ctx := context.WithValue(context.Background(), HTTPClient, client)
+	req, err := http.NewRequest("GET", "https://example.com/token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &RetrieveOptions{Retry: &RetryPolicy{ConnResetRetries: 1}}
+	if _, err := doWithRetries(ctx, req, opts); err != nil {
+		t.Fatalf("doWithRetries: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d; want 2", attempts)
+	}
+}
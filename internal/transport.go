@@ -6,6 +6,7 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 )
 
@@ -26,3 +27,28 @@ func ContextClient(ctx context.Context) *http.Client {
 	}
 	return http.DefaultClient
 }
+
+// CloneContextTransport returns a copy of the *http.Transport of the HTTP
+// client in ctx, or of http.DefaultTransport, with a non-nil TLS config.
+func CloneContextTransport(ctx context.Context) *http.Transport {
+	var tr *http.Transport
+	if base, ok := ContextClient(ctx).Transport.(*http.Transport); ok {
+		tr = base.Clone()
+	} else {
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{}
+	}
+	return tr
+}
+
+// MTLSClient returns an HTTP client, based on the client in ctx, that
+// presents the certificate returned by getCert to servers requesting one.
+// Callers should build it once and reuse it, as each client has its own
+// connection pool.
+func MTLSClient(ctx context.Context, getCert func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) *http.Client {
+	tr := CloneContextTransport(ctx)
+	tr.TLSClientConfig.GetClientCertificate = getCert
+	return &http.Client{Transport: tr}
+}
@@ -0,0 +1,360 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jwks fetches and parses JSON Web Key Sets, as used by providers
+// to publish the public keys that verify their signed tokens.
+//
+// See RFC 7517.
+package jwks // import "golang.org/x/oauth2/jwks"
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/internal"
+)
+
+// Key is a public key from a JSON Web Key Set.
+type Key struct {
+	// KeyID is the "kid" parameter.
+	KeyID string
+	// Algorithm is the "alg" parameter, such as "RS256". It may be empty.
+	Algorithm string
+	// Use is the "use" parameter, such as "sig". It may be empty.
+	Use string
+	// Key is the public key, either an *rsa.PublicKey or an *ecdsa.PublicKey.
+	Key crypto.PublicKey
+}
+
+// Set is a JSON Web Key Set.
+type Set struct {
+	Keys []Key
+}
+
+// Lookup returns the key with the given key ID.
+func (s *Set) Lookup(keyID string) (*Key, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == keyID {
+			return &s.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+type jsonKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Parse parses a JSON Web Key Set. RSA and EC keys are supported; keys of
+// other types are skipped.
+func Parse(data []byte) (*Set, error) {
+	var raw struct {
+		Keys []jsonKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("jwks: cannot parse key set: %v", err)
+	}
+	set := &Set{}
+	for _, k := range raw.Keys {
+		var pub crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			pub, err = k.rsaKey()
+		case "EC":
+			pub, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks: invalid key %q: %v", k.Kid, err)
+		}
+		set.Keys = append(set.Keys, Key{KeyID: k.Kid, Algorithm: k.Alg, Use: k.Use, Key: pub})
+	}
+	return set, nil
+}
+
+func (k jsonKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Source provides a JSON Web Key Set from a URL or a local file, caching
+// it between calls to KeySet.
+//
+// A Source must not be copied after first use.
+type Source struct {
+	// URL is the location of the key set. Exactly one of URL and File
+	// must be set.
+	URL string
+
+	// Header optionally specifies headers to send when fetching URL,
+	// such as a static API key required by the provider.
+	Header http.Header
+
+	// Client optionally specifies the HTTP client used to fetch URL.
+	// Configure its Transport with client certificates to fetch
+	// key sets protected by mutual TLS. If nil, the client from the
+	// context passed to KeySet is used, as with oauth2.HTTPClient.
+	Client *http.Client
+
+	// File is the path of a key set on disk. The file is read again
+	// whenever its modification time changes, so key rotation only
+	// requires replacing the file.
+	File string
+
+	// RefreshInterval optionally specifies how long a key set fetched
+	// from URL is cached. Zero means one hour.
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval optionally specifies the minimum time between
+	// fetches of URL, which bounds the fetches caused by Refresh and by
+	// failed fetches. Zero means one minute.
+	MinRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	set       *Set
+	fetched   time.Time     // when set was fetched from URL
+	attempted time.Time     // when URL was last fetched, successfully or not
+	lastErr   error         // error of the last fetch, if it failed
+	stale     bool          // Refresh was called since set was fetched
+	fetching  chan struct{} // closed when the fetch of URL in progress ends
+	modTime   time.Time     // modification time of File when set was read
+}
+
+// fetchTimeout bounds a fetch of Source.URL.
+const fetchTimeout = 30 * time.Second
+
+// timeNow is time.Now but pulled out as a variable for tests.
+var timeNow = time.Now
+
+// KeySet returns the current key set.
+//
+// A key set fetched from URL is fetched again once RefreshInterval has
+// elapsed, or after Refresh, but no more often than MinRefreshInterval.
+// While a fetch is in progress, or after it fails, the previous key set
+// is returned. Concurrent calls share a single fetch, which is not
+// canceled with ctx: if ctx is done first, KeySet returns its error and
+// the fetch completes for later calls.
+func (s *Source) KeySet(ctx context.Context) (*Set, error) {
+	switch {
+	case s.URL != "" && s.File != "":
+		return nil, errors.New("jwks: only one of URL and File may be set")
+	case s.File != "":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.fileKeySet()
+	case s.URL != "":
+		return s.urlKeySet(ctx)
+	}
+	return nil, errors.New("jwks: one of URL and File must be set")
+}
+
+// Refresh marks the cached key set as stale, so that the next call to
+// KeySet fetches it again from URL, subject to MinRefreshInterval. It is
+// typically called after a token names an unknown key. A key set read
+// from File is reloaded whenever the file changes, so Refresh has no
+// effect on it.
+func (s *Source) Refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = true
+}
+
+func (s *Source) fileKeySet() (*Set, error) {
+	fi, err := os.Stat(s.File)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %v", err)
+	}
+	if s.set != nil && fi.ModTime().Equal(s.modTime) {
+		return s.set, nil
+	}
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %v", err)
+	}
+	set, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	s.set, s.modTime = set, fi.ModTime()
+	return set, nil
+}
+
+func (s *Source) urlKeySet(ctx context.Context) (*Set, error) {
+	s.mu.Lock()
+	for {
+		if s.fetching == nil {
+			if set, ok, err := s.cached(); ok {
+				s.mu.Unlock()
+				return set, err
+			}
+			break
+		}
+		if s.set != nil {
+			set := s.set
+			s.mu.Unlock()
+			return set, nil
+		}
+		done := s.fetching
+		s.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		s.mu.Lock()
+	}
+	done := make(chan struct{})
+	s.fetching = done
+	s.mu.Unlock()
+
+	// The fetch is shared with concurrent callers and its result is
+	// cached, so it must not fail because the caller that started it
+	// gave up: it runs on a context of its own, bounded by
+	// fetchTimeout.
+	client := s.Client
+	if client == nil {
+		client = internal.ContextClient(ctx)
+	}
+	go func() {
+		fctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		set, err := s.fetch(fctx, client)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetching = nil
+		close(done)
+		s.attempted, s.lastErr = timeNow(), err
+		if err == nil {
+			s.set, s.fetched, s.stale = set, s.attempted, false
+		}
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set != nil {
+		return s.set, nil
+	}
+	return nil, s.lastErr
+}
+
+// cached reports the result of KeySet if no fetch is due. s.mu must be
+// held.
+func (s *Source) cached() (*Set, bool, error) {
+	refresh := s.RefreshInterval
+	if refresh == 0 {
+		refresh = time.Hour
+	}
+	minRefresh := s.MinRefreshInterval
+	if minRefresh == 0 {
+		minRefresh = time.Minute
+	}
+	now := timeNow()
+	if !s.attempted.IsZero() && now.Sub(s.attempted) < minRefresh {
+		if s.set != nil {
+			return s.set, true, nil
+		}
+		return nil, true, s.lastErr
+	}
+	if s.set != nil && !s.stale && now.Sub(s.fetched) < refresh {
+		return s.set, true, nil
+	}
+	return nil, false, nil
+}
+
+// fetch fetches the key set from URL with client.
+func (s *Source) fetch(ctx context.Context, client *http.Client) (*Set, error) {
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: cannot fetch key set: %v", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("jwks: cannot fetch key set: %v", err)
+	}
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return nil, fmt.Errorf("jwks: cannot fetch key set: %v\nResponse: %s", resp.Status, body)
+	}
+	return Parse(body)
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func testKeySet(t *testing.T) (string, *rsa.PublicKey, *ecdsa.PublicKey) {
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa1","alg":"RS256","use":"sig","n":%q,"e":%q},
+		{"kty":"EC","kid":"ec1","crv":"P-256","x":%q,"y":%q},
+		{"kty":"oct","kid":"sym","k":"c2VjcmV0"}
+	]}`, b64(rk.N.Bytes()), b64(big.NewInt(int64(rk.E)).Bytes()), b64(ek.X.Bytes()), b64(ek.Y.Bytes()))
+	return data, &rk.PublicKey, &ek.PublicKey
+}
+
+func TestParse(t *testing.T) {
+	data, rk, ek := testKeySet(t)
+	set, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(set.Keys), 2; got != want {
+		t.Fatalf("len(Keys) = %d; want %d", got, want)
+	}
+	k, ok := set.Lookup("rsa1")
+	if !ok {
+		t.Fatal("Lookup(rsa1) failed")
+	}
+	if !rk.Equal(k.Key) || k.Algorithm != "RS256" || k.Use != "sig" {
+		t.Errorf("Lookup(rsa1) = %+v; want RS256 signing key %v", k, rk)
+	}
+	k, ok = set.Lookup("ec1")
+	if !ok || !ek.Equal(k.Key) {
+		t.Errorf("Lookup(ec1) = %+v, %v; want %v", k, ok, ek)
+	}
+	if _, ok := set.Lookup("sym"); ok {
+		t.Errorf("Lookup(sym) found unsupported key")
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	data, _, _ := testKeySet(t)
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("X-Api-Key"), "secret"; got != want {
+			t.Errorf("X-Api-Key = %q; want %q", got, want)
+		}
+		fetches++
+		w.Write([]byte(data))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	src := &Source{URL: ts.URL, Header: http.Header{"X-Api-Key": {"secret"}}}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := src.KeySet(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times before RefreshInterval; want 1", fetches)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := src.KeySet(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("fetched %d times after RefreshInterval; want 2", fetches)
+	}
+}
+
+func TestSourceRefreshThrottled(t *testing.T) {
+	data, _, _ := testKeySet(t)
+	fetches, fail := 0, false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	src := &Source{URL: ts.URL}
+	ctx := context.Background()
+	if _, err := src.KeySet(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		src.Refresh()
+		if _, err := src.KeySet(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times after Refresh within MinRefreshInterval; want 1", fetches)
+	}
+
+	now = now.Add(time.Minute)
+	fail = true
+	src.Refresh()
+	set, err := src.KeySet(ctx)
+	if err != nil {
+		t.Fatalf("KeySet after a failed fetch = %v; want the previous key set", err)
+	}
+	if _, ok := set.Lookup("rsa1"); !ok || fetches != 2 {
+		t.Errorf("KeySet after Refresh: fetches = %d, key set = %+v; want 2 fetches and the previous key set", fetches, set)
+	}
+	if _, err := src.KeySet(ctx); err != nil || fetches != 2 {
+		t.Errorf("KeySet after a failed fetch: fetches = %d, err = %v; want no new fetch", fetches, err)
+	}
+}
+
+func TestSourceCanceledFetch(t *testing.T) {
+	data, _, _ := testKeySet(t)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(data))
+	}))
+	defer ts.Close()
+
+	src := &Source{URL: ts.URL}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := src.KeySet(ctx); err != context.Canceled {
+		t.Fatalf("KeySet with a canceled context = %v; want context.Canceled", err)
+	}
+	close(release)
+
+	// The fetch started by the canceled call completes and is used by
+	// the next call, instead of a cached context.Canceled error.
+	set, err := src.KeySet(context.Background())
+	if err != nil {
+		t.Fatalf("KeySet after a canceled call: %v", err)
+	}
+	if _, ok := set.Lookup("rsa1"); !ok {
+		t.Errorf("KeySet after a canceled call = %+v; want the fetched key set", set)
+	}
+}
+
+func TestSourceFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	src := &Source{File: path}
+	set, err := src.KeySet(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 0 {
+		t.Fatalf("len(Keys) = %d; want 0", len(set.Keys))
+	}
+
+	data, _, _ := testKeySet(t)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	set, err = src.KeySet(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set.Lookup("rsa1"); !ok {
+		t.Errorf("rotated key set was not reloaded")
+	}
+}
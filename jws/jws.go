@@ -38,6 +38,7 @@ type ClaimSet struct {
 	Exp   int64  `json:"exp"`             // the expiration time of the assertion (seconds since Unix epoch)
 	Iat   int64  `json:"iat"`             // the time the assertion was issued (seconds since Unix epoch)
 	Typ   string `json:"typ,omitempty"`   // token type (Optional).
+	Jti   string `json:"jti,omitempty"`   // unique identifier of the assertion (Optional).
 
 	// Email for which the application is requesting delegated access (Optional).
 	Sub string `json:"sub,omitempty"`
@@ -103,6 +104,14 @@ type Header struct {
 
 	// The optional hint of which key is being used.
 	KeyID string `json:"kid,omitempty"`
+
+	// The optional base64url-encoded SHA-1 thumbprint of the DER
+	// encoding of the X.509 certificate of the key.
+	X5T string `json:"x5t,omitempty"`
+
+	// The optional base64url-encoded SHA-256 thumbprint of the DER
+	// encoding of the X.509 certificate of the key.
+	X5TS256 string `json:"x5t#S256,omitempty"`
 }
 
 func (h *Header) encode() (string, error) {
@@ -10,17 +10,26 @@ package jwt
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/internal"
+	internaljws "golang.org/x/oauth2/internal/jws"
 	"golang.org/x/oauth2/jws"
 )
 
@@ -39,17 +48,40 @@ type Config struct {
 	// PrivateKey contains the contents of an RSA private key or the
 	// contents of a PEM file that contains a private key. The provided
 	// private key is used to sign JWT payloads.
-	// PEM containers with a passphrase are not supported.
-	// Use the following command to convert a PKCS 12 file into a PEM.
+	// Encrypted PKCS 8 keys ("ENCRYPTED PRIVATE KEY" PEM blocks using
+	// PBES2 with AES-CBC) are supported if PrivateKeyPassword is set.
+	// Legacy encrypted PEM containers ("Proc-Type: 4,ENCRYPTED") are also
+	// accepted, but that format is insecure and should be avoided; convert
+	// such keys with "openssl pkcs8 -topk8". PKCS 12 files are not supported;
+	// use the following command to convert a PKCS 12 file into a PEM.
 	//
 	//    $ openssl pkcs12 -in key.p12 -out key.pem -nodes
 	//
 	PrivateKey []byte
 
+	// PrivateKeyPassword optionally specifies the passphrase used to
+	// decrypt PrivateKey.
+	PrivateKeyPassword []byte
+
 	// PrivateKeyID contains an optional hint indicating which key is being
 	// used.
 	PrivateKeyID string
 
+	// Certificate optionally contains the PEM-encoded X.509 certificate
+	// of PrivateKey. If set, its SHA-1 and SHA-256 thumbprints are sent
+	// in the x5t and x5t#S256 header parameters of the assertion, as
+	// required for certificate-based assertions by providers such as
+	// Azure AD and Ping Identity.
+	Certificate []byte
+
+	// HeaderType optionally specifies the typ header parameter of the
+	// assertion. If empty, "JWT" is used.
+	HeaderType string
+
+	// Headers optionally specifies additional header parameters of the
+	// assertion. They must not repeat alg, typ, kid, x5t or x5t#S256.
+	Headers map[string]interface{}
+
 	// Subject is the optional user to impersonate.
 	Subject string
 
@@ -59,7 +91,9 @@ type Config struct {
 	// TokenURL is the endpoint required to complete the 2-legged JWT flow.
 	TokenURL string
 
-	// Expires optionally specifies how long the token is valid for.
+	// Expires optionally specifies the lifetime of the assertion, which
+	// bounds how long the token is valid for with some providers.
+	// If zero, one hour is used.
 	Expires time.Duration
 
 	// Audience optionally specifies the intended audience of the
@@ -74,6 +108,24 @@ type Config struct {
 	// UseIDToken optionally specifies whether ID token should be used instead
 	// of access token when the server returns both.
 	UseIDToken bool
+
+	// GenerateJTI optionally includes a unique jti claim in each
+	// assertion, for providers that reject replayed assertions.
+	GenerateJTI bool
+
+	// TokenCache optionally is where the token sources of c cache the
+	// tokens they fetch, keyed by the claims of their assertion, the
+	// signing key, TokenURL and UseIDToken, so
+	// that a valid token is reused instead of signing a new assertion
+	// and requesting a new token. Tokens without an expiry are reused
+	// for the lifetime of their assertion. Configs, including copies
+	// of c, share the tokens of the TokenCache they point to.
+	TokenCache *TokenCache
+
+	// Now optionally returns the current time, used for the iat and
+	// exp claims of the assertion, the Expiry of tokens and to check
+	// their validity. The default is time.Now.
+	Now func() time.Time
 }
 
 // TokenSource returns a JWT TokenSource using the configuration
@@ -82,6 +134,16 @@ func (c *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
 	return oauth2.ReuseTokenSource(nil, jwtSource{ctx, c})
 }
 
+// TokenSourceWithInitialToken is like TokenSource, but returns t until it
+// expires instead of fetching a new token on first use. The token is
+// checked with oauth2.ValidateToken.
+func (c *Config) TokenSourceWithInitialToken(ctx context.Context, t *oauth2.Token) (oauth2.TokenSource, error) {
+	if err := oauth2.ValidateToken(t); err != nil {
+		return nil, err
+	}
+	return oauth2.ReuseTokenSource(t, jwtSource{ctx, c}), nil
+}
+
 // Client returns an HTTP client wrapping the context's
 // HTTP transport and adding Authorization headers with tokens
 // obtained from c.
@@ -91,7 +153,8 @@ func (c *Config) Client(ctx context.Context) *http.Client {
 	return oauth2.NewClient(ctx, c.TokenSource(ctx))
 }
 
-// jwtSource is a source that always does a signed JWT request for a token.
+// jwtSource is a source that does a signed JWT request for a token,
+// unless TokenCache is set and holds a valid token.
 // It should typically be wrapped with a reuseTokenSource.
 type jwtSource struct {
 	ctx  context.Context
@@ -99,12 +162,21 @@ type jwtSource struct {
 }
 
 func (js jwtSource) Token() (*oauth2.Token, error) {
-	pk, err := internal.ParseKey(js.conf.PrivateKey)
+	pk, err := internal.ParseKeyWithPassword(js.conf.PrivateKey, js.conf.PrivateKeyPassword)
 	if err != nil {
 		return nil, err
 	}
 	hc := oauth2.NewClient(js.ctx, nil)
+	now := time.Now
+	if js.conf.Now != nil {
+		now = js.conf.Now
+	}
+	// Reverting time back for machines whose time is not perfectly in
+	// sync, as jws.Encode does.
+	iat := now().Add(-10 * time.Second)
 	claimSet := &jws.ClaimSet{
+		Iat:           iat.Unix(),
+		Exp:           iat.Add(time.Hour).Unix(),
 		Iss:           js.conf.Email,
 		Scope:         strings.Join(js.conf.Scopes, " "),
 		Aud:           js.conf.TokenURL,
@@ -117,14 +189,36 @@ func (js jwtSource) Token() (*oauth2.Token, error) {
 		claimSet.Prn = subject
 	}
 	if t := js.conf.Expires; t > 0 {
-		claimSet.Exp = time.Now().Add(t).Unix()
+		claimSet.Exp = now().Add(t).Unix()
 	}
 	if aud := js.conf.Audience; aud != "" {
 		claimSet.Aud = aud
 	}
+	cache := js.conf.TokenCache
+	var key string
+	if cache != nil {
+		key = cacheKey(js.conf, claimSet)
+		if t := cache.get(key, now); t != nil {
+			return t, nil
+		}
+	}
+	if js.conf.GenerateJTI {
+		if claimSet.Jti, err = newJTI(); err != nil {
+			return nil, err
+		}
+	}
 	h := *defaultHeader
 	h.KeyID = js.conf.PrivateKeyID
-	payload, err := jws.Encode(&h, claimSet, pk)
+	if typ := js.conf.HeaderType; typ != "" {
+		h.Typ = typ
+	}
+	if len(js.conf.Certificate) > 0 {
+		h.X5T, h.X5TS256, err = thumbprints(js.conf.Certificate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	payload, err := internaljws.EncodeWithExtraHeader(&h, js.conf.Headers, claimSet, internaljws.RS256Signer(pk))
 	if err != nil {
 		return nil, err
 	}
@@ -156,16 +250,17 @@ func (js jwtSource) Token() (*oauth2.Token, error) {
 	if err := json.Unmarshal(body, &tokenRes); err != nil {
 		return nil, fmt.Errorf("oauth2: cannot fetch token: %v", err)
 	}
-	token := &oauth2.Token{
+	token := &internal.Token{
 		AccessToken: tokenRes.AccessToken,
 		TokenType:   tokenRes.TokenType,
+		Response:    internal.ResponseMetadata(resp),
 	}
 	raw := make(map[string]interface{})
 	json.Unmarshal(body, &raw) // no error checks for optional fields
-	token = token.WithExtra(raw)
+	token.Raw = raw
 
 	if secs := tokenRes.ExpiresIn; secs > 0 {
-		token.Expiry = time.Now().Add(time.Duration(secs) * time.Second)
+		token.Expiry = now().Add(time.Duration(secs) * time.Second)
 	}
 	if v := tokenRes.IDToken; v != "" {
 		// decode returned id token to get expiry
@@ -181,5 +276,91 @@ func (js jwtSource) Token() (*oauth2.Token, error) {
 		}
 		token.AccessToken = tokenRes.IDToken
 	}
-	return token, nil
+	if cache != nil {
+		if token.Expiry.IsZero() {
+			token.Expiry = time.Unix(claimSet.Exp, 0)
+		}
+		cache.set(key, token)
+	}
+	return internal.PublicToken(token, js.conf.Now).(*oauth2.Token), nil
+}
+
+// TokenCache is a cache of the tokens fetched by the token sources of
+// Configs, keyed by the claims of the assertion they were fetched with
+// and the settings of the Config that change the token returned.
+// See Config.TokenCache.
+//
+// The zero value is an empty cache ready to use. A TokenCache must not
+// be copied after first use.
+type TokenCache struct {
+	mu sync.Mutex
+	m  map[string]*internal.Token
+}
+
+// get returns a copy of the token cached for key, if it is valid at the
+// time returned by now.
+func (tc *TokenCache) get(key string, now func() time.Time) *oauth2.Token {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	t, ok := tc.m[key]
+	if !ok {
+		return nil
+	}
+	// PublicToken returns a new token, as callers such as
+	// oauth2.ReuseTokenSource modify the tokens they are given.
+	tok := internal.PublicToken(t, now).(*oauth2.Token)
+	if !tok.Valid() {
+		delete(tc.m, key)
+		return nil
+	}
+	return tok
+}
+
+func (tc *TokenCache) set(key string, t *internal.Token) {
+	t2 := *t
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.m == nil {
+		tc.m = make(map[string]*internal.Token)
+	}
+	tc.m[key] = &t2
+}
+
+// cacheKey returns the key of tokens fetched by conf with assertions of
+// c, which excludes the claims that differ between assertions.
+func cacheKey(conf *Config, c *jws.ClaimSet) string {
+	key := sha256.Sum256(conf.PrivateKey)
+	b, _ := json.Marshal(struct {
+		Iss, Scope, Aud, Sub string
+		Private              map[string]interface{}
+		TokenURL             string
+		UseIDToken           bool
+		KeyID                string
+		Key                  []byte
+	}{c.Iss, c.Scope, c.Aud, c.Sub, c.PrivateClaims, conf.TokenURL, conf.UseIDToken, conf.PrivateKeyID, key[:]})
+	return string(b)
+}
+
+// newJTI returns a random JWT ID.
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("oauth2: cannot generate jti: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// thumbprints returns the base64url-encoded SHA-1 and SHA-256
+// thumbprints of the PEM-encoded certificate cert.
+func thumbprints(cert []byte) (x5t, x5tS256 string, err error) {
+	block, _ := pem.Decode(cert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", "", errors.New("oauth2: Certificate is not a PEM-encoded certificate")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return "", "", fmt.Errorf("oauth2: cannot parse Certificate: %v", err)
+	}
+	sum1 := sha1.Sum(block.Bytes)
+	sum256 := sha256.Sum256(block.Bytes)
+	return base64.RawURLEncoding.EncodeToString(sum1[:]), base64.RawURLEncoding.EncodeToString(sum256[:]), nil
 }
@@ -6,16 +6,25 @@ package jwt
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/internal"
 	"golang.org/x/oauth2/jws"
 )
 
@@ -192,6 +201,70 @@ func TestJWTFetch_Assertion(t *testing.T) {
 	}
 }
 
+func TestJWTFetch_AssertionHeaders(t *testing.T) {
+	var assertion string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assertion = r.Form.Get("assertion")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "90d", "token_type": "bearer", "expires_in": 3600}`))
+	}))
+	defer ts.Close()
+
+	key, err := internal.ParseKey(dummyPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &Config{
+		Email:        "aaa@xxx.com",
+		PrivateKey:   dummyPrivateKey,
+		PrivateKeyID: "kid",
+		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		HeaderType:   "client-authentication+jwt",
+		Headers:      map[string]interface{}{"private": "p"},
+		TokenURL:     ts.URL,
+	}
+	if _, err := conf.TokenSource(context.Background()).Token(); err != nil {
+		t.Fatalf("Failed to fetch token: %v", err)
+	}
+
+	gotjson, err := base64.RawURLEncoding.DecodeString(strings.Split(assertion, ".")[0])
+	if err != nil {
+		t.Fatalf("invalid token header; err = %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(gotjson, &got); err != nil {
+		t.Fatalf("failed to unmarshal json token header = %q; err = %v", gotjson, err)
+	}
+	sum1, sum256 := sha1.Sum(der), sha256.Sum256(der)
+	want := map[string]string{
+		"alg":      "RS256",
+		"typ":      "client-authentication+jwt",
+		"kid":      "kid",
+		"x5t":      base64.RawURLEncoding.EncodeToString(sum1[:]),
+		"x5t#S256": base64.RawURLEncoding.EncodeToString(sum256[:]),
+		"private":  "p",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assertion header = %v; want %v", got, want)
+	}
+
+	conf.Certificate = []byte("not a certificate")
+	if _, err := conf.TokenSource(context.Background()).Token(); err == nil {
+		t.Error("Token with an invalid Certificate succeeded")
+	}
+}
+
 func TestJWTFetch_AssertionPayload(t *testing.T) {
 	var assertion string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -288,6 +361,137 @@ func TestJWTFetch_AssertionPayload(t *testing.T) {
 	}
 }
 
+func TestJWTFetch_Now(t *testing.T) {
+	var assertion string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assertion = r.Form.Get("assertion")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "90d", "token_type": "bearer", "expires_in": 3600}`))
+	}))
+	defer ts.Close()
+
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	conf := &Config{
+		Email:      "aaa@xxx.com",
+		PrivateKey: dummyPrivateKey,
+		TokenURL:   ts.URL,
+		Now:        func() time.Time { return now },
+	}
+	tok, err := conf.TokenSource(context.Background()).Token()
+	if err != nil {
+		t.Fatalf("Failed to fetch token: %v", err)
+	}
+	if want := now.Add(time.Hour); !tok.Expiry.Equal(want) {
+		t.Errorf("Expiry = %v; want %v", tok.Expiry, want)
+	}
+	if !tok.Valid() {
+		t.Error("token invalid at the injected time")
+	}
+
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		t.Fatalf("assertion = %q; want 3 parts", assertion)
+	}
+	gotjson, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("invalid token payload; err = %v", err)
+	}
+	var claimSet jws.ClaimSet
+	if err := json.Unmarshal(gotjson, &claimSet); err != nil {
+		t.Fatalf("failed to unmarshal json token payload = %q; err = %v", gotjson, err)
+	}
+	if got, want := claimSet.Iat, now.Add(-10*time.Second).Unix(); got != want {
+		t.Errorf("payload iat = %v; want %v", got, want)
+	}
+	if got, want := claimSet.Exp, now.Add(time.Hour-10*time.Second).Unix(); got != want {
+		t.Errorf("payload exp = %v; want %v", got, want)
+	}
+}
+
+func TestJWTFetch_CacheTokensAndJTI(t *testing.T) {
+	var jtis []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		claimSet, err := jws.Decode(r.Form.Get("assertion"))
+		if err != nil {
+			t.Errorf("invalid assertion: %v", err)
+		}
+		jtis = append(jtis, claimSet.Jti)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "90d", "token_type": "bearer"}`))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	conf := &Config{
+		Email:       "aaa@xxx.com",
+		PrivateKey:  dummyPrivateKey,
+		TokenURL:    ts.URL,
+		Expires:     time.Minute,
+		GenerateJTI: true,
+		TokenCache:  new(TokenCache),
+		Now:         func() time.Time { return now },
+	}
+	for i := 0; i < 2; i++ {
+		tok, err := conf.TokenSource(context.Background()).Token()
+		if err != nil {
+			t.Fatalf("Failed to fetch token: %v", err)
+		}
+		if d := tok.Expiry.Sub(now); d <= 0 || d > time.Minute {
+			t.Errorf("Expiry in %v; want within the assertion lifetime", d)
+		}
+	}
+	if len(jtis) != 1 {
+		t.Fatalf("made %d token requests; want 1", len(jtis))
+	}
+
+	// A copy of conf shares its cache.
+	copied := *conf
+	if _, err := copied.TokenSource(context.Background()).Token(); err != nil {
+		t.Fatalf("Failed to fetch token: %v", err)
+	}
+	if len(jtis) != 1 {
+		t.Fatalf("made %d token requests with a copy of the Config; want 1", len(jtis))
+	}
+
+	conf.Scopes = []string{"other"}
+	if _, err := conf.TokenSource(context.Background()).Token(); err != nil {
+		t.Fatalf("Failed to fetch token: %v", err)
+	}
+	if len(jtis) != 2 {
+		t.Fatalf("made %d token requests; want 2 after changing the claims", len(jtis))
+	}
+	if jtis[0] == "" || jtis[0] == jtis[1] {
+		t.Errorf("jti claims = %q; want unique values", jtis)
+	}
+
+	// Configs that would get different tokens for the same claims do
+	// not share them.
+	claims := &jws.ClaimSet{Iss: "aaa@xxx.com"}
+	for name, modify := range map[string]func(*Config){
+		"UseIDToken":   func(c *Config) { c.UseIDToken = true },
+		"PrivateKeyID": func(c *Config) { c.PrivateKeyID = "other" },
+		"PrivateKey":   func(c *Config) { c.PrivateKey = append([]byte("\n"), c.PrivateKey...) },
+		"TokenURL":     func(c *Config) { c.TokenURL += "/other" },
+	} {
+		other := *conf
+		modify(&other)
+		if cacheKey(conf, claims) == cacheKey(&other, claims) {
+			t.Errorf("changing %s keeps the cache key", name)
+		}
+	}
+
+	// Cached tokens expire according to Config.Now.
+	now = now.Add(2 * time.Minute)
+	if _, err := conf.TokenSource(context.Background()).Token(); err != nil {
+		t.Fatalf("Failed to fetch token: %v", err)
+	}
+	if len(jtis) != 3 {
+		t.Fatalf("made %d token requests; want 3 after the cached token expired", len(jtis))
+	}
+}
+
 func TestTokenRetrieveError(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-type", "application/json")
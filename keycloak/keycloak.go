@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keycloak implements Keycloak requests that are not part of
+// OAuth 2.0, for clients of the endpoints returned by endpoints.Keycloak.
+package keycloak // import "golang.org/x/oauth2/keycloak"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/internal"
+)
+
+// Logout ends the Keycloak session of refreshToken, which was issued to
+// the client of conf, invalidating it along with the access tokens of the
+// session. conf.Endpoint must be a Keycloak endpoint, as returned by
+// endpoints.Keycloak; the logout endpoint is found next to its TokenURL.
+//
+// The client authenticates as in token requests, with
+// conf.Endpoint.AuthStyle or, if it is AuthStyleAutoDetect, the style
+// recorded in conf.AuthStyleCache for the token endpoint. If no style is
+// known, the client credentials are sent in the request body, which
+// Keycloak accepts for both public and confidential clients.
+//
+// The HTTP client used is taken from ctx as with oauth2.Config.Exchange.
+// Errors returned by the server are of type *oauth2.RetrieveError.
+func Logout(ctx context.Context, conf *oauth2.Config, refreshToken string) error {
+	logoutURL := strings.TrimSuffix(conf.Endpoint.TokenURL, "/token")
+	if logoutURL == conf.Endpoint.TokenURL {
+		return errors.New("keycloak: not a Keycloak token URL: " + conf.Endpoint.TokenURL)
+	}
+	logoutURL += "/logout"
+
+	style := conf.Endpoint.AuthStyle
+	if style == oauth2.AuthStyleAutoDetect {
+		style = oauth2.AuthStyleInParams
+		if conf.AuthStyleCache != nil {
+			if s, ok := conf.AuthStyleCache.LookupClient(conf.Endpoint.TokenURL, conf.ClientID); ok {
+				style = s
+			}
+		}
+	}
+	v := url.Values{"refresh_token": {refreshToken}}
+	req, err := internal.NewClientRequest(logoutURL, conf.ClientID, conf.ClientSecret, v, internal.AuthStyle(style), conf.BasicAuthEncoding == oauth2.BasicAuthRaw)
+	if err != nil {
+		return err
+	}
+	r, err := internal.ContextClient(ctx).Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+	re := &oauth2.RetrieveError{Response: r, Body: body}
+	var e struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		re.ErrorCode = e.Error
+		re.ErrorDescription = e.ErrorDescription
+	}
+	return re
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+func TestLogout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/test/protocol/openid-connect/logout" {
+			t.Errorf("request to %s", r.URL.Path)
+		}
+		if got := r.FormValue("client_id"); got != "app" {
+			t.Errorf("client_id = %q; want app", got)
+		}
+		if r.FormValue("refresh_token") != "good" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid refresh token"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	conf := &oauth2.Config{
+		ClientID:     "app",
+		ClientSecret: "secret",
+		Endpoint:     endpoints.Keycloak(ts.URL, "test"),
+	}
+	ctx := context.Background()
+	if err := Logout(ctx, conf, "good"); err != nil {
+		t.Fatal(err)
+	}
+	err := Logout(ctx, conf, "bad")
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.ErrorCode != "invalid_grant" {
+		t.Errorf("Logout with a bad token = %v; want invalid_grant", err)
+	}
+}
+
+func TestLogoutAuthStyle(t *testing.T) {
+	var gotUser, gotParam string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _, _ = r.BasicAuth()
+		gotParam = r.FormValue("client_id")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	conf := &oauth2.Config{
+		ClientID:       "app",
+		ClientSecret:   "secret",
+		Endpoint:       endpoints.Keycloak(ts.URL, "test"),
+		AuthStyleCache: new(oauth2.AuthStyleCache),
+	}
+	conf.AuthStyleCache.SetClient(conf.Endpoint.TokenURL, "app", oauth2.AuthStyleInHeader)
+	if err := Logout(context.Background(), conf, "good"); err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != "app" || gotParam != "" {
+		t.Errorf("basic auth user = %q, client_id = %q; want credentials in the header only", gotUser, gotParam)
+	}
+
+	conf.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	if err := Logout(context.Background(), conf, "good"); err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != "" || gotParam != "app" {
+		t.Errorf("basic auth user = %q, client_id = %q; want credentials in the body only", gotUser, gotParam)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/internal"
+)
+
+// ErrTokenRequestLimited is returned, possibly wrapped, for token
+// requests rejected by a TokenRequestLimiter.
+var ErrTokenRequestLimited = errors.New("oauth2: token request limited")
+
+// TokenRequestLimiter protects a token endpoint from hot refresh loops,
+// such as those caused by a misbehaving provider or revoked client
+// credentials. It combines a token bucket, which caps the number of
+// requests per minute, with a circuit breaker, which rejects requests
+// for a cool-down period after the endpoint reports an invalid_client
+// error. Rejected requests fail immediately without contacting the
+// endpoint.
+//
+// A TokenRequestLimiter is used by setting the Limiter field of a
+// Config. It may be shared by several Configs and is safe for
+// concurrent use.
+type TokenRequestLimiter struct {
+	// MaxPerMinute is the maximum number of token requests per
+	// minute, allowing bursts of up to MaxPerMinute requests. Zero
+	// means no limit.
+	MaxPerMinute int
+
+	// InvalidClientCooldown is how long requests are rejected after
+	// an invalid_client error. Zero means one minute; a negative
+	// value disables the circuit breaker.
+	InvalidClientCooldown time.Duration
+
+	mu        sync.Mutex
+	tokens    float64   // available requests in the bucket
+	last      time.Time // when tokens was last updated
+	openUntil time.Time // the circuit breaker rejects requests until then
+	lastErr   error     // the error that opened the circuit breaker
+}
+
+// Allow reports whether a token request may be made now. It returns an
+// error wrapping ErrTokenRequestLimited if not. Each successful call
+// uses up one request of the per-minute budget.
+func (l *TokenRequestLimiter) Allow() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := timeNow()
+	if now.Before(l.openUntil) {
+		return fmt.Errorf("%w until %v after %v", ErrTokenRequestLimited, l.openUntil.Format(time.RFC3339), l.lastErr)
+	}
+	if l.MaxPerMinute <= 0 {
+		return nil
+	}
+	max := float64(l.MaxPerMinute)
+	if l.last.IsZero() {
+		l.tokens = max
+	} else {
+		l.tokens += now.Sub(l.last).Minutes() * max
+		if l.tokens > max {
+			l.tokens = max
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return fmt.Errorf("%w: more than %d requests per minute", ErrTokenRequestLimited, l.MaxPerMinute)
+	}
+	l.tokens--
+	return nil
+}
+
+// Record records the result of a token request allowed by Allow,
+// opening the circuit breaker if err is an invalid_client error.
+func (l *TokenRequestLimiter) Record(err error) {
+	if err == nil || l.InvalidClientCooldown < 0 {
+		return
+	}
+	var code string
+	var re *RetrieveError
+	var ire *internal.RetrieveError
+	switch {
+	case errors.As(err, &re):
+		code = re.ErrorCode
+	case errors.As(err, &ire):
+		code = ire.ErrorCode
+	}
+	if code != "invalid_client" {
+		return
+	}
+	cooldown := l.InvalidClientCooldown
+	if cooldown == 0 {
+		cooldown = time.Minute
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.openUntil = timeNow().Add(cooldown)
+	l.lastErr = err
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenRequestLimiterRate(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	l := &TokenRequestLimiter{MaxPerMinute: 2}
+	for i := 0; i < 2; i++ {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if err := l.Allow(); !errors.Is(err, ErrTokenRequestLimited) {
+		t.Fatalf("third request: %v; want ErrTokenRequestLimited", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := l.Allow(); err != nil {
+		t.Errorf("request after refill: %v", err)
+	}
+}
+
+func TestTokenRequestLimiterInvalidClient(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	conf := &Config{
+		ClientID: "id",
+		Endpoint: Endpoint{TokenURL: ts.URL, AuthStyle: AuthStyleInParams},
+		Limiter:  &TokenRequestLimiter{InvalidClientCooldown: time.Minute},
+	}
+	_, err := conf.Exchange(context.Background(), "code")
+	var re *RetrieveError
+	if !errors.As(err, &re) || re.ErrorCode != "invalid_client" {
+		t.Fatalf("first Exchange: %v; want invalid_client", err)
+	}
+	if _, err := conf.Exchange(context.Background(), "code"); !errors.Is(err, ErrTokenRequestLimited) {
+		t.Errorf("Exchange during cool-down: %v; want ErrTokenRequestLimited", err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests; want 1", requests)
+	}
+	now = now.Add(2 * time.Minute)
+	conf.Exchange(context.Background(), "code")
+	if requests != 2 {
+		t.Errorf("%d requests after cool-down; want 2", requests)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MACAuthorizer is a TokenAuthorizer for MAC access tokens, as described
+// in draft-ietf-oauth-v2-http-mac-02. It signs each request with the
+// "mac_key" and "mac_algorithm" (hmac-sha-1 or hmac-sha-256) returned
+// with the token, and authorizes requests with tokens of other types
+// with Token.SetAuthHeader.
+type MACAuthorizer struct {
+	// Ext optionally returns the "ext" attribute for req, which
+	// carries application-specific data covered by the MAC.
+	Ext func(req *http.Request) string
+
+	// Now optionally returns the current time, used for the "ts"
+	// attribute. The default is time.Now.
+	Now func() time.Time
+}
+
+// AuthorizeRequest implements TokenAuthorizer.
+func (a *MACAuthorizer) AuthorizeRequest(req *http.Request, t *Token) error {
+	if t.Type() != "MAC" {
+		t.SetAuthHeader(req)
+		return nil
+	}
+	key, _ := t.Extra("mac_key").(string)
+	if key == "" {
+		return errors.New("oauth2: MAC token has no mac_key")
+	}
+	var h func() hash.Hash
+	switch alg, _ := t.Extra("mac_algorithm").(string); alg {
+	case "hmac-sha-1":
+		h = sha1.New
+	case "hmac-sha-256":
+		h = sha256.New
+	default:
+		return fmt.Errorf("oauth2: unsupported MAC algorithm %q", alg)
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	ts := strconv.FormatInt(now().Unix(), 10)
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	var ext string
+	if a.Ext != nil {
+		ext = a.Ext(req)
+	}
+
+	mac := hmac.New(h, []byte(key))
+	mac.Write([]byte(macNormalizedRequest(req, ts, nonce, ext)))
+	header := fmt.Sprintf(`MAC id=%q, ts=%q, nonce=%q`, t.AccessToken, ts, nonce)
+	if ext != "" {
+		header += fmt.Sprintf(`, ext=%q`, ext)
+	}
+	header += fmt.Sprintf(`, mac=%q`, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Authorization", header)
+	return nil
+}
+
+// macNormalizedRequest returns the normalized request string of req
+// covered by the MAC.
+func macNormalizedRequest(req *http.Request, ts, nonce, ext string) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	port := "80"
+	if req.URL.Scheme == "https" {
+		port = "443"
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	return strings.Join([]string{
+		ts,
+		nonce,
+		req.Method,
+		req.URL.RequestURI(),
+		strings.ToLower(host),
+		port,
+		ext,
+	}, "\n") + "\n"
+}
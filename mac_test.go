@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMACAuthorizer(t *testing.T) {
+	tok := (&Token{AccessToken: "h480djs93hd8", TokenType: "mac"}).WithExtra(map[string]interface{}{
+		"mac_key":       "489dks293j39",
+		"mac_algorithm": "hmac-sha-256",
+	})
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+	a := &MACAuthorizer{
+		Ext: func(*http.Request) string { return "a,b,c" },
+		Now: func() time.Time { return time.Unix(1336363200, 0) },
+	}
+	client := &http.Client{Transport: &Transport{Source: StaticTokenSource(tok), Authorizer: a}}
+	res, err := client.Get(server.URL + "/resource/1?b=1&a=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	m := regexp.MustCompile(`^MAC id="h480djs93hd8", ts="1336363200", nonce="([^"]+)", ext="a,b,c", mac="([^"]+)"$`).FindStringSubmatch(auth)
+	if m == nil {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	req, _ := http.NewRequest("GET", server.URL+"/resource/1?b=1&a=2", nil)
+	h := hmac.New(sha256.New, []byte("489dks293j39"))
+	h.Write([]byte(macNormalizedRequest(req, "1336363200", m[1], "a,b,c")))
+	if want := base64.StdEncoding.EncodeToString(h.Sum(nil)); m[2] != want {
+		t.Errorf("mac = %q; want %q", m[2], want)
+	}
+}
+
+func TestMACNormalizedRequest(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://Example.com/request?b5=%3D%253D&a3=a", nil)
+	got := macNormalizedRequest(req, "264095", "7d8f3e4a", "")
+	want := "264095\n7d8f3e4a\nPOST\n/request?b5=%3D%253D&a3=a\nexample.com\n443\n\n"
+	if got != want {
+		t.Errorf("normalized request = %q; want %q", got, want)
+	}
+}
+
+func TestMACAuthorizerErrors(t *testing.T) {
+	a := new(MACAuthorizer)
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	if err := a.AuthorizeRequest(req, &Token{AccessToken: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.Header.Get("Authorization"), "Bearer abc"; got != want {
+		t.Errorf("Authorization for a bearer token = %q; want %q", got, want)
+	}
+	for _, extra := range []map[string]interface{}{
+		{"mac_algorithm": "hmac-sha-1"},
+		{"mac_key": "k", "mac_algorithm": "rsa"},
+	} {
+		tok := (&Token{AccessToken: "abc", TokenType: "MAC"}).WithExtra(extra)
+		if err := a.AuthorizeRequest(req, tok); err == nil {
+			t.Errorf("AuthorizeRequest with extra %v succeeded", extra)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microsoft
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/internal"
+)
+
+// Config describes a Microsoft identity platform application that
+// authenticates with a certificate instead of a client secret, using the
+// client credentials grant.
+//
+// For more information see:
+// https://learn.microsoft.com/en-us/entra/identity-platform/certificate-credentials
+type Config struct {
+	// TenantID is the directory (tenant) ID or domain. Required.
+	TenantID string
+
+	// ClientID is the application (client) ID. Required.
+	ClientID string
+
+	// Certificate is the PEM-encoded certificate registered with the
+	// application, optionally followed by its issuing certificates.
+	// Its thumbprints are sent with every assertion.
+	Certificate []byte
+
+	// SendCertificateChain sends the certificates in Certificate in the
+	// x5c header of every assertion, as required for subject name and
+	// issuer (SNI) authentication.
+	SendCertificateChain bool
+
+	// PrivateKey is the PEM-encoded RSA private key of Certificate.
+	PrivateKey []byte
+
+	// Scopes are the scopes to request, typically a single resource's
+	// "/.default" scope such as "https://graph.microsoft.com/.default".
+	Scopes []string
+
+	// Claims optionally specifies a JSON claims request to send with
+	// token requests, such as one returned by ClaimsFromChallenge when a
+	// resource rejects a token during Continuous Access Evaluation. To
+	// satisfy such a challenge, set Claims and create a new TokenSource.
+	Claims string
+
+	// AuthorityHost optionally specifies the authority of a national
+	// cloud. The default is "https://login.microsoftonline.com".
+	AuthorityHost string
+}
+
+// assertionLifetime is how long each client assertion is valid.
+const assertionLifetime = 10 * time.Minute
+
+// timeNow is time.Now but pulled out as a variable for tests.
+var timeNow = time.Now
+
+// TokenSource returns a TokenSource that obtains tokens with c's
+// certificate. A new signed client assertion is built for every token
+// request, so it never expires while the TokenSource is in use. Tokens
+// are cached until they expire.
+func TokenSource(ctx context.Context, c Config) (oauth2.TokenSource, error) {
+	if c.TenantID == "" || c.ClientID == "" {
+		return nil, errors.New("microsoft: TenantID and ClientID must be set")
+	}
+	block, _ := pem.Decode(c.Certificate)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("microsoft: Certificate must be a PEM-encoded certificate")
+	}
+	var chain []string
+	for rest := c.Certificate; ; {
+		var b *pem.Block
+		if b, rest = pem.Decode(rest); b == nil {
+			break
+		}
+		if b.Type == "CERTIFICATE" {
+			chain = append(chain, base64.StdEncoding.EncodeToString(b.Bytes))
+		}
+	}
+	key, err := internal.ParseKey(c.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("microsoft: %v", err)
+	}
+	sha1Sum := sha1.Sum(block.Bytes)
+	sha256Sum := sha256.Sum256(block.Bytes)
+	src := &certificateSource{
+		ctx:       ctx,
+		conf:      &c,
+		tokenURL:  tokenURL(c.AuthorityHost, c.TenantID),
+		key:       key,
+		x5t:       base64.RawURLEncoding.EncodeToString(sha1Sum[:]),
+		x5tSHA256: base64.RawURLEncoding.EncodeToString(sha256Sum[:]),
+	}
+	if c.SendCertificateChain {
+		src.x5c = chain
+	}
+	return oauth2.ReuseTokenSource(nil, src), nil
+}
+
+type certificateSource struct {
+	ctx       context.Context
+	conf      *Config
+	tokenURL  string
+	key       *rsa.PrivateKey
+	x5t       string
+	x5tSHA256 string
+	x5c       []string // base64 DER chain, if SendCertificateChain is set
+}
+
+func (s *certificateSource) Token() (*oauth2.Token, error) {
+	assertion, err := s.assertion()
+	if err != nil {
+		return nil, err
+	}
+	cc := &clientcredentials.Config{
+		ClientID: s.conf.ClientID,
+		TokenURL: s.tokenURL,
+		Scopes:   s.conf.Scopes,
+		EndpointParams: url.Values{
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {assertion},
+		},
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+	if s.conf.Claims != "" {
+		cc.EndpointParams.Set("claims", s.conf.Claims)
+	}
+	return cc.Token(s.ctx)
+}
+
+// assertion returns a client assertion signed with s.key.
+func (s *certificateSource) assertion() (string, error) {
+	h := map[string]interface{}{
+		"alg":      "RS256",
+		"typ":      "JWT",
+		"x5t":      s.x5t,
+		"x5t#S256": s.x5tSHA256,
+	}
+	if s.x5c != nil {
+		h["x5c"] = s.x5c
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", err
+	}
+	now := timeNow()
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": s.tokenURL,
+		"iss": s.conf.ClientID,
+		"sub": s.conf.ClientID,
+		"jti": hex.EncodeToString(jti[:]),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	ss := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(ss))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("microsoft: cannot sign client assertion: %v", err)
+	}
+	return ss + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// tokenURL returns the v2.0 token endpoint of tenant at authority,
+// which defaults to "https://login.microsoftonline.com".
+func tokenURL(authority, tenant string) string {
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	return strings.TrimRight(authority, "/") + "/" + tenant + "/oauth2/v2.0/token"
+}
+
+// ClaimsFromChallenge returns the claims request of a Continuous Access
+// Evaluation challenge, as passed to oauth2.Transport.OnChallenge when a
+// resource rejects a token with an "insufficient_claims" error. It
+// reports false if c is not such a challenge.
+//
+// For more information see:
+// https://learn.microsoft.com/en-us/entra/identity-platform/claims-challenge
+func ClaimsFromChallenge(c *oauth2.BearerChallenge) (string, bool) {
+	enc := c.Params["claims"]
+	if c.Error != "insufficient_claims" || enc == "" {
+		return "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		b, err = base64.RawStdEncoding.DecodeString(enc)
+	}
+	if err != nil || !json.Valid(b) {
+		return "", false
+	}
+	return string(b), true
+}
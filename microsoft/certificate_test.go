@@ -0,0 +1,181 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microsoft
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jws"
+)
+
+func testCertificate(t *testing.T) (key *rsa.PrivateKey, der, certPEM, keyPEM []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err = x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, der, certPEM, keyPEM
+}
+
+func TestCertificateTokenSource(t *testing.T) {
+	key, der, certPEM, keyPEM := testCertificate(t)
+	sum := sha1.Sum(der)
+	wantX5t := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	var requests int
+	var tokenURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got, want := r.URL.Path, "/tenant/oauth2/v2.0/token"; got != want {
+			t.Errorf("path = %q; want %q", got, want)
+		}
+		r.ParseForm()
+		for k, want := range map[string]string{
+			"grant_type":            "client_credentials",
+			"client_id":             "client",
+			"scope":                 "https://graph.microsoft.com/.default",
+			"client_assertion_type": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer",
+		} {
+			if got := r.PostForm.Get(k); got != want {
+				t.Errorf("%s = %q; want %q", k, got, want)
+			}
+		}
+		assertion := r.PostForm.Get("client_assertion")
+		if err := jws.Verify(assertion, &key.PublicKey); err != nil {
+			t.Errorf("client assertion signature: %v", err)
+		}
+		h, _ := base64.RawURLEncoding.DecodeString(strings.Split(assertion, ".")[0])
+		var header map[string]interface{}
+		json.Unmarshal(h, &header)
+		if header["x5t"] != wantX5t || header["x5t#S256"] == nil || header["x5c"] != nil {
+			t.Errorf("assertion header = %v; want x5t %q, x5t#S256 and no x5c", header, wantX5t)
+		}
+		claims, err := jws.Decode(assertion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.Iss != "client" || claims.Sub != "client" || claims.Aud != tokenURL {
+			t.Errorf("claims = %+v", claims)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+	tokenURL = ts.URL + "/tenant/oauth2/v2.0/token"
+
+	src, err := TokenSource(context.Background(), Config{
+		TenantID:      "tenant",
+		ClientID:      "client",
+		Certificate:   certPEM,
+		PrivateKey:    keyPEM,
+		Scopes:        []string{"https://graph.microsoft.com/.default"},
+		AuthorityHost: ts.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.AccessToken != "abc" {
+			t.Errorf("AccessToken = %q; want %q", tok.AccessToken, "abc")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("%d token requests; want 1", requests)
+	}
+}
+
+func TestCertificateChainAndClaims(t *testing.T) {
+	_, der, certPEM, keyPEM := testCertificate(t)
+	_, issuer, issuerPEM, _ := testCertificate(t)
+	const claims = `{"access_token":{"nbf":{"essential":true,"value":"1700000000"}}}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if got := r.PostForm.Get("claims"); got != claims {
+			t.Errorf("claims = %q; want %q", got, claims)
+		}
+		h, _ := base64.RawURLEncoding.DecodeString(strings.Split(r.PostForm.Get("client_assertion"), ".")[0])
+		var header struct {
+			X5c []string `json:"x5c"`
+		}
+		json.Unmarshal(h, &header)
+		want := []string{base64.StdEncoding.EncodeToString(der), base64.StdEncoding.EncodeToString(issuer)}
+		if !reflect.DeepEqual(header.X5c, want) {
+			t.Errorf("x5c = %q; want %q", header.X5c, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	src, err := TokenSource(context.Background(), Config{
+		TenantID:             "tenant",
+		ClientID:             "client",
+		Certificate:          append(certPEM, issuerPEM...),
+		SendCertificateChain: true,
+		PrivateKey:           keyPEM,
+		Claims:               claims,
+		AuthorityHost:        ts.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Token(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClaimsFromChallenge(t *testing.T) {
+	const claims = `{"access_token":{"nbf":{"essential":true}}}`
+	enc := base64.StdEncoding.EncodeToString([]byte(claims))
+	tests := []struct {
+		c      oauth2.BearerChallenge
+		want   string
+		wantOK bool
+	}{
+		{oauth2.BearerChallenge{Error: "insufficient_claims", Params: map[string]string{"claims": enc}}, claims, true},
+		{oauth2.BearerChallenge{Error: "insufficient_claims", Params: map[string]string{"claims": strings.TrimRight(enc, "=")}}, claims, true},
+		{oauth2.BearerChallenge{Error: "invalid_token", Params: map[string]string{"claims": enc}}, "", false},
+		{oauth2.BearerChallenge{Error: "insufficient_claims", Params: map[string]string{"claims": "!!"}}, "", false},
+		{oauth2.BearerChallenge{Error: "insufficient_claims"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ClaimsFromChallenge(&tt.c)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ClaimsFromChallenge(%+v) = %q, %v; want %q, %v", tt.c, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
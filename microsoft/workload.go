@@ -0,0 +1,104 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microsoft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// WorkloadIdentityConfig describes a Microsoft identity platform
+// application that authenticates with a federated token, such as the
+// service account token Kubernetes projects into pods that use Azure
+// workload identity.
+//
+// Empty fields are read from the environment variables set by the
+// workload identity webhook: AZURE_TENANT_ID, AZURE_CLIENT_ID,
+// AZURE_FEDERATED_TOKEN_FILE and AZURE_AUTHORITY_HOST.
+//
+// For more information see:
+// https://learn.microsoft.com/en-us/entra/workload-id/workload-identity-federation
+type WorkloadIdentityConfig struct {
+	// TenantID is the directory (tenant) ID or domain.
+	TenantID string
+
+	// ClientID is the application (client) ID.
+	ClientID string
+
+	// TokenFile is the path of the file holding the federated token.
+	TokenFile string
+
+	// Scopes are the scopes to request, typically a single resource's
+	// "/.default" scope such as "https://graph.microsoft.com/.default".
+	Scopes []string
+
+	// AuthorityHost optionally specifies the authority of a national
+	// cloud. The default is "https://login.microsoftonline.com".
+	AuthorityHost string
+}
+
+// WorkloadIdentityTokenSource returns a TokenSource that exchanges the
+// federated token in c.TokenFile for an access token. The file is read
+// again for every token request, so tokens rotated by the kubelet are
+// picked up. Tokens are cached until they expire.
+func WorkloadIdentityTokenSource(ctx context.Context, c WorkloadIdentityConfig) (oauth2.TokenSource, error) {
+	for _, f := range []struct {
+		v   *string
+		env string
+	}{
+		{&c.TenantID, "AZURE_TENANT_ID"},
+		{&c.ClientID, "AZURE_CLIENT_ID"},
+		{&c.TokenFile, "AZURE_FEDERATED_TOKEN_FILE"},
+		{&c.AuthorityHost, "AZURE_AUTHORITY_HOST"},
+	} {
+		if *f.v == "" {
+			*f.v = os.Getenv(f.env)
+		}
+	}
+	if c.TenantID == "" || c.ClientID == "" || c.TokenFile == "" {
+		return nil, errors.New("microsoft: TenantID, ClientID and TokenFile must be set")
+	}
+	src := &workloadIdentitySource{
+		ctx:      ctx,
+		conf:     &c,
+		tokenURL: tokenURL(c.AuthorityHost, c.TenantID),
+	}
+	return oauth2.ReuseTokenSource(nil, src), nil
+}
+
+type workloadIdentitySource struct {
+	ctx      context.Context
+	conf     *WorkloadIdentityConfig
+	tokenURL string
+}
+
+func (s *workloadIdentitySource) Token() (*oauth2.Token, error) {
+	b, err := os.ReadFile(s.conf.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("microsoft: cannot read federated token: %v", err)
+	}
+	assertion := strings.TrimSpace(string(b))
+	if assertion == "" {
+		return nil, fmt.Errorf("microsoft: federated token file %q is empty", s.conf.TokenFile)
+	}
+	cc := &clientcredentials.Config{
+		ClientID: s.conf.ClientID,
+		TokenURL: s.tokenURL,
+		Scopes:   s.conf.Scopes,
+		EndpointParams: url.Values{
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {assertion},
+		},
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+	return cc.Token(s.ctx)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package microsoft
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkloadIdentityTokenSource(t *testing.T) {
+	var wantAssertion string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Path, "/tenant/oauth2/v2.0/token"; got != want {
+			t.Errorf("path = %q; want %q", got, want)
+		}
+		r.ParseForm()
+		if got := r.PostForm.Get("client_id"); got != "client" {
+			t.Errorf("client_id = %q; want %q", got, "client")
+		}
+		if got := r.PostForm.Get("client_assertion"); got != wantAssertion {
+			t.Errorf("client_assertion = %q; want %q", got, wantAssertion)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":1}`))
+	}))
+	defer ts.Close()
+
+	file := filepath.Join(t.TempDir(), "token")
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", file)
+	t.Setenv("AZURE_AUTHORITY_HOST", ts.URL)
+
+	src, err := WorkloadIdentityTokenSource(context.Background(), WorkloadIdentityConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The token expires within the expiry delta, so every call
+	// exchanges the current contents of the file.
+	for _, assertion := range []string{"first", "rotated"} {
+		if err := os.WriteFile(file, []byte(assertion+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		wantAssertion = assertion
+		if _, err := src.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkloadIdentityTokenSourceMissingConfig(t *testing.T) {
+	for _, env := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_FEDERATED_TOKEN_FILE"} {
+		t.Setenv(env, "")
+	}
+	if _, err := WorkloadIdentityTokenSource(context.Background(), WorkloadIdentityConfig{ClientID: "client"}); err == nil {
+		t.Error("WorkloadIdentityTokenSource succeeded without TenantID and TokenFile")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nativeapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ManualOptions configures AuthorizeManual.
+type ManualOptions struct {
+	// RedirectURL is the redirect URI registered for the client. The
+	// browser, possibly on another machine, is redirected to it; the
+	// page need not load. The default is the RedirectURL of the
+	// Config.
+	RedirectURL string
+
+	// In is where the pasted redirect URL or code is read from. The
+	// default is os.Stdin.
+	In io.Reader
+
+	// Out is where the instructions are written. The default is
+	// os.Stderr.
+	Out io.Writer
+}
+
+// AuthorizeManual runs the authorization code flow of c for environments
+// without a browser or a reachable loopback interface, such as a remote
+// shell. It prints the authorization URL with instructions, reads the URL
+// the browser was redirected to, or only the code, from a single line of
+// input, and exchanges the code for a token.
+//
+// The state of a pasted URL is checked. A bare code cannot be checked
+// against the state, but is still protected by PKCE. opts are passed to
+// both AuthCodeURL and Exchange.
+func AuthorizeManual(ctx context.Context, c *oauth2.Config, m ManualOptions, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	in, out := m.In, m.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	if m.RedirectURL != "" {
+		opts = append([]oauth2.AuthCodeOption{oauth2.SetAuthURLParam("redirect_uri", m.RedirectURL)}, opts...)
+	}
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL := c.AuthCodeURL(state, append([]oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier)}, opts...)...)
+	fmt.Fprintf(out, "Open this URL in a browser on any device and authorize access:\n\n    %s\n\n", authURL)
+	fmt.Fprint(out, "Then paste the URL of the page you were redirected to, or the code: ")
+
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			lines <- line
+			return
+		}
+		if err == nil {
+			err = errors.New("nativeapp: no code entered")
+		}
+		errs <- err
+	}()
+	var line string
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-errs:
+		return nil, err
+	case line = <-lines:
+	}
+
+	code := line
+	exOpts := []oauth2.AuthCodeOption{oauth2.VerifierOption(verifier)}
+	if strings.Contains(line, "?") || strings.Contains(line, "#") {
+		resp, err := oauth2.ParseAuthResponseURL(line, state)
+		if err != nil {
+			return nil, err
+		}
+		code = resp.Code
+		exOpts = append(exOpts, oauth2.IssuerOption(resp.Issuer))
+	}
+	return c.Exchange(ctx, code, append(exOpts, opts...)...)
+}
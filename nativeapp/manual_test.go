@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nativeapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuthorizeManual(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "CODE" || r.Form.Get("code_verifier") == "" || r.Form.Get("redirect_uri") != "http://localhost/cb" {
+			t.Errorf("token request = %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"a"}`)
+	}))
+	defer ts.Close()
+	conf := &oauth2.Config{
+		ClientID: "CLIENT_ID",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://as.example/auth", TokenURL: ts.URL, AuthStyle: oauth2.AuthStyleInParams},
+		Issuer:   "https://as.example",
+	}
+
+	for _, tt := range []struct {
+		name    string
+		input   func(state string) string
+		wantErr error
+	}{
+		{"url", func(state string) string { return "http://localhost/cb?code=CODE&state=" + state + "\n" }, nil},
+		{"code", func(string) string { return " CODE \n" }, nil},
+		{"issuer", func(state string) string {
+			return "http://localhost/cb?code=CODE&iss=https%3A%2F%2Fas.example&state=" + state + "\n"
+		}, nil},
+		{"wrong issuer", func(state string) string {
+			return "http://localhost/cb?code=CODE&iss=https%3A%2F%2Fevil.example&state=" + state + "\n"
+		}, oauth2.ErrIssuerMismatch},
+		{"wrong state", func(string) string { return "http://localhost/cb?code=CODE&state=x\n" }, oauth2.ErrStateMismatch},
+	} {
+		// The state is only known once the URL is printed, so the
+		// input is written after the instructions and the prompt.
+		pr, pw := io.Pipe()
+		var out bytes.Buffer
+		outw := &notifyWriter{w: &out, wrote: make(chan struct{}, 8)}
+		go func() {
+			<-outw.wrote
+			<-outw.wrote
+			m := regexp.MustCompile(`https://\S+`).FindString(out.String())
+			u, _ := url.Parse(m)
+			io.WriteString(pw, tt.input(url.QueryEscape(u.Query().Get("state"))))
+		}()
+		tok, err := AuthorizeManual(context.Background(), conf, ManualOptions{RedirectURL: "http://localhost/cb", In: pr, Out: outw})
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: AuthorizeManual() error = %v; want %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || tok.AccessToken != "a" {
+			t.Errorf("%s: AuthorizeManual() = %v, %v; want token", tt.name, tok, err)
+		}
+		if !strings.Contains(out.String(), "paste") {
+			t.Errorf("%s: instructions = %q", tt.name, out.String())
+		}
+	}
+}
+
+// notifyWriter signals each write on wrote.
+type notifyWriter struct {
+	w     io.Writer
+	wrote chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.wrote <- struct{}{}
+	return n, err
+}
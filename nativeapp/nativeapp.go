@@ -0,0 +1,142 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nativeapp implements the authorization code flow for native
+// applications, such as command-line and desktop tools, following the
+// best practices of RFC 8252: the authorization response is received by
+// a loopback redirect URI on an ephemeral port, and the flow is protected
+// by a state parameter and PKCE.
+package nativeapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+const defaultSuccessPage = `<!DOCTYPE html>
+<html><head><title>Authorization complete</title></head>
+<body><p>Authorization complete. You can close this window and return to the application.</p></body></html>
+`
+
+// Options configures Authorize.
+type Options struct {
+	// OpenURL is called with the authorization URL, typically to open
+	// it in the user's browser or print it. Required.
+	OpenURL func(authURL string) error
+
+	// Host is the loopback address to listen on. The default is
+	// "127.0.0.1", as RFC 8252 section 8.3 recommends over "localhost".
+	Host string
+
+	// Port is the port to listen on. The default, zero, means an
+	// ephemeral port. The authorization server must allow any port
+	// in loopback redirect URIs, as RFC 8252 section 7.3 requires.
+	Port int
+
+	// Path is the path of the redirect URI. The default is "/".
+	Path string
+
+	// SuccessPage is the HTML page shown in the browser after the
+	// authorization response is received. A short default page is
+	// used if it is empty.
+	SuccessPage string
+}
+
+// Authorize runs the authorization code flow of c with a loopback
+// redirect URI, and exchanges the code it receives for a token.
+//
+// It listens on the loopback interface, calls o.OpenURL with the
+// authorization URL, and waits for the authorization response, the
+// cancellation of ctx, or an error. Requests whose state does not match
+// are rejected without ending the flow. c.RedirectURL is ignored; opts
+// are passed to both AuthCodeURL and Exchange.
+func Authorize(ctx context.Context, c *oauth2.Config, o Options, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	if o.OpenURL == nil {
+		return nil, errors.New("nativeapp: Options.OpenURL is nil")
+	}
+	host, path := o.Host, o.Path
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if path == "" {
+		path = "/"
+	}
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(o.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("nativeapp: cannot listen for the redirect: %v", err)
+	}
+	redirect := oauth2.SetAuthURLParam("redirect_uri", "http://"+l.Addr().String()+path)
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		resp *oauth2.AuthResponse
+		err  error
+	}
+	results := make(chan result, 1)
+	var once sync.Once
+	page := o.SuccessPage
+	if page == "" {
+		page = defaultSuccessPage
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		resp, err := oauth2.ParseAuthResponse(r, state)
+		if errors.Is(err, oauth2.ErrStateMismatch) {
+			http.Error(w, "Invalid state.", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<!DOCTYPE html>\n<html><body><p>Authorization failed: %s</p></body></html>\n", html.EscapeString(err.Error()))
+		} else {
+			fmt.Fprint(w, page)
+		}
+		once.Do(func() { results <- result{resp, err} })
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	authOpts := append([]oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier), redirect}, opts...)
+	if err := o.OpenURL(c.AuthCodeURL(state, authOpts...)); err != nil {
+		return nil, err
+	}
+
+	var res result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-results:
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	exOpts := append([]oauth2.AuthCodeOption{oauth2.VerifierOption(verifier), redirect, oauth2.IssuerOption(res.resp.Issuer)}, opts...)
+	return c.Exchange(ctx, res.resp.Code, exOpts...)
+}
+
+// randomState returns a random state parameter.
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("nativeapp: cannot generate state: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
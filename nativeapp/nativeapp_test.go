@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nativeapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuthorize(t *testing.T) {
+	var redirectURI string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "CODE" || r.Form.Get("code_verifier") == "" || r.Form.Get("redirect_uri") != redirectURI {
+			t.Errorf("token request = %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"a"}`)
+	}))
+	defer ts.Close()
+
+	conf := &oauth2.Config{
+		ClientID:      "CLIENT_ID",
+		Endpoint:      oauth2.Endpoint{AuthURL: "https://as.example/auth", TokenURL: ts.URL, AuthStyle: oauth2.AuthStyleInParams},
+		Issuer:        "https://as.example",
+		RequireIssuer: true,
+	}
+	openURL := func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		redirectURI = q.Get("redirect_uri")
+		if !strings.HasPrefix(redirectURI, "http://127.0.0.1:") || q.Get("code_challenge") == "" {
+			t.Errorf("authorization URL = %s", authURL)
+		}
+		// A forged response with the wrong state does not end the flow.
+		res, err := http.Get(redirectURI + "?code=EVIL&state=wrong")
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("forged response status = %d; want 400", res.StatusCode)
+		}
+		res, err = http.Get(redirectURI + "?code=CODE&iss=https%3A%2F%2Fas.example&state=" + url.QueryEscape(q.Get("state")))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return nil
+	}
+	tok, err := Authorize(context.Background(), conf, Options{OpenURL: openURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "a" {
+		t.Errorf("AccessToken = %q; want %q", tok.AccessToken, "a")
+	}
+}
+
+func TestAuthorizeError(t *testing.T) {
+	conf := &oauth2.Config{ClientID: "CLIENT_ID", Endpoint: oauth2.Endpoint{AuthURL: "https://as.example/auth"}}
+	openURL := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		q := u.Query()
+		res, err := http.Get(q.Get("redirect_uri") + "?error=access_denied&state=" + url.QueryEscape(q.Get("state")))
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+	_, err := Authorize(context.Background(), conf, Options{OpenURL: openURL})
+	var aerr *oauth2.AuthError
+	if !errors.As(err, &aerr) || aerr.ErrorCode != "access_denied" {
+		t.Errorf("Authorize() error = %v; want access_denied AuthError", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -59,11 +60,175 @@ type Config struct {
 	// Scopes specifies optional requested permissions.
 	Scopes []string
 
+	// EventHook optionally receives notifications about requests
+	// made to the token endpoint.
+	EventHook EventHook
+
+	// Logger optionally receives debug logs of requests made to the
+	// token endpoint: their outcome, status, error code, latency and
+	// retries. Secrets are never logged.
+	Logger Logger
+
+	// TokenRequestMiddleware optionally wraps the transport used for
+	// requests to the token endpoint, and only those, to apply
+	// proxies, request signing or auditing to token traffic. It
+	// receives the transport of the context's HTTP client, or
+	// http.DefaultTransport.
+	TokenRequestMiddleware func(next http.RoundTripper) http.RoundTripper
+
+	// RequestEncoding optionally specifies how the parameters of
+	// token and device authorization requests are encoded. The zero
+	// value follows RFC 6749.
+	RequestEncoding RequestEncoding
+
+	// LenientExpiry makes token responses whose lifetime does not
+	// follow RFC 6749 yield an Expiry instead of none: lifetimes
+	// given as strings or with a fraction, such as "3600.0", and
+	// lifetimes in an "expires" field, as returned by some older
+	// providers, when "expires_in" is missing. It has no effect on
+	// responses parsed by ResponseParser.
+	LenientExpiry bool
+
+	// Now optionally returns the current time. It is used to compute
+	// the Expiry of tokens and device codes, and to check the validity
+	// of tokens retrieved by c. The default is time.Now.
+	Now func() time.Time
+
+	// BasicAuthEncoding optionally specifies how the client ID and
+	// client secret are encoded in the Authorization header. The
+	// zero value follows RFC 6749.
+	BasicAuthEncoding BasicAuthEncoding
+
+	// TokenRequestTimeout optionally limits the duration of each
+	// request to the token and device authorization endpoints,
+	// independently of the HTTP client used for resource requests.
+	// Zero means a default of 30 seconds; a negative value means no
+	// limit other than that of the context.
+	TokenRequestTimeout time.Duration
+
+	// DisableAuthStyleProbing makes token requests fail instead of
+	// probing which auth style the token endpoint accepts, when
+	// Endpoint.AuthStyle is AuthStyleAutoDetect and no style is
+	// recorded in the auth style cache. It is intended for
+	// environments such as CI with recorded HTTP fixtures, where the
+	// extra probing request is not acceptable. Decisions recorded in
+	// an AuthStyleCache can be replayed by pre-seeding it with
+	// AuthStyleCache.Set.
+	DisableAuthStyleProbing bool
+
+	// RetryPolicy optionally retries token requests that fail with
+	// DNS, connection reset or TLS handshake errors. If nil, failed
+	// requests are not retried.
+	RetryPolicy *RetryPolicy
+
+	// Limiter optionally rate limits token requests and suspends them
+	// after invalid_client errors. It may be shared by several Configs.
+	Limiter *TokenRequestLimiter
+
+	// ResponseParser optionally parses successful token endpoint
+	// responses, for providers whose responses do not follow RFC 6749,
+	// such as ones that nest the token in another object. It is given
+	// the response's Content-Type header and body, and is used by
+	// Exchange, PasswordCredentialsToken and token refreshes. If the
+	// returned Token has ExpiresIn set but no Expiry, Expiry is computed
+	// from it. Error responses (non-2xx status codes) are still
+	// reported as *RetrieveError; ResponseParser may return a
+	// *RetrieveError itself for providers that report errors with a
+	// 200 status.
+	ResponseParser func(contentType string, body []byte) (*Token, error)
+
+	// Issuer optionally is the authorization server's issuer
+	// identifier. If set, Exchange checks it against the "iss"
+	// parameter of the authorization response, passed with
+	// IssuerOption, to mitigate mix-up attacks.
+	// See https://datatracker.ietf.org/doc/html/rfc9207.
+	Issuer string
+
+	// RequireIssuer makes Exchange fail if Issuer is set and the
+	// authorization response had no "iss" parameter. Set it for
+	// servers that advertise
+	// authorization_response_iss_parameter_supported.
+	RequireIssuer bool
+
+	// AuthStyleCache optionally specifies the cache recording which
+	// auth style to use when Endpoint.AuthStyle is the zero value
+	// (AuthStyleAutoDetect), keyed by token URL and client ID. It may
+	// be shared by several Configs, such as the per-tenant Configs of
+	// a multi-tenant deployment. If nil, each Config uses its own
+	// unbounded cache.
+	//
+	// To inspect the auth style detected for the client, or to
+	// pre-seed it so that no probing request is made, set
+	// AuthStyleCache and use its LookupClient and SetClient methods
+	// with Endpoint.TokenURL and ClientID.
+	AuthStyleCache *AuthStyleCache
+
 	// authStyleCache caches which auth style to use when Endpoint.AuthStyle is
-	// the zero value (AuthStyleAutoDetect).
+	// the zero value (AuthStyleAutoDetect) and AuthStyleCache is nil.
 	authStyleCache internal.LazyAuthStyleCache
 }
 
+// styleCache returns the auth style cache to use for c.
+func (c *Config) styleCache() *internal.AuthStyleCache {
+	if c.AuthStyleCache != nil {
+		return &c.AuthStyleCache.c
+	}
+	return c.authStyleCache.Get()
+}
+
+// now returns the current time according to c.Now.
+func (c *Config) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return timeNow()
+}
+
+// retrieveOptions returns the options for token requests made by c,
+// sending the extra headers h.
+func (c *Config) retrieveOptions(h http.Header) *internal.RetrieveOptions {
+	opts := &internal.RetrieveOptions{
+		RawBasicAuth: c.BasicAuthEncoding == BasicAuthRaw,
+		Timeout:      c.TokenRequestTimeout,
+		DisableProbe: c.DisableAuthStyleProbing,
+		Retry:        (*internal.RetryPolicy)(c.RetryPolicy),
+		Header:       h,
+	}
+	if c.Endpoint.ParamNames != (ParamNames{}) {
+		opts.ParamNames = (*internal.ParamNames)(&c.Endpoint.ParamNames)
+	}
+	if c.Limiter != nil {
+		opts.Limiter = c.Limiter
+	}
+	if c.Logger != nil {
+		opts.Logger = c.Logger
+	}
+	opts.Middleware = c.TokenRequestMiddleware
+	opts.JSONBody = c.RequestEncoding == RequestEncodingJSON
+	opts.Now = c.Now
+	opts.LenientExpiry = c.LenientExpiry
+	if c.ResponseParser != nil {
+		opts.ParseResponse = func(contentType string, body []byte) (*internal.Token, error) {
+			t, err := c.ResponseParser(contentType, body)
+			if err != nil || t == nil {
+				return nil, err
+			}
+			expiry := t.Expiry
+			if expiry.IsZero() && t.ExpiresIn != 0 {
+				expiry = c.now().Add(time.Duration(t.ExpiresIn) * time.Second)
+			}
+			return &internal.Token{
+				AccessToken:  t.AccessToken,
+				TokenType:    t.TokenType,
+				RefreshToken: t.RefreshToken,
+				Expiry:       expiry,
+				Raw:          t.raw,
+			}, nil
+		}
+	}
+	return opts
+}
+
 // A TokenSource is anything that can return a token.
 type TokenSource interface {
 	// Token returns a token or an error.
@@ -83,8 +248,50 @@ type Endpoint struct {
 	// client ID & client secret sent. The zero value means to
 	// auto-detect.
 	AuthStyle AuthStyle
+
+	// ParamNames optionally overrides the names of standard
+	// parameters and the HTTP method of token requests, for
+	// providers that deviate from RFC 6749. If it renames the
+	// client ID or secret, the zero AuthStyle means
+	// AuthStyleInParams instead of auto-detection.
+	ParamNames ParamNames
 }
 
+// ParamNames overrides the names of standard OAuth 2.0 parameters and
+// the HTTP method used for token requests. Empty fields keep the
+// standard values.
+type ParamNames struct {
+	// ClientID replaces "client_id" in AuthCodeURL and in token
+	// requests that send the client credentials as parameters.
+	ClientID string
+
+	// ClientSecret replaces "client_secret" in token requests that
+	// send the client credentials as parameters.
+	ClientSecret string
+
+	// GrantType replaces "grant_type" in token requests.
+	GrantType string
+
+	// Method is the HTTP method of token requests. It defaults to
+	// "POST". With "GET", the parameters are sent in the URL query.
+	Method string
+}
+
+// RequestEncoding represents how the parameters of requests to the token
+// endpoint are encoded.
+type RequestEncoding int
+
+const (
+	// RequestEncodingForm sends the parameters as an
+	// application/x-www-form-urlencoded body, as RFC 6749 requires.
+	RequestEncodingForm RequestEncoding = 0
+
+	// RequestEncodingJSON sends the parameters as an application/json
+	// object, as required by some FAPI profiles and legacy APIs.
+	// Parameters with a single value are encoded as strings.
+	RequestEncodingJSON RequestEncoding = 1
+)
+
 // AuthStyle represents how requests for tokens are authenticated
 // to the server.
 type AuthStyle int
@@ -105,6 +312,21 @@ const (
 	AuthStyleInHeader AuthStyle = 2
 )
 
+// BasicAuthEncoding represents how the client ID and client secret are
+// encoded in the HTTP Basic Authorization header when AuthStyleInHeader
+// is used.
+type BasicAuthEncoding int
+
+const (
+	// BasicAuthURLEncoded URL-encodes the client ID and client secret
+	// before Basic encoding them, as required by RFC 6749 section 2.3.1.
+	BasicAuthURLEncoded BasicAuthEncoding = 0
+
+	// BasicAuthRaw Basic encodes the client ID and client secret as-is,
+	// for servers that do not decode them as RFC 6749 requires.
+	BasicAuthRaw BasicAuthEncoding = 1
+)
+
 var (
 	// AccessTypeOnline and AccessTypeOffline are options passed
 	// to the Options.AuthCodeURL method. They modify the
@@ -141,6 +363,105 @@ func SetAuthURLParam(key, value string) AuthCodeOption {
 	return setParam{key, value}
 }
 
+type setParams url.Values
+
+func (p setParams) setValue(m url.Values) {
+	for k, vv := range p {
+		m[k] = append([]string(nil), vv...)
+	}
+}
+
+// SetParams builds an AuthCodeOption which passes the parameters in v,
+// such as audience, resource or tenant, like clientcredentials'
+// EndpointParams. Unlike SetAuthURLParam, parameters may have several
+// values, as resource does in RFC 8707. They replace parameters of the
+// same name. Requests encode parameters sorted by name.
+func SetParams(v url.Values) AuthCodeOption {
+	p := make(setParams, len(v))
+	for k, vv := range v {
+		p[k] = append([]string(nil), vv...)
+	}
+	return p
+}
+
+// optionTarget says which requests an AuthCodeOption applies to.
+type optionTarget int
+
+const (
+	targetAll          optionTarget = iota
+	targetAuthURL                   // the URL returned by AuthCodeURL
+	targetTokenRequest              // requests to the token and device authorization endpoints
+)
+
+type targetedOption struct {
+	AuthCodeOption
+	target optionTarget
+}
+
+// AuthURLOnly returns an AuthCodeOption that applies opt only to the
+// URL returned by AuthCodeURL, and is ignored by Exchange and the other
+// methods that make token requests.
+func AuthURLOnly(opt AuthCodeOption) AuthCodeOption {
+	return targetedOption{opt, targetAuthURL}
+}
+
+// TokenRequestOnly returns an AuthCodeOption that applies opt only to
+// token requests, such as those made by Exchange, and to device
+// authorization requests. It is ignored by AuthCodeURL.
+func TokenRequestOnly(opt AuthCodeOption) AuthCodeOption {
+	return targetedOption{opt, targetTokenRequest}
+}
+
+type setHeader struct{ k, v string }
+
+func (setHeader) setValue(url.Values) {}
+
+// SetTokenRequestHeader builds an AuthCodeOption which sets an HTTP
+// header on token requests, and on device authorization requests, for
+// providers that require headers such as Origin or an API version. It
+// is ignored by AuthCodeURL. The Content-Type and Authorization headers
+// cannot be set this way.
+func SetTokenRequestHeader(key, value string) AuthCodeOption {
+	return TokenRequestOnly(setHeader{key, value})
+}
+
+// setAuthURLValues adds the parameters of the opts that apply to the
+// authorization URL to v.
+func setAuthURLValues(v url.Values, opts []AuthCodeOption) {
+	for _, opt := range opts {
+		if t, ok := opt.(targetedOption); ok {
+			if t.target == targetTokenRequest {
+				continue
+			}
+			opt = t.AuthCodeOption
+		}
+		opt.setValue(v)
+	}
+}
+
+// setTokenRequestValues adds the parameters of the opts that apply to
+// token requests to v, and returns the headers they set, if any.
+func setTokenRequestValues(v url.Values, opts []AuthCodeOption) http.Header {
+	var h http.Header
+	for _, opt := range opts {
+		if t, ok := opt.(targetedOption); ok {
+			if t.target == targetAuthURL {
+				continue
+			}
+			opt = t.AuthCodeOption
+		}
+		if sh, ok := opt.(setHeader); ok {
+			if h == nil {
+				h = make(http.Header)
+			}
+			h.Add(sh.k, sh.v)
+			continue
+		}
+		opt.setValue(v)
+	}
+	return h
+}
+
 // AuthCodeURL returns a URL to OAuth 2.0 provider's consent page
 // that asks for permissions for the required scopes explicitly.
 //
@@ -157,12 +478,22 @@ func SetAuthURLParam(key, value string) AuthCodeOption {
 // See https://datatracker.ietf.org/doc/html/rfc6749#section-10.12 (predating
 // PKCE), https://www.oauth.com/oauth2-servers/pkce/ and
 // https://www.ietf.org/archive/id/draft-ietf-oauth-v2-1-09.html#name-cross-site-request-forgery (describing both approaches)
+//
+// The query parameters added to Endpoint.AuthURL are always sorted by key,
+// regardless of the order of opts, so the returned URL is stable across
+// calls and releases. Any query already present in Endpoint.AuthURL is kept
+// as-is, ahead of the added parameters. To compare URLs produced by
+// different code paths, see NormalizeURL.
 func (c *Config) AuthCodeURL(state string, opts ...AuthCodeOption) string {
 	var buf bytes.Buffer
 	buf.WriteString(c.Endpoint.AuthURL)
 	v := url.Values{
 		"response_type": {"code"},
-		"client_id":     {c.ClientID},
+	}
+	if name := c.Endpoint.ParamNames.ClientID; name != "" {
+		v.Set(name, c.ClientID)
+	} else {
+		v.Set("client_id", c.ClientID)
 	}
 	if c.RedirectURL != "" {
 		v.Set("redirect_uri", c.RedirectURL)
@@ -173,9 +504,7 @@ func (c *Config) AuthCodeURL(state string, opts ...AuthCodeOption) string {
 	if state != "" {
 		v.Set("state", state)
 	}
-	for _, opt := range opts {
-		opt.setValue(v)
-	}
+	setAuthURLValues(v, opts)
 	if strings.Contains(c.Endpoint.AuthURL, "?") {
 		buf.WriteByte('&')
 	} else {
@@ -185,6 +514,24 @@ func (c *Config) AuthCodeURL(state string, opts ...AuthCodeOption) string {
 	return buf.String()
 }
 
+// NormalizeURL returns rawURL with all of its query parameters sorted by
+// key, keeping the relative order of repeated keys. It is intended for
+// comparing URLs returned by AuthCodeURL in tests, where parameters from
+// Endpoint.AuthURL and from AuthCodeURL would otherwise appear in
+// separate groups.
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 // PasswordCredentialsToken converts a resource owner username and password
 // pair into a token.
 //
@@ -220,7 +567,14 @@ func (c *Config) PasswordCredentialsToken(ctx context.Context, username, passwor
 //
 // If using PKCE to protect against CSRF attacks, opts should include a
 // VerifierOption.
+//
+// Opts may also add parameters required by the provider, such as with
+// ResourceOption or AudienceOption, and replace the grant type with
+// GrantTypeOption for providers that use a variant of it.
 func (c *Config) Exchange(ctx context.Context, code string, opts ...AuthCodeOption) (*Token, error) {
+	if err := c.checkIssuer(opts); err != nil {
+		return nil, err
+	}
 	v := url.Values{
 		"grant_type": {"authorization_code"},
 		"code":       {code},
@@ -228,10 +582,76 @@ func (c *Config) Exchange(ctx context.Context, code string, opts ...AuthCodeOpti
 	if c.RedirectURL != "" {
 		v.Set("redirect_uri", c.RedirectURL)
 	}
+	h := setTokenRequestValues(v, opts)
+	return retrieveTokenWithHeader(ctx, c, v, h)
+}
+
+// ExchangeWithAssertion requests a token with an extension grant, such
+// as the JWT bearer grant of RFC 7523 or a provider-specific grant,
+// at the configured token endpoint. The request is authenticated and
+// its response parsed in the same way as for Exchange.
+//
+// The request contains grantType, assertion as the "assertion"
+// parameter unless it is empty, c.Scopes, and the parameters set by
+// opts. Grants that do not use the "assertion" parameter, such as
+// RFC 8693 token exchange
+// ("urn:ietf:params:oauth:grant-type:token-exchange"), can pass an
+// empty assertion and set their parameters with SetAuthURLParam.
+//
+// The provided context optionally controls which HTTP client is used. See the HTTPClient variable.
+func (c *Config) ExchangeWithAssertion(ctx context.Context, grantType, assertion string, opts ...AuthCodeOption) (*Token, error) {
+	if grantType == "" {
+		return nil, errors.New("oauth2: grant type must be set")
+	}
+	v := url.Values{
+		"grant_type": {grantType},
+	}
+	if assertion != "" {
+		v.Set("assertion", assertion)
+	}
+	if len(c.Scopes) > 0 {
+		v.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	h := setTokenRequestValues(v, opts)
+	return retrieveTokenWithHeader(ctx, c, v, h)
+}
+
+// ErrIssuerMismatch is returned by Exchange when the issuer of the
+// authorization response does not match Config.Issuer.
+var ErrIssuerMismatch = errors.New("oauth2: authorization response issuer mismatch")
+
+type issuerOption string
+
+func (issuerOption) setValue(url.Values) {}
+
+// IssuerOption returns an AuthCodeOption that passes the "iss"
+// parameter of the authorization response, such as
+// AuthResponse.Issuer, to Exchange, which checks it against
+// Config.Issuer. It is not sent to the token endpoint. An empty iss
+// means that the response had no "iss" parameter.
+func IssuerOption(iss string) AuthCodeOption {
+	return issuerOption(iss)
+}
+
+// checkIssuer checks the issuer passed in opts with IssuerOption
+// against c.Issuer.
+func (c *Config) checkIssuer(opts []AuthCodeOption) error {
+	if c.Issuer == "" {
+		return nil
+	}
+	var iss string
 	for _, opt := range opts {
-		opt.setValue(v)
+		if o, ok := opt.(issuerOption); ok {
+			iss = string(o)
+		}
 	}
-	return retrieveToken(ctx, c, v)
+	switch {
+	case iss == "" && c.RequireIssuer:
+		return errors.New("oauth2: authorization response has no issuer; pass it with IssuerOption")
+	case iss != "" && iss != c.Issuer:
+		return fmt.Errorf("%w: got %q, want %q", ErrIssuerMismatch, iss, c.Issuer)
+	}
+	return nil
 }
 
 // Client returns an HTTP client using the provided token.
@@ -244,6 +664,7 @@ func (c *Config) Client(ctx context.Context, t *Token) *http.Client {
 
 // TokenSource returns a TokenSource that returns t until t expires,
 // automatically refreshing it as necessary using the provided context.
+// It implements InspectableTokenSource.
 //
 // Most users will use Config.Client instead.
 func (c *Config) TokenSource(ctx context.Context, t *Token) TokenSource {
@@ -260,6 +681,22 @@ func (c *Config) TokenSource(ctx context.Context, t *Token) TokenSource {
 	}
 }
 
+// TokenSourceWithInitialToken is like TokenSource, but validates t first
+// with ValidateToken. Because t is renewed using its RefreshToken, a t
+// that expires must also have a RefreshToken.
+//
+// It is intended for warm-starting from tokens persisted by a previous
+// run of the program.
+func (c *Config) TokenSourceWithInitialToken(ctx context.Context, t *Token) (TokenSource, error) {
+	if err := ValidateToken(t); err != nil {
+		return nil, err
+	}
+	if !t.Expiry.IsZero() && t.RefreshToken == "" {
+		return nil, errors.New("oauth2: token expires but has no refresh_token")
+	}
+	return c.TokenSource(ctx, t), nil
+}
+
 // tokenRefresher is a TokenSource that makes "grant_type"=="refresh_token"
 // HTTP requests to renew a token using a RefreshToken.
 type tokenRefresher struct {
@@ -276,6 +713,9 @@ func (tf *tokenRefresher) Token() (*Token, error) {
 	if tf.refreshToken == "" {
 		return nil, errors.New("oauth2: token expired and refresh token is not set")
 	}
+	if h := tf.conf.EventHook; h != nil {
+		h.OnRefresh(TokenEvent{TokenURL: tf.conf.Endpoint.TokenURL, GrantType: "refresh_token"})
+	}
 
 	tk, err := retrieveToken(tf.ctx, tf.conf, url.Values{
 		"grant_type":    {"refresh_token"},
@@ -322,6 +762,37 @@ func (s *reuseTokenSource) Token() (*Token, error) {
 	return t, nil
 }
 
+// Peek returns the cached token. See InspectableTokenSource.
+func (s *reuseTokenSource) Peek() *Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t
+}
+
+// Invalidate discards the cached token. See InspectableTokenSource.
+func (s *reuseTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t = nil
+}
+
+// InspectableTokenSource is a TokenSource caching a token, whose cached
+// token can be inspected and discarded. Operators use it for health
+// checks and manual credential rotation. The TokenSources returned by
+// ReuseTokenSource, CachedTokenSource and Config.TokenSource implement
+// it.
+type InspectableTokenSource interface {
+	TokenSource
+
+	// Peek returns the cached token, which may be nil or expired,
+	// without fetching a new one.
+	Peek() *Token
+
+	// Invalidate discards the cached token, so that the next call
+	// to Token fetches a new one.
+	Invalidate()
+}
+
 // StaticTokenSource returns a TokenSource that always returns the same token.
 // Because the provided token t is never refreshed, StaticTokenSource is only
 // useful for tokens that never expire.
@@ -338,6 +809,44 @@ func (s staticTokenSource) Token() (*Token, error) {
 	return s.t, nil
 }
 
+// StaticTokenSourceWithExpiry returns a TokenSource that returns a copy of t
+// with its Expiry set to expiry. Unlike StaticTokenSource, the returned
+// token is reported invalid once it expires, so ReuseTokenSource stops
+// using it, and Token returns an error from then on.
+func StaticTokenSourceWithExpiry(t *Token, expiry time.Time) TokenSource {
+	tok := *t
+	tok.Expiry = expiry
+	return expiringStaticTokenSource{&tok}
+}
+
+// expiringStaticTokenSource is a TokenSource that returns the same Token
+// until it expires.
+type expiringStaticTokenSource struct {
+	t *Token
+}
+
+func (s expiringStaticTokenSource) Token() (*Token, error) {
+	if s.t.expired() {
+		return nil, fmt.Errorf("oauth2: static token expired at %v", s.t.Expiry)
+	}
+	return s.t, nil
+}
+
+// ErrorTokenSource returns a TokenSource whose Token method always returns
+// err. It is useful in tests and to signal a permanent failure in a
+// composed TokenSource.
+func ErrorTokenSource(err error) TokenSource {
+	return errorTokenSource{err}
+}
+
+type errorTokenSource struct {
+	err error
+}
+
+func (s errorTokenSource) Token() (*Token, error) {
+	return nil, s.err
+}
+
 // HTTPClient is the context key to use with golang.org/x/net/context's
 // WithValue function to associate an *http.Client value with a context.
 var HTTPClient internal.ContextKey
@@ -376,6 +885,9 @@ func NewClient(ctx context.Context, src TokenSource) *http.Client {
 // wrapped in a caching version if it isn't one already. This also
 // means it's always safe to wrap ReuseTokenSource around any other
 // TokenSource without adverse effects.
+//
+// ReuseTokenSource does not validate t. Callers loading t from
+// persistent storage should check it with ValidateToken first.
 func ReuseTokenSource(t *Token, src TokenSource) TokenSource {
 	// Don't wrap a reuseTokenSource in itself. That would work,
 	// but cause an unnecessary number of mutex operations.
@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestScriptedTokenSource(t *testing.T) {
+	errFail := errors.New("fail")
+	s := NewScriptedTokenSource(
+		Result{Token: &oauth2.Token{AccessToken: "a"}},
+		Result{Err: errFail},
+	)
+	if tok, err := s.Token(); err != nil || tok.AccessToken != "a" {
+		t.Errorf("Token() = %v, %v; want token a", tok, err)
+	}
+	if _, err := s.Token(); err != errFail {
+		t.Errorf("Token() error = %v; want %v", err, errFail)
+	}
+	if _, err := s.Token(); err != ErrScriptExhausted {
+		t.Errorf("Token() error = %v; want ErrScriptExhausted", err)
+	}
+	s.Append(Result{Token: &oauth2.Token{AccessToken: "b"}})
+	if got := s.Remaining(); got != 1 {
+		t.Errorf("Remaining() = %d; want 1", got)
+	}
+}
+
+func TestRecordingTokenSource(t *testing.T) {
+	r := &RecordingTokenSource{Source: NewScriptedTokenSource(Result{Token: &oauth2.Token{AccessToken: "a"}})}
+	r.Token()
+	r.Token()
+	calls := r.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("recorded %d calls; want 2", len(calls))
+	}
+	if calls[0].Token.AccessToken != "a" || calls[1].Err != ErrScriptExhausted {
+		t.Errorf("Calls() = %+v", calls)
+	}
+	r.Reset()
+	if got := r.Calls(); len(got) != 0 {
+		t.Errorf("Calls() after Reset = %v; want none", got)
+	}
+}
+
+func TestTransportRecorder(t *testing.T) {
+	rec := new(TransportRecorder)
+	c := &http.Client{Transport: &oauth2.Transport{
+		Source: NewScriptedTokenSource(
+			Result{Token: &oauth2.Token{AccessToken: "a"}},
+			Result{Token: &oauth2.Token{AccessToken: "b", TokenType: "mac"}},
+		),
+		Base: rec,
+	}}
+	for i := 0; i < 2; i++ {
+		res, err := c.Get("https://api.example/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	want := []string{"Bearer a", "MAC b"}
+	if got := rec.Authorizations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Authorizations() = %q; want %q", got, want)
+	}
+	if got := rec.Requests()[0].URL.String(); got != "https://api.example/" {
+		t.Errorf("recorded URL = %q", got)
+	}
+}
@@ -0,0 +1,630 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package oauth2test provides an in-memory OAuth 2.0 authorization
+// server for testing applications that use golang.org/x/oauth2.
+//
+// A Server implements the authorization code grant with PKCE
+// (RFC 7636), the client credentials, refresh token and device
+// authorization (RFC 8628) grants, and token revocation (RFC 7009).
+// Its Quirks reproduce the non-standard behaviors of some providers.
+// Requests reach it through HTTPClient or Context without using the
+// network; it may also be served with net/http/httptest by setting
+// BaseURL.
+//
+// The package also provides test doubles: ScriptedTokenSource and
+// RecordingTokenSource for code consuming an oauth2.TokenSource, and
+// TransportRecorder to assert the requests sent by an HTTP client.
+package oauth2test // import "golang.org/x/oauth2/oauth2test"
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultBaseURL is the BaseURL of servers returned by NewServer.
+const DefaultBaseURL = "https://oauth2test.example"
+
+// Paths of the endpoints of a Server, relative to its BaseURL.
+const (
+	AuthPath       = "/authorize"
+	TokenPath      = "/token"
+	DeviceAuthPath = "/device"
+	VerifyPath     = "/device/verify"
+	RevocationPath = "/revoke"
+)
+
+// Client is a client registered with a Server.
+type Client struct {
+	ID string
+
+	// Secret is the client secret. Public clients have none, and
+	// may only use the authorization code grant with PKCE, refresh
+	// tokens and the device authorization grant.
+	Secret string
+
+	// RedirectURIs optionally lists the redirect URIs the client may
+	// use. If empty, any redirect URI is accepted.
+	RedirectURIs []string
+}
+
+// Quirks configure deviations of a Server from RFC 6749, to reproduce
+// providers that applications must interoperate with. The zero value
+// follows the RFCs.
+type Quirks struct {
+	// AuthStyle is how confidential clients must authenticate to the
+	// token and revocation endpoints. The zero value,
+	// oauth2.AuthStyleAutoDetect, accepts both styles.
+	AuthStyle oauth2.AuthStyle
+
+	// FormResponses makes the token endpoint respond with
+	// application/x-www-form-urlencoded bodies instead of JSON.
+	FormResponses bool
+
+	// ErrorStatus is the HTTP status of error responses of the token
+	// endpoint. Zero means 400, or 401 for invalid_client errors. Set
+	// it to 200 to reproduce providers that report errors with a
+	// successful status.
+	ErrorStatus int
+
+	// RequirePKCE rejects authorization requests without a code
+	// challenge, even from confidential clients.
+	RequirePKCE bool
+
+	// RotateRefreshTokens issues a new refresh token on each refresh
+	// and invalidates the previous one.
+	RotateRefreshTokens bool
+
+	// NoRefreshTokens makes the server never issue refresh tokens.
+	NoRefreshTokens bool
+}
+
+// Server is an in-memory OAuth 2.0 authorization server. Its fields
+// must not be modified after it has served its first request.
+type Server struct {
+	// BaseURL is the URL the endpoints are served under, without a
+	// trailing slash.
+	BaseURL string
+
+	// TokenLifetime is the lifetime of access tokens. Zero means one
+	// hour.
+	TokenLifetime time.Duration
+
+	// DeviceInterval is the polling interval, in seconds, returned
+	// by the device authorization endpoint. Zero means 1.
+	DeviceInterval int
+
+	Quirks Quirks
+
+	mu      sync.Mutex
+	clients map[string]Client
+	codes   map[string]*authCode
+	access  map[string]*accessToken
+	refresh map[string]*grant
+	devices map[string]*device // by device code
+}
+
+// grant is an authorization given to a client, from which access and
+// refresh tokens are issued.
+type grant struct {
+	clientID string
+	scope    string
+	revoked  bool
+}
+
+type authCode struct {
+	grant         *grant
+	redirectURI   string
+	challenge     string
+	challengeMeth string
+}
+
+type accessToken struct {
+	grant  *grant
+	expiry time.Time
+}
+
+// device is a pending device authorization.
+type device struct {
+	clientID string
+	scope    string
+	userCode string
+	expiry   time.Time
+	approved bool
+	denied   bool
+}
+
+// NewServer returns a Server with the registered clients, served under
+// DefaultBaseURL.
+func NewServer(clients ...Client) *Server {
+	s := &Server{
+		BaseURL: DefaultBaseURL,
+		clients: make(map[string]Client),
+		codes:   make(map[string]*authCode),
+		access:  make(map[string]*accessToken),
+		refresh: make(map[string]*grant),
+		devices: make(map[string]*device),
+	}
+	for _, c := range clients {
+		s.clients[c.ID] = c
+	}
+	return s
+}
+
+// Endpoint returns the endpoint of s. Its AuthStyle is
+// oauth2.AuthStyleAutoDetect, so that clients probe s.Quirks.AuthStyle.
+func (s *Server) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:       s.BaseURL + AuthPath,
+		TokenURL:      s.BaseURL + TokenPath,
+		DeviceAuthURL: s.BaseURL + DeviceAuthPath,
+	}
+}
+
+// RevocationURL returns the URL of the revocation endpoint of s.
+func (s *Server) RevocationURL() string {
+	return s.BaseURL + RevocationPath
+}
+
+// Config returns a Config for the registered client with the given ID,
+// using its first redirect URI, if any.
+func (s *Server) Config(clientID string, scopes ...string) *oauth2.Config {
+	s.mu.Lock()
+	c := s.clients[clientID]
+	s.mu.Unlock()
+	conf := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: c.Secret,
+		Endpoint:     s.Endpoint(),
+		Scopes:       scopes,
+	}
+	if len(c.RedirectURIs) > 0 {
+		conf.RedirectURL = c.RedirectURIs[0]
+	}
+	return conf
+}
+
+// HTTPClient returns an HTTP client that sends requests for BaseURL to
+// s in memory. Requests for other URLs fail.
+func (s *Server) HTTPClient() *http.Client {
+	return &http.Client{
+		Transport: transport{s},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Context returns a copy of ctx carrying HTTPClient, for use with the
+// functions of the oauth2 packages.
+func (s *Server) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, s.HTTPClient())
+}
+
+type transport struct {
+	s *Server
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), t.s.BaseURL+"/") {
+		return nil, fmt.Errorf("oauth2test: no route to %s", req.URL.Redacted())
+	}
+	rec := httptest.NewRecorder()
+	t.s.ServeHTTP(rec, req)
+	res := rec.Result()
+	res.Request = req
+	return res, nil
+}
+
+// Authorize simulates a user approving the authorization request
+// authURL, as returned by oauth2.Config.AuthCodeURL. It returns the
+// URL the user agent is redirected to, which carries the authorization
+// code and state, or an error code for invalid requests. It returns an
+// error if the client or redirect URI is invalid, in which case the
+// user is not redirected.
+func (s *Server) Authorize(authURL string) (*url.URL, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.authorize(u.Query())
+}
+
+func (s *Server) authorize(q url.Values) (*url.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.clients[q.Get("client_id")]
+	if !ok {
+		return nil, fmt.Errorf("oauth2test: unknown client %q", q.Get("client_id"))
+	}
+	redirectURI := q.Get("redirect_uri")
+	if redirectURI == "" {
+		if len(c.RedirectURIs) != 1 {
+			return nil, errors.New("oauth2test: missing redirect_uri")
+		}
+		redirectURI = c.RedirectURIs[0]
+	} else if len(c.RedirectURIs) > 0 && !contains(c.RedirectURIs, redirectURI) {
+		return nil, fmt.Errorf("oauth2test: redirect_uri %q is not registered", redirectURI)
+	}
+	ru, err := url.Parse(redirectURI)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2test: invalid redirect_uri: %v", err)
+	}
+
+	v := ru.Query()
+	if state := q.Get("state"); state != "" {
+		v.Set("state", state)
+	}
+	challenge, method := q.Get("code_challenge"), q.Get("code_challenge_method")
+	if method == "" {
+		method = "plain"
+	}
+	switch {
+	case q.Get("response_type") != "code":
+		v.Set("error", "unsupported_response_type")
+	case challenge == "" && (c.Secret == "" || s.Quirks.RequirePKCE):
+		v.Set("error", "invalid_request")
+		v.Set("error_description", "code_challenge required")
+	case challenge != "" && method != "S256" && method != "plain":
+		v.Set("error", "invalid_request")
+		v.Set("error_description", "unsupported code_challenge_method")
+	default:
+		code := randomString()
+		s.codes[code] = &authCode{
+			grant:         &grant{clientID: c.ID, scope: q.Get("scope")},
+			redirectURI:   q.Get("redirect_uri"),
+			challenge:     challenge,
+			challengeMeth: method,
+		}
+		v.Set("code", code)
+	}
+	ru.RawQuery = v.Encode()
+	return ru, nil
+}
+
+// ApproveDevice simulates a user entering userCode at the verification
+// URI and approving the device authorization request.
+func (s *Server) ApproveDevice(userCode string) error {
+	return s.decideDevice(userCode, true)
+}
+
+// DenyDevice simulates a user entering userCode at the verification
+// URI and denying the device authorization request.
+func (s *Server) DenyDevice(userCode string) error {
+	return s.decideDevice(userCode, false)
+}
+
+func (s *Server) decideDevice(userCode string, approve bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if d.userCode == userCode {
+			d.approved, d.denied = approve, !approve
+			return nil
+		}
+	}
+	return fmt.Errorf("oauth2test: unknown user code %q", userCode)
+}
+
+// Active reports whether accessToken was issued by s and has neither
+// expired nor been revoked.
+func (s *Server) Active(accessToken string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.access[accessToken]
+	return ok && !at.grant.revoked && time.Now().Before(at.expiry)
+}
+
+// ServeHTTP serves the endpoints of s.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case AuthPath:
+		u, err := s.authorize(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, u.String(), http.StatusFound)
+	case TokenPath:
+		s.serveToken(w, r)
+	case DeviceAuthPath:
+		s.serveDeviceAuth(w, r)
+	case RevocationPath:
+		s.serveRevocation(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// tokenError is an RFC 6749 error response.
+type tokenError struct {
+	code, description string
+}
+
+func (e *tokenError) Error() string { return e.code + ": " + e.description }
+
+func (s *Server) writeError(w http.ResponseWriter, err *tokenError) {
+	status := s.Quirks.ErrorStatus
+	if status == 0 {
+		status = http.StatusBadRequest
+		if err.code == "invalid_client" {
+			status = http.StatusUnauthorized
+		}
+	}
+	s.writeResponse(w, status, map[string]interface{}{
+		"error":             err.code,
+		"error_description": err.description,
+	})
+}
+
+func (s *Server) writeResponse(w http.ResponseWriter, status int, m map[string]interface{}) {
+	w.Header().Set("Cache-Control", "no-store")
+	if s.Quirks.FormResponses {
+		v := url.Values{}
+		for k, x := range m {
+			v.Set(k, fmt.Sprint(x))
+		}
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.WriteHeader(status)
+		w.Write([]byte(v.Encode()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(m)
+}
+
+// authenticate returns the client making r, following s.Quirks.AuthStyle.
+// Public clients may identify themselves with only a client_id parameter.
+func (s *Server) authenticate(r *http.Request) (Client, *tokenError) {
+	invalid := &tokenError{"invalid_client", "client authentication failed"}
+	id, secret, inHeader := r.BasicAuth()
+	if inHeader {
+		if s.Quirks.AuthStyle == oauth2.AuthStyleInParams {
+			return Client{}, invalid
+		}
+		// RFC 6749 section 2.3.1 form-encodes the credentials.
+		if v, err := url.QueryUnescape(id); err == nil {
+			id = v
+		}
+		if v, err := url.QueryUnescape(secret); err == nil {
+			secret = v
+		}
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		if secret != "" && s.Quirks.AuthStyle == oauth2.AuthStyleInHeader {
+			return Client{}, invalid
+		}
+	}
+	s.mu.Lock()
+	c, ok := s.clients[id]
+	s.mu.Unlock()
+	if !ok || c.Secret != secret {
+		return Client{}, invalid
+	}
+	if c.Secret == "" && inHeader {
+		return Client{}, invalid
+	}
+	return c, nil
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, &tokenError{"invalid_request", err.Error()})
+		return
+	}
+	c, terr := s.authenticate(r)
+	if terr != nil {
+		s.writeError(w, terr)
+		return
+	}
+	var g *grant
+	switch gt := r.PostForm.Get("grant_type"); gt {
+	case "authorization_code":
+		g, terr = s.exchangeCode(c, r.PostForm)
+	case "client_credentials":
+		if c.Secret == "" {
+			terr = &tokenError{"unauthorized_client", "public clients cannot use client credentials"}
+		} else {
+			g = &grant{clientID: c.ID, scope: r.PostForm.Get("scope")}
+		}
+	case "refresh_token":
+		g, terr = s.refreshGrant(c, r.PostForm.Get("refresh_token"))
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		g, terr = s.deviceGrant(c, r.PostForm.Get("device_code"))
+	default:
+		terr = &tokenError{"unsupported_grant_type", fmt.Sprintf("grant type %q is not supported", gt)}
+	}
+	if terr != nil {
+		s.writeError(w, terr)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, s.issue(g, r.PostForm))
+}
+
+// issue issues an access token, and maybe a refresh token, for g.
+func (s *Server) issue(g *grant, v url.Values) map[string]interface{} {
+	lifetime := s.TokenLifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok := randomString()
+	s.access[tok] = &accessToken{grant: g, expiry: time.Now().Add(lifetime)}
+	res := map[string]interface{}{
+		"access_token": tok,
+		"token_type":   "Bearer",
+		"expires_in":   int64(lifetime / time.Second),
+	}
+	if g.scope != "" {
+		res["scope"] = g.scope
+	}
+	if s.Quirks.NoRefreshTokens || v.Get("grant_type") == "client_credentials" {
+		return res
+	}
+	old := v.Get("refresh_token")
+	if old != "" && !s.Quirks.RotateRefreshTokens {
+		return res
+	}
+	delete(s.refresh, old)
+	rt := randomString()
+	s.refresh[rt] = g
+	res["refresh_token"] = rt
+	return res
+}
+
+func (s *Server) exchangeCode(c Client, v url.Values) (*grant, *tokenError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[v.Get("code")]
+	if !ok || code.grant.clientID != c.ID {
+		return nil, &tokenError{"invalid_grant", "invalid authorization code"}
+	}
+	delete(s.codes, v.Get("code"))
+	if code.redirectURI != v.Get("redirect_uri") {
+		return nil, &tokenError{"invalid_grant", "redirect_uri does not match the authorization request"}
+	}
+	if code.challenge != "" {
+		verifier := v.Get("code_verifier")
+		if verifier == "" {
+			return nil, &tokenError{"invalid_request", "code_verifier required"}
+		}
+		if code.challengeMeth == "S256" {
+			sum := sha256.Sum256([]byte(verifier))
+			verifier = base64.RawURLEncoding.EncodeToString(sum[:])
+		}
+		if verifier != code.challenge {
+			return nil, &tokenError{"invalid_grant", "code_verifier does not match the code challenge"}
+		}
+	}
+	return code.grant, nil
+}
+
+func (s *Server) refreshGrant(c Client, refreshToken string) (*grant, *tokenError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.refresh[refreshToken]
+	if !ok || g.revoked || g.clientID != c.ID {
+		return nil, &tokenError{"invalid_grant", "invalid refresh token"}
+	}
+	return g, nil
+}
+
+func (s *Server) deviceGrant(c Client, deviceCode string) (*grant, *tokenError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.devices[deviceCode]
+	switch {
+	case !ok || d.clientID != c.ID:
+		return nil, &tokenError{"invalid_grant", "invalid device code"}
+	case time.Now().After(d.expiry):
+		delete(s.devices, deviceCode)
+		return nil, &tokenError{"expired_token", "the device code has expired"}
+	case d.denied:
+		delete(s.devices, deviceCode)
+		return nil, &tokenError{"access_denied", "the user denied the request"}
+	case !d.approved:
+		return nil, &tokenError{"authorization_pending", "the user has not approved the request yet"}
+	}
+	delete(s.devices, deviceCode)
+	return &grant{clientID: c.ID, scope: d.scope}, nil
+}
+
+func (s *Server) serveDeviceAuth(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, &tokenError{"invalid_request", err.Error()})
+		return
+	}
+	s.mu.Lock()
+	c, ok := s.clients[r.PostForm.Get("client_id")]
+	s.mu.Unlock()
+	if !ok {
+		s.writeError(w, &tokenError{"invalid_client", "unknown client"})
+		return
+	}
+	const lifetime = 10 * time.Minute
+	interval := s.DeviceInterval
+	if interval == 0 {
+		interval = 1
+	}
+	deviceCode, userCode := randomString(), strings.ToUpper(randomString()[:8])
+	s.mu.Lock()
+	s.devices[deviceCode] = &device{
+		clientID: c.ID,
+		scope:    r.PostForm.Get("scope"),
+		userCode: userCode,
+		expiry:   time.Now().Add(lifetime),
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          s.BaseURL + VerifyPath,
+		"verification_uri_complete": s.BaseURL + VerifyPath + "?user_code=" + userCode,
+		"expires_in":                int64(lifetime / time.Second),
+		"interval":                  interval,
+	})
+}
+
+func (s *Server) serveRevocation(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, &tokenError{"invalid_request", err.Error()})
+		return
+	}
+	c, terr := s.authenticate(r)
+	if terr != nil {
+		s.writeError(w, terr)
+		return
+	}
+	// RFC 7009 section 2.2: invalid tokens are not an error, and
+	// revoking a refresh token also revokes the access tokens of its
+	// grant.
+	tok := r.PostForm.Get("token")
+	s.mu.Lock()
+	if g, ok := s.refresh[tok]; ok && g.clientID == c.ID {
+		g.revoked = true
+		delete(s.refresh, tok)
+	} else if at, ok := s.access[tok]; ok && at.grant.clientID == c.ID {
+		delete(s.access, tok)
+	}
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("oauth2test: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestAuthCodeFlow(t *testing.T) {
+	s := NewServer(Client{ID: "app", Secret: "secret", RedirectURIs: []string{"https://app.example/callback"}})
+	ctx := s.Context(context.Background())
+	conf := s.Config("app", "read")
+
+	verifier := oauth2.GenerateVerifier()
+	u, err := s.Authorize(conf.AuthCodeURL("state", oauth2.S256ChallengeOption(verifier)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if got := q.Get("state"); got != "state" {
+		t.Errorf("state = %q; want %q", got, "state")
+	}
+	if _, err := conf.Exchange(ctx, q.Get("code"), oauth2.VerifierOption("wrong")); err == nil {
+		t.Error("Exchange with a wrong verifier succeeded")
+	}
+
+	u, _ = s.Authorize(conf.AuthCodeURL("state", oauth2.S256ChallengeOption(verifier)))
+	tok, err := conf.Exchange(ctx, u.Query().Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Active(tok.AccessToken) {
+		t.Error("issued access token is not active")
+	}
+	if got := tok.Extra("scope"); got != "read" {
+		t.Errorf("scope = %v; want read", got)
+	}
+	if tok.RefreshToken == "" {
+		t.Fatal("no refresh token issued")
+	}
+
+	tok.Expiry = time.Now().Add(-time.Minute)
+	refreshed, err := conf.TokenSource(ctx, tok).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessToken == tok.AccessToken || !s.Active(refreshed.AccessToken) {
+		t.Errorf("refresh did not issue a new active access token")
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	s := NewServer(
+		Client{ID: "app", Secret: "secret", RedirectURIs: []string{"https://app.example/callback"}},
+		Client{ID: "public"},
+	)
+	if _, err := s.Authorize("https://oauth2test.example/authorize?response_type=code&client_id=unknown"); err == nil {
+		t.Error("Authorize for an unknown client succeeded")
+	}
+	conf := s.Config("app")
+	conf.RedirectURL = "https://evil.example/"
+	if _, err := s.Authorize(conf.AuthCodeURL("state")); err == nil {
+		t.Error("Authorize with an unregistered redirect URI succeeded")
+	}
+
+	// Public clients must use PKCE.
+	conf = s.Config("public")
+	conf.RedirectURL = "http://127.0.0.1/callback"
+	u, err := s.Authorize(conf.AuthCodeURL("state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("error"); got != "invalid_request" {
+		t.Errorf("error = %q; want invalid_request", got)
+	}
+}
+
+func TestClientCredentialsAuthStyle(t *testing.T) {
+	other := map[oauth2.AuthStyle]oauth2.AuthStyle{
+		oauth2.AuthStyleInHeader: oauth2.AuthStyleInParams,
+		oauth2.AuthStyleInParams: oauth2.AuthStyleInHeader,
+	}
+	for style := range other {
+		s := NewServer(Client{ID: "svc", Secret: "s3cr3t"})
+		s.Quirks.AuthStyle = style
+		conf := &clientcredentials.Config{
+			ClientID:     "svc",
+			ClientSecret: "s3cr3t",
+			TokenURL:     s.Endpoint().TokenURL,
+		}
+		tok, err := conf.Token(s.Context(context.Background()))
+		if err != nil {
+			t.Fatalf("style %v: %v", style, err)
+		}
+		if tok.RefreshToken != "" {
+			t.Errorf("style %v: client credentials grant issued a refresh token", style)
+		}
+
+		// Forcing the other style fails.
+		conf.AuthStyle = other[style]
+		_, err = conf.Token(s.Context(context.Background()))
+		var re *oauth2.RetrieveError
+		if !errors.As(err, &re) || re.ErrorCode != "invalid_client" {
+			t.Errorf("style %v: Token with the wrong style = %v; want invalid_client", style, err)
+		}
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	s := NewServer(Client{ID: "tv"})
+	ctx := s.Context(context.Background())
+	conf := s.Config("tv")
+
+	da, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ApproveDevice(da.UserCode); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := conf.DeviceAccessToken(ctx, da)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Active(tok.AccessToken) {
+		t.Error("issued access token is not active")
+	}
+
+	da, err = conf.DeviceAuth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.DenyDevice(da.UserCode)
+	_, err = conf.DeviceAccessToken(ctx, da)
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.ErrorCode != "access_denied" {
+		t.Errorf("DeviceAccessToken after denial = %v; want access_denied", err)
+	}
+}
+
+func TestRevocationAndRotation(t *testing.T) {
+	s := NewServer(Client{ID: "app", Secret: "secret"})
+	s.Quirks.RotateRefreshTokens = true
+	ctx := s.Context(context.Background())
+	conf := s.Config("app")
+	conf.RedirectURL = "https://app.example/callback"
+
+	u, _ := s.Authorize(conf.AuthCodeURL("state"))
+	tok, err := conf.Exchange(ctx, u.Query().Get("code"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.Expiry = time.Now().Add(-time.Minute)
+	refreshed, err := conf.TokenSource(ctx, tok).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == tok.RefreshToken {
+		t.Error("refresh token was not rotated")
+	}
+	if _, err := conf.TokenSource(ctx, tok).Token(); err == nil {
+		t.Error("refresh with a rotated refresh token succeeded")
+	}
+
+	req, _ := http.NewRequest("POST", s.RevocationURL(), strings.NewReader(url.Values{"token": {refreshed.RefreshToken}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("app", "secret")
+	res, err := s.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("revocation status = %d; want 200", res.StatusCode)
+	}
+	if s.Active(refreshed.AccessToken) {
+		t.Error("access token active after its refresh token was revoked")
+	}
+}
+
+func TestErrorQuirks(t *testing.T) {
+	s := NewServer(Client{ID: "app", Secret: "secret"})
+	s.Quirks.FormResponses = true
+	s.Quirks.ErrorStatus = http.StatusOK
+	ctx := s.Context(context.Background())
+	conf := s.Config("app")
+
+	_, err := conf.Exchange(ctx, "bad-code")
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.ErrorCode != "invalid_grant" {
+		t.Fatalf("Exchange = %v; want invalid_grant", err)
+	}
+	if re.Response.StatusCode != http.StatusOK {
+		t.Errorf("status = %d; want 200", re.Response.StatusCode)
+	}
+}
+
+func TestHTTPClientRoutes(t *testing.T) {
+	s := NewServer()
+	if _, err := s.HTTPClient().Get("https://elsewhere.example/"); err == nil {
+		t.Error("request to another host succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrScriptExhausted is returned by a ScriptedTokenSource called more
+// times than it has results.
+var ErrScriptExhausted = errors.New("oauth2test: scripted token source exhausted")
+
+// TokenCall records a call to the Token method of a
+// RecordingTokenSource.
+type TokenCall struct {
+	Token *oauth2.Token
+	Err   error
+	Time  time.Time
+}
+
+// RecordingTokenSource is a TokenSource recording the calls made to
+// another one. It is safe for concurrent use.
+type RecordingTokenSource struct {
+	// Source returns the tokens and errors of the recorded calls.
+	Source oauth2.TokenSource
+
+	mu    sync.Mutex
+	calls []TokenCall
+}
+
+// Token calls r.Source and records the result.
+func (r *RecordingTokenSource) Token() (*oauth2.Token, error) {
+	t, err := r.Source.Token()
+	r.mu.Lock()
+	r.calls = append(r.calls, TokenCall{Token: t, Err: err, Time: time.Now()})
+	r.mu.Unlock()
+	return t, err
+}
+
+// Calls returns the calls recorded so far, oldest first.
+func (r *RecordingTokenSource) Calls() []TokenCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]TokenCall(nil), r.calls...)
+}
+
+// Reset discards the recorded calls.
+func (r *RecordingTokenSource) Reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.mu.Unlock()
+}
+
+// Result is a result returned by a ScriptedTokenSource.
+type Result struct {
+	Token *oauth2.Token
+	Err   error
+}
+
+// ScriptedTokenSource is a TokenSource returning a sequence of tokens
+// and errors. It is safe for concurrent use.
+type ScriptedTokenSource struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+// NewScriptedTokenSource returns a ScriptedTokenSource returning
+// results in order. Once they are used up, it returns
+// ErrScriptExhausted.
+func NewScriptedTokenSource(results ...Result) *ScriptedTokenSource {
+	return &ScriptedTokenSource{results: append([]Result(nil), results...)}
+}
+
+// Token returns the next result of s.
+func (s *ScriptedTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.results) == 0 {
+		return nil, ErrScriptExhausted
+	}
+	r := s.results[0]
+	s.results = s.results[1:]
+	return r.Token, r.Err
+}
+
+// Append adds results to the end of the script of s.
+func (s *ScriptedTokenSource) Append(results ...Result) {
+	s.mu.Lock()
+	s.results = append(s.results, results...)
+	s.mu.Unlock()
+}
+
+// Remaining reports how many results s has not returned yet.
+func (s *ScriptedTokenSource) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.results)
+}
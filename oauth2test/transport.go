@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TransportRecorder is an http.RoundTripper recording the requests it
+// sends, typically used as the base transport of an oauth2.Transport
+// to assert which Authorization headers were sent. It is safe for
+// concurrent use.
+type TransportRecorder struct {
+	// Base sends the recorded requests. If nil, each request gets an
+	// empty 200 OK response.
+	Base http.RoundTripper
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+// RoundTrip records a copy of req, without its body, and sends it
+// with r.Base.
+func (r *TransportRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	clone.Body = nil
+	r.mu.Lock()
+	r.requests = append(r.requests, clone)
+	r.mu.Unlock()
+	if r.Base != nil {
+		return r.Base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the requests recorded so far, oldest first.
+func (r *TransportRecorder) Requests() []*http.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*http.Request(nil), r.requests...)
+}
+
+// Authorizations returns the Authorization header of each recorded
+// request, oldest first, with "" for requests without one.
+func (r *TransportRecorder) Authorizations() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auths := make([]string, len(r.requests))
+	for i, req := range r.requests {
+		auths[i] = req.Header.Get("Authorization")
+	}
+	return auths
+}
+
+// Reset discards the recorded requests.
+func (r *TransportRecorder) Reset() {
+	r.mu.Lock()
+	r.requests = nil
+	r.mu.Unlock()
+}
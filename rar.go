@@ -0,0 +1,133 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// AuthorizationDetail is an authorization details object of a Rich
+// Authorization Request, such as a payment initiation.
+// See https://datatracker.ietf.org/doc/html/rfc9396#section-2.
+type AuthorizationDetail struct {
+	// Type is the type of the authorization, such as
+	// "payment_initiation". Required.
+	Type string `json:"type"`
+
+	// The common data fields of RFC 9396 section 2.2. Optional.
+	Locations  []string `json:"locations,omitempty"`
+	Actions    []string `json:"actions,omitempty"`
+	DataTypes  []string `json:"datatypes,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+	Privileges []string `json:"privileges,omitempty"`
+
+	// Fields holds the fields specific to Type, such as
+	// "instructedAmount", keyed by name. Fields named like the ones
+	// above are ignored when encoding.
+	Fields map[string]interface{} `json:"-"`
+}
+
+// authorizationDetail has the fields of AuthorizationDetail that are
+// encoded by the standard JSON encoder.
+type authorizationDetail AuthorizationDetail
+
+func (d AuthorizationDetail) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(authorizationDetail(d))
+	if err != nil || len(d.Fields) == 0 {
+		return b, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range d.Fields {
+		if _, ok := m[k]; !ok && !isCommonDetailField(k) {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+func (d *AuthorizationDetail) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*authorizationDetail)(d)); err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	d.Fields = nil
+	for k, v := range m {
+		if isCommonDetailField(k) {
+			continue
+		}
+		if d.Fields == nil {
+			d.Fields = make(map[string]interface{})
+		}
+		d.Fields[k] = v
+	}
+	return nil
+}
+
+func isCommonDetailField(name string) bool {
+	switch name {
+	case "type", "locations", "actions", "datatypes", "identifier", "privileges":
+		return true
+	}
+	return false
+}
+
+// AuthorizationDetails is the value of the "authorization_details"
+// parameter of a Rich Authorization Request.
+type AuthorizationDetails []AuthorizationDetail
+
+// AuthorizationDetailsOption returns an AuthCodeOption that sends details
+// in the "authorization_details" parameter. It applies to AuthCodeURL and
+// to token requests; use AuthURLOnly or TokenRequestOnly to restrict it.
+func AuthorizationDetailsOption(details AuthorizationDetails) (AuthCodeOption, error) {
+	for i, d := range details {
+		if d.Type == "" {
+			return nil, fmt.Errorf("oauth2: authorization detail %d has no type", i)
+		}
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot encode authorization details: %v", err)
+	}
+	return SetAuthURLParam("authorization_details", string(b)), nil
+}
+
+// AuthorizationDetails returns the authorization details granted, as
+// reported in the "authorization_details" field of the token response.
+// It returns nil and no error if the response had no such field.
+func (t *Token) AuthorizationDetails() (AuthorizationDetails, error) {
+	var b []byte
+	switch raw := t.raw.(type) {
+	case map[string]interface{}:
+		v, ok := raw["authorization_details"]
+		if !ok || v == nil {
+			return nil, nil
+		}
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	case url.Values:
+		s := raw.Get("authorization_details")
+		if s == "" {
+			return nil, nil
+		}
+		b = []byte(s)
+	default:
+		return nil, nil
+	}
+	var details AuthorizationDetails
+	if err := json.Unmarshal(b, &details); err != nil {
+		return nil, fmt.Errorf("oauth2: cannot parse authorization_details: %v", err)
+	}
+	return details, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizationDetails(t *testing.T) {
+	details := AuthorizationDetails{{
+		Type:      "payment_initiation",
+		Locations: []string{"https://bank.example/payments"},
+		Fields: map[string]interface{}{
+			"instructedAmount": map[string]interface{}{"currency": "EUR", "amount": "123.50"},
+			"type":             "ignored",
+		},
+	}}
+	const want = `[{"instructedAmount":{"amount":"123.50","currency":"EUR"},"locations":["https://bank.example/payments"],"type":"payment_initiation"}]`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("authorization_details"); got != want {
+			t.Errorf("token request authorization_details = %s; want %s", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"a","authorization_details":`+want+`}`)
+	}))
+	defer ts.Close()
+
+	opt, err := AuthorizationDetailsOption(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := newConf(ts.URL)
+	u, _ := url.Parse(conf.AuthCodeURL("state", opt))
+	if got := u.Query().Get("authorization_details"); got != want {
+		t.Errorf("AuthCodeURL authorization_details = %s; want %s", got, want)
+	}
+	tok, err := conf.Exchange(context.Background(), "code", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tok.AuthorizationDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(details[0].Fields, "type")
+	if !reflect.DeepEqual(got, details) {
+		t.Errorf("AuthorizationDetails() = %+v; want %+v", got, details)
+	}
+
+	if _, err := AuthorizationDetailsOption(AuthorizationDetails{{}}); err == nil {
+		t.Error("AuthorizationDetailsOption accepted a detail without type")
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package resourceserver validates JWT access tokens (RFC 9068) for
+// OAuth 2.0 resource servers.
+package resourceserver // import "golang.org/x/oauth2/resourceserver"
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // for crypto.SHA384 and crypto.SHA512
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/jwks"
+)
+
+// ErrInvalidToken is wrapped by every error returned for a token that
+// fails validation, as opposed to errors fetching the key set.
+var ErrInvalidToken = errors.New("resourceserver: invalid access token")
+
+// Validator validates JWT access tokens issued by one authorization
+// server for one resource server.
+type Validator struct {
+	// Issuer is the authorization server's issuer identifier, which
+	// must equal the token's "iss" claim. Required.
+	Issuer string
+
+	// Audience is the resource server's identifier, which must be
+	// one of the token's "aud" claims. Required.
+	Audience string
+
+	// Keys is the authorization server's key set. If a token names a
+	// key that is not in the set, the set is refreshed once, at most
+	// once per Keys.MinRefreshInterval. Required.
+	Keys *jwks.Source
+
+	// Leeway optionally is the allowed clock skew when checking the
+	// "exp", "nbf" and "iat" claims.
+	Leeway time.Duration
+}
+
+// Principal describes the subject of a valid access token.
+type Principal struct {
+	// Subject is the "sub" claim: the resource owner, or the client
+	// itself for client credentials grants.
+	Subject string
+
+	// ClientID is the "client_id" claim: the client the token was
+	// issued to.
+	ClientID string
+
+	// Issuer is the "iss" claim.
+	Issuer string
+
+	// Audience is the "aud" claim.
+	Audience []string
+
+	// Scopes are the scopes of the "scope" claim.
+	Scopes []string
+
+	// Expiry is the "exp" claim, and IssuedAt the "iat" claim.
+	Expiry   time.Time
+	IssuedAt time.Time
+
+	// JWTID is the "jti" claim.
+	JWTID string
+
+	// CertificateThumbprint is the x5t#S256 member of the "cnf" claim
+	// of certificate-bound tokens (RFC 8705): the thumbprint of the
+	// client certificate the token is bound to.
+	CertificateThumbprint string
+
+	// Claims holds all the claims of the token, such as "auth_time",
+	// "acr", "groups" or "roles".
+	Claims map[string]interface{}
+}
+
+// HasScope reports whether the token was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// timeNow is time.Now but pulled out as a variable for tests.
+var timeNow = time.Now
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Iss      string          `json:"iss"`
+	Sub      string          `json:"sub"`
+	Aud      json.RawMessage `json:"aud"`
+	Exp      *int64          `json:"exp"`
+	Nbf      *int64          `json:"nbf"`
+	Iat      *int64          `json:"iat"`
+	Jti      string          `json:"jti"`
+	ClientID string          `json:"client_id"`
+	Scope    string          `json:"scope"`
+	Cnf      struct {
+		X5TS256 string `json:"x5t#S256"`
+	} `json:"cnf"`
+}
+
+// Validate validates token and returns its principal. A token is valid
+// if its header has the "at+jwt" type, it is signed with an RS, PS or ES
+// algorithm by a key in v.Keys, and its iss, aud, exp, nbf, iat, sub and
+// client_id claims are present where required and acceptable.
+func (v *Validator) Validate(ctx context.Context, token string) (*Principal, error) {
+	if v.Issuer == "" || v.Audience == "" || v.Keys == nil {
+		return nil, errors.New("resourceserver: Issuer, Audience and Keys must be set")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, invalid("malformed header: %v", err)
+	}
+	if typ := strings.ToLower(h.Typ); typ != "at+jwt" && typ != "application/at+jwt" {
+		return nil, invalid("token type %q is not at+jwt", h.Typ)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed signature: %v", err)
+	}
+	key, err := v.key(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(h.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, invalid("malformed claims: %v", err)
+	}
+	var all map[string]interface{}
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return nil, invalid("malformed claims: %v", err)
+	}
+	p := &Principal{
+		Subject:  c.Sub,
+		ClientID: c.ClientID,
+		Issuer:   c.Iss,
+		Scopes:   strings.Fields(c.Scope),
+		JWTID:    c.Jti,
+		Claims:   all,
+
+		CertificateThumbprint: c.Cnf.X5TS256,
+	}
+	if err := v.checkClaims(&c, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ValidateRequest validates the bearer token in the Authorization
+// header of r, as Validate does. If the token is certificate-bound, it
+// also checks that r was made over a mutual TLS connection with the
+// client certificate the token is bound to, as VerifyCertificate does.
+func (v *Validator) ValidateRequest(r *http.Request) (*Principal, error) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return nil, invalid("no bearer token in request")
+	}
+	p, err := v.Validate(r.Context(), strings.TrimSpace(auth[7:]))
+	if err != nil {
+		return nil, err
+	}
+	if p.CertificateThumbprint != "" {
+		var cert *x509.Certificate
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			cert = r.TLS.PeerCertificates[0]
+		}
+		if err := p.VerifyCertificate(cert); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// VerifyCertificate checks that cert, the client certificate of the
+// mutual TLS connection the token of p was presented over, is the one
+// the token is bound to, as described in RFC 8705 section 3. Tokens
+// that are not certificate-bound are accepted with any certificate,
+// including nil.
+func (p *Principal) VerifyCertificate(cert *x509.Certificate) error {
+	if p.CertificateThumbprint == "" {
+		return nil
+	}
+	if cert == nil {
+		return invalid("certificate-bound token presented without a client certificate")
+	}
+	sum := sha256.Sum256(cert.Raw)
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != p.CertificateThumbprint {
+		return invalid("token is bound to another client certificate")
+	}
+	return nil
+}
+
+func (v *Validator) checkClaims(c *claims, p *Principal) error {
+	if c.Iss != v.Issuer {
+		return invalid("issuer %q, want %q", c.Iss, v.Issuer)
+	}
+	switch {
+	case len(c.Aud) == 0:
+	case c.Aud[0] == '[':
+		if err := json.Unmarshal(c.Aud, &p.Audience); err != nil {
+			return invalid("malformed aud claim")
+		}
+	default:
+		var aud string
+		if err := json.Unmarshal(c.Aud, &aud); err != nil {
+			return invalid("malformed aud claim")
+		}
+		p.Audience = []string{aud}
+	}
+	found := false
+	for _, a := range p.Audience {
+		found = found || a == v.Audience
+	}
+	if !found {
+		return invalid("audience %q does not include %q", p.Audience, v.Audience)
+	}
+	if c.Exp == nil || c.Iat == nil || c.Sub == "" || c.ClientID == "" {
+		return invalid("missing exp, iat, sub or client_id claim")
+	}
+	now := timeNow()
+	p.Expiry = time.Unix(*c.Exp, 0)
+	p.IssuedAt = time.Unix(*c.Iat, 0)
+	if !now.Before(p.Expiry.Add(v.Leeway)) {
+		return invalid("token expired at %v", p.Expiry)
+	}
+	if p.IssuedAt.After(now.Add(v.Leeway)) {
+		return invalid("token issued in the future")
+	}
+	if c.Nbf != nil && time.Unix(*c.Nbf, 0).After(now.Add(v.Leeway)) {
+		return invalid("token not valid yet")
+	}
+	return nil
+}
+
+// key returns the key that signed a token with header h.
+func (v *Validator) key(ctx context.Context, h header) (*jwks.Key, error) {
+	for refreshed := false; ; refreshed = true {
+		set, err := v.Keys.KeySet(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if h.Kid == "" && len(set.Keys) == 1 {
+			return &set.Keys[0], nil
+		}
+		if k, ok := set.Lookup(h.Kid); ok {
+			return k, nil
+		}
+		if refreshed {
+			return nil, invalid("unknown key %q", h.Kid)
+		}
+		v.Keys.Refresh()
+	}
+}
+
+// verify checks the signature sig of signed with key, using alg.
+func verify(alg string, key *jwks.Key, signed, sig []byte) error {
+	if key.Use != "" && key.Use != "sig" {
+		return invalid("key %q is not a signing key", key.KeyID)
+	}
+	if key.Algorithm != "" && key.Algorithm != alg {
+		return invalid("algorithm %q does not match key algorithm %q", alg, key.Algorithm)
+	}
+	if len(alg) != 5 {
+		return invalid("unsupported algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return invalid("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	var ok bool
+	switch pub := key.Key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			ok = rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+		case "PS":
+			ok = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		default:
+			return invalid("algorithm %q cannot be used with an RSA key", alg)
+		}
+	case *ecdsa.PublicKey:
+		// RFC 7518, section 3.4, ties each ES algorithm to a curve.
+		curve := map[string]string{"ES256": "P-256", "ES384": "P-384", "ES512": "P-521"}[alg]
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if curve != pub.Curve.Params().Name || len(sig) != 2*size {
+			return invalid("algorithm %q cannot be used with this EC key", alg)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		ok = ecdsa.Verify(pub, digest, r, s)
+	default:
+		return invalid("unsupported key type %T", key.Key)
+	}
+	if !ok {
+		return invalid("invalid signature")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidToken}, args...)...)
+}
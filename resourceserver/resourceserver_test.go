@@ -0,0 +1,221 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package resourceserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2/jwks"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	src *jwks.Source
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa1","alg":"RS256","n":%q,"e":%q},
+		{"kty":"EC","kid":"ec1","crv":"P-256","x":%q,"y":%q}
+	]}`, b64(rk.N.Bytes()), b64(big.NewInt(int64(rk.E)).Bytes()), b64(ek.X.FillBytes(make([]byte, 32))), b64(ek.Y.FillBytes(make([]byte, 32))))
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, []byte(set), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{rsa: rk, ec: ek, src: &jwks.Source{File: file}}
+}
+
+// sign returns a token with the given header and claims, signed with
+// the RSA key for RS256 and the EC key for ES256.
+func (k *testKeys) sign(t *testing.T, header, claims map[string]interface{}) string {
+	hb, _ := json.Marshal(header)
+	cb, _ := json.Marshal(claims)
+	signed := b64(hb) + "." + b64(cb)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch header["alg"] {
+	case "RS256":
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(sig)
+}
+
+func TestValidate(t *testing.T) {
+	keys := newTestKeys(t)
+	v := &Validator{Issuer: "https://as", Audience: "https://api", Keys: keys.src}
+	now := time.Now().Unix()
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": "https://as", "aud": []string{"https://other", "https://api"},
+			"sub": "alice", "client_id": "app", "scope": "read write",
+			"exp": now + 300, "iat": now, "jti": "1", "groups": []string{"admin"},
+		}
+	}
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		kid := map[string]string{"RS256": "rsa1", "ES256": "ec1"}[alg]
+		tok := keys.sign(t, map[string]interface{}{"alg": alg, "typ": "at+jwt", "kid": kid}, validClaims())
+		p, err := v.Validate(context.Background(), tok)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if p.Subject != "alice" || p.ClientID != "app" || !p.HasScope("write") || p.HasScope("admin") || p.Expiry.Unix() != now+300 {
+			t.Errorf("%s: principal = %+v", alg, p)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+keys.sign(t, map[string]interface{}{"alg": "RS256", "typ": "application/at+jwt", "kid": "rsa1"}, validClaims()))
+	if _, err := v.ValidateRequest(r); err != nil {
+		t.Errorf("ValidateRequest: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]interface{}
+		modify func(map[string]interface{})
+	}{
+		{"wrong typ", map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": "rsa1"}, func(map[string]interface{}) {}},
+		{"alg mismatch", map[string]interface{}{"alg": "ES256", "typ": "at+jwt", "kid": "rsa1"}, func(map[string]interface{}) {}},
+		{"unknown key", map[string]interface{}{"alg": "RS256", "typ": "at+jwt", "kid": "nope"}, func(map[string]interface{}) {}},
+		{"wrong issuer", nil, func(c map[string]interface{}) { c["iss"] = "https://evil" }},
+		{"wrong audience", nil, func(c map[string]interface{}) { c["aud"] = "https://other" }},
+		{"expired", nil, func(c map[string]interface{}) { c["exp"] = now - 1 }},
+		{"missing client_id", nil, func(c map[string]interface{}) { delete(c, "client_id") }},
+		{"not yet valid", nil, func(c map[string]interface{}) { c["nbf"] = now + 100 }},
+	}
+	for _, tt := range tests {
+		h := tt.header
+		if h == nil {
+			h = map[string]interface{}{"alg": "RS256", "typ": "at+jwt", "kid": "rsa1"}
+		}
+		c := validClaims()
+		tt.modify(c)
+		if _, err := v.Validate(context.Background(), keys.sign(t, h, c)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Validate error = %v; want ErrInvalidToken", tt.name, err)
+		}
+	}
+
+	// A tampered token fails signature verification.
+	parts := strings.Split(keys.sign(t, map[string]interface{}{"alg": "RS256", "typ": "at+jwt", "kid": "rsa1"}, validClaims()), ".")
+	c := validClaims()
+	c["sub"] = "mallory"
+	cb, _ := json.Marshal(c)
+	tampered := parts[0] + "." + b64(cb) + "." + parts[2]
+	if _, err := v.Validate(context.Background(), tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered token: %v; want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyKeyRestrictions(t *testing.T) {
+	signed := []byte("header.claims")
+	digest := sha256.Sum256(signed)
+
+	// ES256 requires a P-256 key.
+	ek, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, ek, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := append(r.FillBytes(make([]byte, 48)), s.FillBytes(make([]byte, 48))...)
+	if err := verify("ES256", &jwks.Key{Key: &ek.PublicKey}, signed, sig); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ES256 with a P-384 key: %v; want ErrInvalidToken", err)
+	}
+
+	// Keys for other uses than signatures are rejected.
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err = rsa.SignPKCS1v15(rand.Reader, rk, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify("RS256", &jwks.Key{Use: "sig", Key: &rk.PublicKey}, signed, sig); err != nil {
+		t.Errorf("RS256 with a signing key: %v", err)
+	}
+	if err := verify("RS256", &jwks.Key{Use: "enc", Key: &rk.PublicKey}, signed, sig); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RS256 with an encryption key: %v; want ErrInvalidToken", err)
+	}
+}
+
+func TestCertificateBoundToken(t *testing.T) {
+	keys := newTestKeys(t)
+	v := &Validator{Issuer: "https://as", Audience: "https://api", Keys: keys.src}
+	bound := &x509.Certificate{Raw: []byte("bound certificate")}
+	other := &x509.Certificate{Raw: []byte("other certificate")}
+	sum := sha256.Sum256(bound.Raw)
+	now := time.Now().Unix()
+	tok := keys.sign(t, map[string]interface{}{"alg": "RS256", "typ": "at+jwt", "kid": "rsa1"}, map[string]interface{}{
+		"iss": "https://as", "aud": "https://api", "sub": "alice", "client_id": "app",
+		"exp": now + 300, "iat": now, "cnf": map[string]string{"x5t#S256": b64(sum[:])},
+	})
+
+	for _, tt := range []struct {
+		name  string
+		certs []*x509.Certificate
+		ok    bool
+	}{
+		{"bound certificate", []*x509.Certificate{bound}, true},
+		{"other certificate", []*x509.Certificate{other}, false},
+		{"no certificate", nil, false},
+	} {
+		r := httptest.NewRequest("GET", "https://api/", nil)
+		r.TLS.PeerCertificates = tt.certs
+		r.Header.Set("Authorization", "Bearer "+tok)
+		p, err := v.ValidateRequest(r)
+		if tt.ok && err != nil {
+			t.Errorf("%s: ValidateRequest: %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: ValidateRequest error = %v; want ErrInvalidToken", tt.name, err)
+		}
+		if p != nil && p.CertificateThumbprint != b64(sum[:]) {
+			t.Errorf("%s: CertificateThumbprint = %q", tt.name, p.CertificateThumbprint)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import "time"
+
+// RetryPolicy controls retrying of token requests that fail before a
+// response is received from the token endpoint.
+//
+// Each class of failure has its own retry budget, so that, for example, a
+// flaky resolver cannot use up the retries meant for connections reset by
+// a load balancer. Failures that are not in any class below, and every
+// HTTP response regardless of its status code, are never retried.
+type RetryPolicy struct {
+	// DNSRetries is the number of retries after temporary DNS
+	// failures, such as a resolver timeout. Lookups of hosts that do
+	// not exist are not retried.
+	DNSRetries int
+
+	// ConnResetRetries is the number of retries after the connection
+	// is reset by the peer.
+	ConnResetRetries int
+
+	// TLSHandshakeRetries is the number of retries after a TLS
+	// handshake times out.
+	TLSHandshakeRetries int
+
+	// Backoff is the delay before each retry.
+	Backoff time.Duration
+}
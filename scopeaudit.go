@@ -0,0 +1,120 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScopeAudit is an http.RoundTripper that records which APIs are called,
+// to help find scopes that a Config requests but never uses. It is meant
+// to be set as the Base of a Transport, or of the client returned by
+// Config.Client, while auditing an application:
+//
+//	audit := &oauth2.ScopeAudit{
+//		Scopes: conf.Scopes,
+//		Uses: map[string][]string{
+//			"https://www.googleapis.com/auth/drive": {"www.googleapis.com/drive/"},
+//		},
+//	}
+//	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: audit})
+//	client := conf.Client(ctx, tok)
+//	...
+//	log.Printf("unused scopes: %v", audit.Report().Unused)
+//
+// A ScopeAudit records every distinct host and path it sees, so it is
+// not intended to stay enabled in long-running production servers.
+type ScopeAudit struct {
+	// Base is the base RoundTripper used to make HTTP requests.
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Scopes are the scopes being audited, typically Config.Scopes.
+	Scopes []string
+
+	// Uses maps each scope to the URL prefixes, without scheme, of
+	// the APIs it grants access to, such as "api.example.com/v1/files/".
+	// A scope is exercised when a request's host and path begin with
+	// one of its prefixes.
+	Uses map[string][]string
+
+	mu    sync.Mutex
+	calls map[string]int // by host and path
+}
+
+// RoundTrip records req's host and path and sends req with a.Base.
+func (a *ScopeAudit) RoundTrip(req *http.Request) (*http.Response, error) {
+	a.mu.Lock()
+	if a.calls == nil {
+		a.calls = make(map[string]int)
+	}
+	a.calls[req.URL.Host+req.URL.Path]++
+	a.mu.Unlock()
+
+	base := a.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// ScopeReport is the result of a ScopeAudit.
+type ScopeReport struct {
+	// Calls counts the requests made to each host and path.
+	Calls map[string]int
+
+	// Used lists the scopes that were exercised by at least one
+	// request.
+	Used []string
+
+	// Unused lists the scopes that have URL prefixes in
+	// ScopeAudit.Uses but were never exercised. They are candidates
+	// for removal from the Config.
+	Unused []string
+
+	// Unmapped lists the scopes that have no URL prefixes in
+	// ScopeAudit.Uses, so the audit cannot tell whether they are
+	// used.
+	Unmapped []string
+}
+
+// Report returns a report of the requests recorded so far.
+func (a *ScopeAudit) Report() *ScopeReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := &ScopeReport{Calls: make(map[string]int, len(a.calls))}
+	for k, n := range a.calls {
+		r.Calls[k] = n
+	}
+	for _, scope := range a.Scopes {
+		prefixes := a.Uses[scope]
+		switch {
+		case len(prefixes) == 0:
+			r.Unmapped = append(r.Unmapped, scope)
+		case a.exercisedLocked(prefixes):
+			r.Used = append(r.Used, scope)
+		default:
+			r.Unused = append(r.Unused, scope)
+		}
+	}
+	sort.Strings(r.Used)
+	sort.Strings(r.Unused)
+	sort.Strings(r.Unmapped)
+	return r
+}
+
+func (a *ScopeAudit) exercisedLocked(prefixes []string) bool {
+	for call := range a.calls {
+		for _, p := range prefixes {
+			if strings.HasPrefix(call, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
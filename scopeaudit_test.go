@@ -0,0 +1,54 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestScopeAudit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	host := u.Host
+
+	audit := &ScopeAudit{
+		Scopes: []string{"files", "mail", "admin", "profile"},
+		Uses: map[string][]string{
+			"files": {host + "/files/"},
+			"mail":  {host + "/mail/"},
+			"admin": {host + "/admin/", "admin.example.com/"},
+		},
+	}
+	client := &http.Client{Transport: &Transport{
+		Source: StaticTokenSource(&Token{AccessToken: "abc"}),
+		Base:   audit,
+	}}
+	for _, p := range []string{"/files/1", "/files/2", "/files/1"} {
+		res, err := client.Get(ts.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	r := audit.Report()
+	if got, want := r.Calls, map[string]int{host + "/files/1": 2, host + "/files/2": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls = %v; want %v", got, want)
+	}
+	if got, want := r.Used, []string{"files"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Used = %v; want %v", got, want)
+	}
+	if got, want := r.Unused, []string{"admin", "mail"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unused = %v; want %v", got, want)
+	}
+	if got, want := r.Unmapped, []string{"profile"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmapped = %v; want %v", got, want)
+	}
+}
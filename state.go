@@ -0,0 +1,159 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrStateInvalid is returned by StateManager.Verify for state
+	// values that it did not generate or that were modified.
+	ErrStateInvalid = errors.New("oauth2: invalid state")
+
+	// ErrStateExpired is returned by StateManager.Verify for state
+	// values older than the lifetime of the StateManager.
+	ErrStateExpired = errors.New("oauth2: state expired")
+)
+
+// DefaultStateLifetime is the lifetime of state values generated by a
+// StateManager with no Lifetime.
+const DefaultStateLifetime = 10 * time.Minute
+
+// StateManager generates and verifies state parameters that carry their
+// own data, signed with HMAC-SHA256, so that web applications need not
+// store pending authorizations on the server.
+//
+// A state value cannot be forged or modified without the key, but it can
+// be replayed until it expires. To prevent login CSRF, bind each value to
+// the user agent that started the authorization, for example by storing
+// its Nonce in a short-lived cookie and comparing it to that of the
+// verified state. To reject replays outright, record the nonces of
+// verified states until they expire.
+type StateManager struct {
+	// Key is the HMAC key. It must be at least 32 bytes long and
+	// kept secret. Rotating it invalidates pending state values.
+	Key []byte
+
+	// Lifetime optionally specifies how long state values are
+	// valid. If zero, DefaultStateLifetime is used.
+	Lifetime time.Duration
+
+	// Now optionally returns the current time. The default is
+	// time.Now.
+	Now func() time.Time
+}
+
+// SignedState is the data carried by a state value.
+type SignedState struct {
+	// Value is the state value, to pass to Config.AuthCodeURL.
+	Value string
+
+	// RedirectTo is the location the application should redirect
+	// the user to once the authorization completes.
+	RedirectTo string
+
+	// Nonce is a random value unique to the state value. It can be
+	// passed to NonceOption and VerifyIDTokenNonce as the OpenID
+	// Connect nonce.
+	Nonce string
+
+	// Expiry is the time the state value expires.
+	Expiry time.Time
+}
+
+type statePayload struct {
+	RedirectTo string `json:"r,omitempty"`
+	Nonce      string `json:"n"`
+	Expiry     int64  `json:"e"`
+}
+
+// New generates a state value carrying redirectTo and a fresh nonce.
+func (m *StateManager) New(redirectTo string) (*SignedState, error) {
+	if err := m.checkKey(); err != nil {
+		return nil, err
+	}
+	lifetime := m.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultStateLifetime
+	}
+	p := statePayload{
+		RedirectTo: redirectTo,
+		Nonce:      GenerateNonce(),
+		Expiry:     m.now().Add(lifetime).Unix(),
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return &SignedState{
+		Value:      payload + "." + base64.RawURLEncoding.EncodeToString(m.sign(payload)),
+		RedirectTo: p.RedirectTo,
+		Nonce:      p.Nonce,
+		Expiry:     time.Unix(p.Expiry, 0),
+	}, nil
+}
+
+// Verify checks the signature and expiry of the state value, comparing
+// signatures in constant time, and returns the data it carries. It
+// returns ErrStateInvalid or ErrStateExpired if the value is not valid.
+func (m *StateManager) Verify(value string) (*SignedState, error) {
+	if err := m.checkKey(); err != nil {
+		return nil, err
+	}
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrStateInvalid
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, m.sign(payload)) {
+		return nil, ErrStateInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrStateInvalid
+	}
+	var p statePayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, ErrStateInvalid
+	}
+	expiry := time.Unix(p.Expiry, 0)
+	if !m.now().Before(expiry) {
+		return nil, ErrStateExpired
+	}
+	return &SignedState{
+		Value:      value,
+		RedirectTo: p.RedirectTo,
+		Nonce:      p.Nonce,
+		Expiry:     expiry,
+	}, nil
+}
+
+func (m *StateManager) checkKey() error {
+	if len(m.Key) < 32 {
+		return errors.New("oauth2: StateManager key must be at least 32 bytes")
+	}
+	return nil
+}
+
+func (m *StateManager) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, m.Key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func (m *StateManager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
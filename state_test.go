@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateManager(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := &StateManager{
+		Key: []byte(strings.Repeat("k", 32)),
+		Now: func() time.Time { return now },
+	}
+	st, err := m.New("/dashboard?tab=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Nonce == "" || !st.Expiry.Equal(now.Add(DefaultStateLifetime)) {
+		t.Errorf("New = %+v; want a nonce and an expiry in %v", st, DefaultStateLifetime)
+	}
+	got, err := m.Verify(st.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *st {
+		t.Errorf("Verify = %+v; want %+v", got, st)
+	}
+
+	other, _ := m.New("/dashboard?tab=1")
+	if other.Value == st.Value || other.Nonce == st.Nonce {
+		t.Error("New returned the same state twice")
+	}
+
+	payload, sig, _ := strings.Cut(st.Value, ".")
+	forged, _ := (&StateManager{Key: []byte(strings.Repeat("x", 32)), Now: m.Now}).New("https://evil.example/")
+	forgedPayload, _, _ := strings.Cut(forged.Value, ".")
+	for _, v := range []string{"", payload, payload + ".", forgedPayload + "." + sig, forged.Value, st.Value + "x"} {
+		if _, err := m.Verify(v); err != ErrStateInvalid {
+			t.Errorf("Verify(%q) = %v; want ErrStateInvalid", v, err)
+		}
+	}
+
+	now = now.Add(DefaultStateLifetime)
+	if _, err := m.Verify(st.Value); err != ErrStateExpired {
+		t.Errorf("Verify of an expired state = %v; want ErrStateExpired", err)
+	}
+
+	if _, err := (&StateManager{Key: []byte("short")}).New(""); err == nil {
+		t.Error("New with a short key succeeded")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errInsufficientUserAuthentication is the error code of step-up
+// challenges. See https://datatracker.ietf.org/doc/html/rfc9470#section-3.
+const errInsufficientUserAuthentication = "insufficient_user_authentication"
+
+// StepUpError reports that a resource server requires stronger or more
+// recent user authentication than the access token reflects, as
+// described in RFC 9470. The client should obtain a new token with
+// AuthCodeURL, passing AuthCodeOptions, and retry the request.
+type StepUpError struct {
+	// Challenge is the resource server's Bearer challenge, with
+	// Error "insufficient_user_authentication".
+	Challenge *BearerChallenge
+}
+
+func (e *StepUpError) Error() string {
+	var b strings.Builder
+	b.WriteString("oauth2: insufficient user authentication")
+	if d := e.Challenge.ErrorDescription; d != "" {
+		fmt.Fprintf(&b, ": %s", d)
+	}
+	if acr := e.Challenge.ACRValues; acr != "" {
+		fmt.Fprintf(&b, " (acr_values %q)", acr)
+	}
+	if e.Challenge.MaxAge >= 0 {
+		fmt.Fprintf(&b, " (max_age %d)", e.Challenge.MaxAge)
+	}
+	return b.String()
+}
+
+// AuthCodeOptions returns the options to pass to AuthCodeURL to request
+// the authentication required by the challenge.
+func (e *StepUpError) AuthCodeOptions() []AuthCodeOption {
+	var opts []AuthCodeOption
+	if acr := e.Challenge.ACRValues; acr != "" {
+		opts = append(opts, ACRValuesOption(acr))
+	}
+	if e.Challenge.MaxAge >= 0 {
+		opts = append(opts, MaxAgeOption(e.Challenge.MaxAge))
+	}
+	return opts
+}
+
+// StepUpFromResponse returns a *StepUpError if res is a 401 or 403 response
+// with a step-up Bearer challenge, and nil otherwise.
+func StepUpFromResponse(res *http.Response) *StepUpError {
+	c := BearerChallengeFromResponse(res)
+	if c == nil || c.Error != errInsufficientUserAuthentication {
+		return nil
+	}
+	return &StepUpError{Challenge: c}
+}
+
+// StepUp returns a *StepUpError if the response of r carries a step-up
+// challenge, and nil otherwise.
+func (r *RetrieveError) StepUp() *StepUpError {
+	if r.Response == nil {
+		return nil
+	}
+	return StepUpFromResponse(r.Response)
+}
+
+// ACRValuesOption returns an AuthCodeOption that requests the
+// authentication context class references in acrValues, space-delimited,
+// with the "acr_values" parameter of AuthCodeURL.
+func ACRValuesOption(acrValues string) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("acr_values", acrValues))
+}
+
+// MaxAgeOption returns an AuthCodeOption that sets the "max_age"
+// parameter of AuthCodeURL: the maximum time in seconds since the user
+// last authenticated. Zero requires the user to authenticate again.
+func MaxAgeOption(seconds int) AuthCodeOption {
+	return AuthURLOnly(SetAuthURLParam("max_age", strconv.Itoa(seconds)))
+}
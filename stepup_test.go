@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestTransportStepUpErrors(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication", error_description="A different authentication level is required", acr_values="myACR", max_age="5"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{
+		Source:       StaticTokenSource(&Token{AccessToken: "abc"}),
+		StepUpErrors: true,
+	}}
+	_, err := client.Get(server.URL)
+	var serr *StepUpError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Get() error = %v; want *StepUpError", err)
+	}
+	if c := serr.Challenge; c.ACRValues != "myACR" || c.MaxAge != 5 {
+		t.Errorf("challenge = %+v; want acr_values myACR and max_age 5", c)
+	}
+
+	conf := newConf("server")
+	u, _ := url.Parse(conf.AuthCodeURL("state", serr.AuthCodeOptions()...))
+	if q := u.Query(); q.Get("acr_values") != "myACR" || q.Get("max_age") != "5" {
+		t.Errorf("AuthCodeURL query = %v; want acr_values and max_age", q)
+	}
+}
+
+func TestStepUpFromResponse(t *testing.T) {
+	tests := []struct {
+		header     string
+		wantStepUp bool
+		wantMaxAge int
+	}{
+		{`Bearer error="insufficient_user_authentication", acr_values="a b"`, true, -1},
+		{`Bearer error="insufficient_user_authentication", max_age="0"`, true, 0},
+		{`Bearer error="invalid_token"`, false, 0},
+	}
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{"Www-Authenticate": {tt.header}}}
+		serr := StepUpFromResponse(res)
+		if (serr != nil) != tt.wantStepUp {
+			t.Errorf("StepUpFromResponse(%q) = %v; want step-up %v", tt.header, serr, tt.wantStepUp)
+			continue
+		}
+		if serr != nil && serr.Challenge.MaxAge != tt.wantMaxAge {
+			t.Errorf("StepUpFromResponse(%q).Challenge.MaxAge = %d; want %d", tt.header, serr.Challenge.MaxAge, tt.wantMaxAge)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// ConfigTemplate holds the client settings shared by the Configs of the
+// tenants of a multi-tenant application, which typically differ only in
+// their endpoints, such as those of the identity providers of its
+// customers. The Configs it returns share its auth style cache, so that
+// each token endpoint is probed once per client, and its HTTP client.
+//
+// A ConfigTemplate must not be modified or copied after first use.
+type ConfigTemplate struct {
+	// ClientID, ClientSecret, RedirectURL and Scopes are copied to
+	// the fields of the same name of the Configs.
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AuthStyle optionally is the auth style used with the tenant
+	// endpoints whose AuthStyle is the zero value.
+	AuthStyle AuthStyle
+
+	// AuthStyleCache optionally is the auth style cache of the
+	// Configs. If nil, the Configs share an unbounded cache.
+	AuthStyleCache *AuthStyleCache
+
+	// HTTPClient optionally is the HTTP client used for requests to
+	// the tenants' endpoints by contexts returned by Context.
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	cache   *AuthStyleCache
+	configs map[string]*Config
+}
+
+// Config returns the Config of tenant, whose endpoint is endpoint. The
+// Config is created on first use and returned by later calls with the
+// same endpoint, so that it can be used as the key of a ClientPool. It
+// must not be modified.
+func (t *ConfigTemplate) Config(tenant string, endpoint Endpoint) *Config {
+	if endpoint.AuthStyle == AuthStyleAutoDetect {
+		endpoint.AuthStyle = t.AuthStyle
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.configs[tenant]; ok && c.Endpoint == endpoint {
+		return c
+	}
+	cache := t.AuthStyleCache
+	if cache == nil {
+		if t.cache == nil {
+			t.cache = new(AuthStyleCache)
+		}
+		cache = t.cache
+	}
+	c := &Config{
+		ClientID:       t.ClientID,
+		ClientSecret:   t.ClientSecret,
+		Endpoint:       endpoint,
+		RedirectURL:    t.RedirectURL,
+		Scopes:         append([]string(nil), t.Scopes...),
+		AuthStyleCache: cache,
+	}
+	if t.configs == nil {
+		t.configs = make(map[string]*Config)
+	}
+	t.configs[tenant] = c
+	return c
+}
+
+// Forget discards the Config of tenant, such as when the tenant is
+// removed.
+func (t *ConfigTemplate) Forget(tenant string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.configs, tenant)
+}
+
+// Context returns a context that makes the Configs of t use
+// t.HTTPClient, unless it is nil or ctx already carries an HTTP client.
+func (t *ConfigTemplate) Context(ctx context.Context) context.Context {
+	if t.HTTPClient == nil {
+		return ctx
+	}
+	if _, ok := ctx.Value(HTTPClient).(*http.Client); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, HTTPClient, t.HTTPClient)
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConfigTemplate(t *testing.T) {
+	ts := newMockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token"}`))
+	})
+	defer ts.Close()
+
+	hc := &http.Client{}
+	tmpl := &ConfigTemplate{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		Scopes:       []string{"openid"},
+		HTTPClient:   hc,
+	}
+	a := tmpl.Config("a", Endpoint{TokenURL: ts.URL + "/token"})
+	b := tmpl.Config("b", Endpoint{TokenURL: ts.URL + "/token"})
+	if a == b || a.AuthStyleCache != b.AuthStyleCache || a.ClientID != "CLIENT_ID" || len(b.Scopes) != 1 {
+		t.Fatalf("Configs a = %+v, b = %+v; want distinct Configs with the template's settings and cache", a, b)
+	}
+	if got := tmpl.Config("a", Endpoint{TokenURL: ts.URL + "/token"}); got != a {
+		t.Error("Config returned a new Config for the same tenant and endpoint")
+	}
+	if got := tmpl.Config("a", Endpoint{TokenURL: ts.URL + "/other"}); got == a {
+		t.Error("Config returned the old Config for a new endpoint")
+	}
+	a = tmpl.Config("a", Endpoint{TokenURL: ts.URL + "/token"})
+
+	ctx := tmpl.Context(context.Background())
+	if got := ctx.Value(HTTPClient); got != hc {
+		t.Errorf("Context carries %v; want the template's client", got)
+	}
+	for _, c := range []*Config{a, b} {
+		if _, err := c.PasswordCredentialsToken(ctx, "user", "pass"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if style, ok := a.AuthStyleCache.LookupClient(ts.URL+"/token", "CLIENT_ID"); !ok || style != AuthStyleInHeader {
+		t.Errorf("cached style = %v, %v; want AuthStyleInHeader, true", style, ok)
+	}
+
+	tmpl = &ConfigTemplate{AuthStyle: AuthStyleInParams}
+	if c := tmpl.Config("c", Endpoint{TokenURL: ts.URL + "/token"}); c.Endpoint.AuthStyle != AuthStyleInParams {
+		t.Errorf("AuthStyle = %v; want the template's AuthStyleInParams", c.Endpoint.AuthStyle)
+	}
+}
@@ -6,6 +6,7 @@ package oauth2
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -64,6 +65,27 @@ type Token struct {
 	// expired, by subtracting from Expiry. If zero, defaultExpiryDelta
 	// is used.
 	expiryDelta time.Duration
+
+	// clock optionally provides the current time used by Valid. If
+	// nil, time.Now is used. It is a pointer to keep Token comparable.
+	clock *clock
+
+	// res optionally is the response of the token endpoint t was
+	// retrieved from.
+	res *http.Response
+}
+
+// clock holds a function returning the current time.
+type clock struct {
+	now func() time.Time
+}
+
+// newClock returns a clock using now, or nil if now is nil.
+func newClock(now func() time.Time) *clock {
+	if now == nil {
+		return nil
+	}
+	return &clock{now}
 }
 
 // Type returns t.TokenType if non-empty, else "Bearer".
@@ -130,6 +152,68 @@ func (t *Token) Extra(key string) interface{} {
 	return v
 }
 
+// Scopes returns the scopes the server reported as granted in the "scope"
+// field of the token response.
+//
+// Per RFC 6749 section 5.1, a server may omit "scope" when the granted
+// scopes are identical to those requested. Scopes distinguishes the two
+// cases: it returns nil if the response had no "scope" field, and a
+// non-nil, possibly empty, slice if it had one. An empty "scope" thus
+// means the server granted no scopes, which may indicate a downgrade.
+func (t *Token) Scopes() []string {
+	switch raw := t.raw.(type) {
+	case map[string]interface{}:
+		v, ok := raw["scope"]
+		if !ok || v == nil {
+			return nil
+		}
+		switch v := v.(type) {
+		case string:
+			return append([]string{}, strings.Fields(v)...)
+		case []interface{}:
+			// Some servers return scopes as a JSON array.
+			scopes := []string{}
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					scopes = append(scopes, s)
+				}
+			}
+			return scopes
+		}
+	case url.Values:
+		if v, ok := raw["scope"]; ok && len(v) > 0 {
+			return append([]string{}, strings.Fields(v[0])...)
+		}
+	}
+	return nil
+}
+
+// MissingScopes returns the scopes in requested that t was not granted,
+// according to Scopes. If the token response had no "scope" field, the
+// requested scopes are assumed to be granted and MissingScopes returns
+// nil.
+//
+// Comparing a refreshed token with the token it replaces, as in
+// next.MissingScopes(prev.Scopes()), detects a scope downgrade on
+// refresh.
+func (t *Token) MissingScopes(requested []string) []string {
+	granted := t.Scopes()
+	if granted == nil {
+		return nil
+	}
+	have := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		have[s] = true
+	}
+	var missing []string
+	for _, s := range requested {
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
 // timeNow is time.Now but pulled out as a variable for tests.
 var timeNow = time.Now
 
@@ -144,7 +228,19 @@ func (t *Token) expired() bool {
 	if t.expiryDelta != 0 {
 		expiryDelta = t.expiryDelta
 	}
-	return t.Expiry.Round(0).Add(-expiryDelta).Before(timeNow())
+	now := timeNow
+	if t.clock != nil {
+		now = t.clock.now
+	}
+	return t.Expiry.Round(0).Add(-expiryDelta).Before(now())
+}
+
+// HTTPResponse returns the status and headers of the response of the
+// token endpoint t was retrieved from, or nil if unknown. The headers,
+// such as request IDs and rate limits, let operators correlate token
+// requests with the provider's logs. Its Body and Request are nil.
+func (t *Token) HTTPResponse() *http.Response {
+	return t.res
 }
 
 // Valid reports whether t is non-nil, has an AccessToken, and is not expired.
@@ -152,6 +248,44 @@ func (t *Token) Valid() bool {
 	return t != nil && t.AccessToken != "" && !t.expired()
 }
 
+// ValidateToken reports an error if t is not usable as the initial token
+// of a TokenSource, such as one loaded from a cache on disk and passed to
+// ReuseTokenSource.
+//
+// It checks that t is non-nil, has an AccessToken, and has an Expiry that
+// is either zero or plausible. A token with ExpiresIn but no Expiry is
+// rejected, as it usually means Expiry was never populated before the
+// token was persisted. ValidateToken does not report expired tokens as
+// invalid, since those are simply refreshed.
+func ValidateToken(t *Token) error {
+	if t == nil {
+		return errors.New("oauth2: token is nil")
+	}
+	if t.AccessToken == "" {
+		return errors.New("oauth2: token is missing access_token")
+	}
+	if t.Expiry.IsZero() {
+		if t.ExpiresIn != 0 {
+			return errors.New("oauth2: token has expires_in but no Expiry")
+		}
+		return nil
+	}
+	if t.Expiry.Before(time.Unix(0, 0)) {
+		return fmt.Errorf("oauth2: token has implausible Expiry %v", t.Expiry)
+	}
+	return nil
+}
+
+func init() {
+	internal.PublicToken = func(t *internal.Token, now func() time.Time) interface{} {
+		tk := tokenFromInternal(t)
+		if tk != nil {
+			tk.clock = newClock(now)
+		}
+		return tk
+	}
+}
+
 // tokenFromInternal maps an *internal.Token struct into
 // a *Token struct.
 func tokenFromInternal(t *internal.Token) *Token {
@@ -164,6 +298,7 @@ func tokenFromInternal(t *internal.Token) *Token {
 		RefreshToken: t.RefreshToken,
 		Expiry:       t.Expiry,
 		raw:          t.Raw,
+		res:          t.Response,
 	}
 }
 
@@ -171,14 +306,41 @@ func tokenFromInternal(t *internal.Token) *Token {
 // This token is then mapped from *internal.Token into an *oauth2.Token which is returned along
 // with an error..
 func retrieveToken(ctx context.Context, c *Config, v url.Values) (*Token, error) {
-	tk, err := internal.RetrieveToken(ctx, c.ClientID, c.ClientSecret, c.Endpoint.TokenURL, v, internal.AuthStyle(c.Endpoint.AuthStyle), c.authStyleCache.Get())
+	return retrieveTokenWithHeader(ctx, c, v, nil)
+}
+
+// retrieveTokenWithHeader is like retrieveToken, but also sends the
+// headers in h, which may be nil.
+func retrieveTokenWithHeader(ctx context.Context, c *Config, v url.Values, h http.Header) (*Token, error) {
+	if c.EventHook == nil {
+		return doRetrieveToken(ctx, c, v, h)
+	}
+	ev := TokenEvent{TokenURL: c.Endpoint.TokenURL, GrantType: v.Get("grant_type")}
+	c.EventHook.OnTokenRequest(ev)
+	start := timeNow()
+	tk, err := doRetrieveToken(ctx, c, v, h)
+	ev.Duration = timeNow().Sub(start)
+	if err != nil {
+		ev.Err = err
+		c.EventHook.OnTokenError(ev)
+		return nil, err
+	}
+	ev.Token = tk
+	c.EventHook.OnTokenSuccess(ev)
+	return tk, nil
+}
+
+func doRetrieveToken(ctx context.Context, c *Config, v url.Values, h http.Header) (*Token, error) {
+	tk, err := internal.RetrieveToken(ctx, c.ClientID, c.ClientSecret, c.Endpoint.TokenURL, v, internal.AuthStyle(c.Endpoint.AuthStyle), c.styleCache(), c.retrieveOptions(h))
 	if err != nil {
 		if rErr, ok := err.(*internal.RetrieveError); ok {
 			return nil, (*RetrieveError)(rErr)
 		}
 		return nil, err
 	}
-	return tokenFromInternal(tk), nil
+	t := tokenFromInternal(tk)
+	t.clock = newClock(c.Now)
+	return t, nil
 }
 
 // RetrieveError is the error returned when the token endpoint returns a
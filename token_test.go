@@ -5,6 +5,8 @@
 package oauth2
 
 import (
+	"net/url"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -78,3 +80,67 @@ func TestTokenTypeMethod(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateToken(t *testing.T) {
+	cases := []struct {
+		name    string
+		tok     *Token
+		wantErr bool
+	}{
+		{name: "nil", tok: nil, wantErr: true},
+		{name: "no access token", tok: &Token{RefreshToken: "r"}, wantErr: true},
+		{name: "no expiry", tok: &Token{AccessToken: "a"}},
+		{name: "expired", tok: &Token{AccessToken: "a", Expiry: time.Now().Add(-time.Hour)}},
+		{name: "expires_in without expiry", tok: &Token{AccessToken: "a", ExpiresIn: 3600}, wantErr: true},
+		{name: "implausible expiry", tok: &Token{AccessToken: "a", Expiry: time.Date(1, 1, 2, 0, 0, 0, 0, time.UTC)}, wantErr: true},
+	}
+	for _, tc := range cases {
+		if err := ValidateToken(tc.tok); (err != nil) != tc.wantErr {
+			t.Errorf("ValidateToken(%q) = %v; wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestTokenScopes(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  interface{}
+		want []string
+	}{
+		{name: "json absent", raw: map[string]interface{}{}, want: nil},
+		{name: "json null", raw: map[string]interface{}{"scope": nil}, want: nil},
+		{name: "json empty", raw: map[string]interface{}{"scope": ""}, want: []string{}},
+		{name: "json string", raw: map[string]interface{}{"scope": "a  b"}, want: []string{"a", "b"}},
+		{name: "json array", raw: map[string]interface{}{"scope": []interface{}{"a", "b"}}, want: []string{"a", "b"}},
+		{name: "form absent", raw: url.Values{}, want: nil},
+		{name: "form empty", raw: url.Values{"scope": {""}}, want: []string{}},
+		{name: "form string", raw: url.Values{"scope": {"a b"}}, want: []string{"a", "b"}},
+		{name: "no raw", raw: nil, want: nil},
+	}
+	for _, tc := range cases {
+		got := (&Token{raw: tc.raw}).Scopes()
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Scopes (%q) = %#v; want %#v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTokenMissingScopes(t *testing.T) {
+	requested := []string{"a", "b", "c"}
+	cases := []struct {
+		name string
+		raw  interface{}
+		want []string
+	}{
+		{name: "no scope field", raw: map[string]interface{}{}, want: nil},
+		{name: "all granted", raw: map[string]interface{}{"scope": "c b a"}, want: nil},
+		{name: "downgraded", raw: map[string]interface{}{"scope": "b"}, want: []string{"a", "c"}},
+		{name: "none granted", raw: url.Values{"scope": {""}}, want: []string{"a", "b", "c"}},
+	}
+	for _, tc := range cases {
+		got := (&Token{raw: tc.raw}).MissingScopes(requested)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("MissingScopes (%q) = %#v; want %#v", tc.name, got, tc.want)
+		}
+	}
+}
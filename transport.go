@@ -5,6 +5,7 @@
 package oauth2
 
 import (
+	"crypto/x509"
 	"errors"
 	"log"
 	"net/http"
@@ -25,8 +26,67 @@ type Transport struct {
 	// Base is the base RoundTripper used to make HTTP requests.
 	// If nil, http.DefaultTransport is used.
 	Base http.RoundTripper
+
+	// EventHook optionally receives notifications about each call
+	// to Source. OnRefresh is never called by Transport.
+	EventHook EventHook
+
+	// OnChallenge optionally is notified when a resource server
+	// responds with a 401 or 403 status and a Bearer challenge in
+	// its WWW-Authenticate header. When the challenge's Error is
+	// "insufficient_scope", its Scope lists the scopes required to
+	// access the resource, so OnChallenge can trigger incremental
+	// consent for the missing ones. The response is returned to the
+	// caller unchanged.
+	OnChallenge ChallengeHandler
+
+	// StepUpErrors makes RoundTrip return a *StepUpError, instead of
+	// the response, when a resource server requires stronger or more
+	// recent user authentication, as described in RFC 9470.
+	StepUpErrors bool
+
+	// ClientCertificate optionally is the client certificate Base
+	// presents in TLS connections. If set, RoundTrip fails before
+	// sending a certificate-bound access token (RFC 8705) bound to
+	// another certificate, as reported by Token.CertificateThumbprint.
+	ClientCertificate *x509.Certificate
+
+	// DisableRefresh makes RoundTrip fail with ErrTokenExpired instead
+	// of refreshing the token on the request path, for applications
+	// that refresh tokens out of band, such as with
+	// NewAutoRefreshTokenSource or by calling Source's Token method on
+	// a schedule. Source must implement InspectableTokenSource, as the
+	// sources returned by ReuseTokenSource and Config.TokenSource do;
+	// RoundTrip uses the token returned by its Peek method, and fails
+	// without calling Source's Token method if Source does not
+	// implement it.
+	DisableRefresh bool
+
+	// Authorizer optionally authorizes each request with the token
+	// instead of Token.SetAuthHeader, for token types such as MAC
+	// whose Authorization header depends on the request.
+	Authorizer TokenAuthorizer
 }
 
+// A ChallengeHandler handles the Bearer challenges of resource servers
+// reported by Transport.
+type ChallengeHandler interface {
+	// HandleChallenge is called with a 401 or 403 response and its
+	// Bearer challenge. It must not read or close the response body.
+	HandleChallenge(res *http.Response, c *BearerChallenge)
+}
+
+// A TokenAuthorizer authorizes HTTP requests with an access token,
+// typically by setting their Authorization header.
+type TokenAuthorizer interface {
+	// AuthorizeRequest authorizes req, which it may modify, with t.
+	AuthorizeRequest(req *http.Request, t *Token) error
+}
+
+// ErrTokenExpired is returned by Transport's RoundTrip when
+// DisableRefresh is set and there is no valid cached token.
+var ErrTokenExpired = errors.New("oauth2: token expired and refresh is disabled")
+
 // RoundTrip authorizes and authenticates the request with an
 // access token from Transport's Source.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -42,17 +102,67 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if t.Source == nil {
 		return nil, errors.New("oauth2: Transport's Source is nil")
 	}
-	token, err := t.Source.Token()
+	token, err := t.token()
 	if err != nil {
 		return nil, err
 	}
 
+	if t.ClientCertificate != nil {
+		if err := checkCertificateBinding(token, t.ClientCertificate); err != nil {
+			return nil, err
+		}
+	}
+
 	req2 := cloneRequest(req) // per RoundTripper contract
-	token.SetAuthHeader(req2)
+	if t.Authorizer != nil {
+		if err := t.Authorizer.AuthorizeRequest(req2, token); err != nil {
+			return nil, err
+		}
+	} else {
+		token.SetAuthHeader(req2)
+	}
 
 	// req.Body is assumed to be closed by the base RoundTripper.
 	reqBodyClosed = true
-	return t.base().RoundTrip(req2)
+	res, err := t.base().RoundTrip(req2)
+	if err == nil && t.OnChallenge != nil {
+		if c := BearerChallengeFromResponse(res); c != nil {
+			t.OnChallenge.HandleChallenge(res, c)
+		}
+	}
+	if err == nil && t.StepUpErrors {
+		if serr := StepUpFromResponse(res); serr != nil {
+			res.Body.Close()
+			return nil, serr
+		}
+	}
+	return res, err
+}
+
+func (t *Transport) token() (*Token, error) {
+	if t.DisableRefresh {
+		its, ok := t.Source.(InspectableTokenSource)
+		if !ok {
+			return nil, errors.New("oauth2: Transport's DisableRefresh requires a Source implementing InspectableTokenSource")
+		}
+		if tok := its.Peek(); tok.Valid() {
+			return tok, nil
+		}
+		return nil, ErrTokenExpired
+	}
+	if t.EventHook == nil {
+		return t.Source.Token()
+	}
+	t.EventHook.OnTokenRequest(TokenEvent{})
+	start := timeNow()
+	token, err := t.Source.Token()
+	ev := TokenEvent{Token: token, Err: err, Duration: timeNow().Sub(start)}
+	if err != nil {
+		t.EventHook.OnTokenError(ev)
+	} else {
+		t.EventHook.OnTokenSuccess(ev)
+	}
+	return token, err
 }
 
 var cancelOnce sync.Once
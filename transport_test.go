@@ -1,6 +1,9 @@
 package oauth2
 
 import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -166,3 +169,79 @@ func TestExpiredWithExpiry(t *testing.T) {
 func newMockServer(handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(handler))
 }
+
+func TestTransportClientCertificate(t *testing.T) {
+	bound := &x509.Certificate{Raw: []byte("bound certificate")}
+	other := &x509.Certificate{Raw: []byte("other certificate")}
+	claims, _ := json.Marshal(map[string]interface{}{"cnf": map[string]string{"x5t#S256": CertificateThumbprint(bound)}})
+	tok := &Token{AccessToken: "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"}
+	if got, want := tok.CertificateThumbprint(), CertificateThumbprint(bound); got != want {
+		t.Fatalf("CertificateThumbprint() = %q; want %q", got, want)
+	}
+	if got := (&Token{AccessToken: "opaque"}).CertificateThumbprint(); got != "" {
+		t.Errorf("CertificateThumbprint() of an opaque token = %q; want empty", got)
+	}
+
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+	for _, tt := range []struct {
+		cert *x509.Certificate
+		ok   bool
+	}{
+		{bound, true},
+		{other, false},
+	} {
+		client := &http.Client{Transport: &Transport{
+			Source:            StaticTokenSource(tok),
+			ClientCertificate: tt.cert,
+		}}
+		res, err := client.Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("Get with certificate %q: err = %v; want success %v", tt.cert.Raw, err, tt.ok)
+		}
+	}
+}
+
+func TestTransportDisableRefresh(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+	calls := 0
+	src := ReuseTokenSource(&Token{AccessToken: "abc", Expiry: time.Now().Add(time.Hour)}, tokenSourceFunc(func() (*Token, error) {
+		calls++
+		return &Token{AccessToken: "new"}, nil
+	}))
+	client := &http.Client{Transport: &Transport{Source: src, DisableRefresh: true}}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	src.(InspectableTokenSource).Invalidate()
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Get with an expired token = %v; want ErrTokenExpired", err)
+	}
+	if calls != 0 {
+		t.Errorf("Source refreshed %d times; want 0", calls)
+	}
+}
+
+func TestTransportDisableRefreshNotInspectable(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+	calls := 0
+	src := tokenSourceFunc(func() (*Token, error) {
+		calls++
+		return &Token{AccessToken: "new"}, nil
+	})
+	client := &http.Client{Transport: &Transport{Source: src, DisableRefresh: true}}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("Get with a Source that is not an InspectableTokenSource succeeded; want error")
+	}
+	if calls != 0 {
+		t.Errorf("Source refreshed %d times; want 0", calls)
+	}
+}
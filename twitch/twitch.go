@@ -14,6 +14,7 @@ import (
 // For more information see:
 // https://dev.twitch.tv/docs/authentication
 var Endpoint = oauth2.Endpoint{
-	AuthURL:  "https://id.twitch.tv/oauth2/authorize",
-	TokenURL: "https://id.twitch.tv/oauth2/token",
+	AuthURL:       "https://id.twitch.tv/oauth2/authorize",
+	TokenURL:      "https://id.twitch.tv/oauth2/token",
+	DeviceAuthURL: "https://id.twitch.tv/oauth2/device",
 }